@@ -0,0 +1,41 @@
+package lumberjack
+
+import "fmt"
+
+// ACLSource 决定新建日志文件的访问控制列表（DACL）从何处复制
+type ACLSource string
+
+const (
+	// ACLDefault 不做处理，新文件使用系统默认的 DACL
+	ACLDefault ACLSource = ""
+	// ACLFromDir 让新文件重新继承日志目录上可继承的权限
+	ACLFromDir ACLSource = "directory"
+	// ACLFromPrevious 把轮转前日志文件（即刚产生的备份）的 DACL 复制到新文件，
+	// 首次创建文件时没有可复制的来源，使用系统默认的 DACL
+	ACLFromPrevious ACLSource = "previous"
+)
+
+// checkACL 校验 CopyACL 的取值
+func (l *Logger) checkACL() error {
+	switch l.CopyACL {
+	case ACLDefault, ACLFromDir, ACLFromPrevious:
+		return nil
+	default:
+		return fmt.Errorf("unknown ACL source %q", l.CopyACL)
+	}
+}
+
+// applyACL 按 CopyACL 设置新建日志文件 name 的 DACL，prev 为轮转前的日志文件
+// 被移动到的路径，首次创建时为空
+func (l *Logger) applyACL(name, prev string) error {
+	switch l.CopyACL {
+	case ACLFromDir:
+		return inheritACL(name)
+	case ACLFromPrevious:
+		if prev == "" {
+			return nil
+		}
+		return copyFileACL(prev, name)
+	}
+	return nil
+}
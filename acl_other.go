@@ -0,0 +1,14 @@
+//go:build !windows
+// +build !windows
+
+package lumberjack
+
+// inheritACL 在 Windows 以外的平台上不做任何事，权限由 FileMode 控制
+func inheritACL(_ string) error {
+	return nil
+}
+
+// copyFileACL 在 Windows 以外的平台上不做任何事
+func copyFileACL(_, _ string) error {
+	return nil
+}
@@ -0,0 +1,40 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+)
+
+func TestCopyACLUnknown(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestCopyACLUnknown", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		CopyACL:  "parent",
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	notNil(err, t)
+	notExist(logFile(dir), t)
+}
+
+func TestCopyACL(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestCopyACL", t)
+	defer os.RemoveAll(dir)
+
+	for _, src := range []ACLSource{ACLFromDir, ACLFromPrevious} {
+		l := &Logger{
+			Filename: logFile(dir),
+			CopyACL:  src,
+		}
+		_, err := l.Write([]byte("boo!"))
+		isNil(err, t)
+		newFakeTime()
+		isNil(l.Rotate(), t)
+		existsWithContent(logFile(dir), []byte{}, t)
+		isNil(l.Close(), t)
+	}
+}
@@ -0,0 +1,39 @@
+//go:build windows
+// +build windows
+
+package lumberjack
+
+import "golang.org/x/sys/windows"
+
+// inheritACL 以空的、不受保护的 DACL 替换 name 的 DACL，使其重新继承父目录
+// 上可继承的权限
+func inheritACL(name string) error {
+	acl, err := windows.ACLFromEntries(nil, nil)
+	if err != nil {
+		return err
+	}
+	return windows.SetNamedSecurityInfo(fixLongPath(name), windows.SE_FILE_OBJECT,
+		windows.DACL_SECURITY_INFORMATION|windows.UNPROTECTED_DACL_SECURITY_INFORMATION,
+		nil, nil, acl, nil)
+}
+
+// copyFileACL 把 src 的 DACL 复制到 dst，并保持 src 是否继承父目录权限的设置
+func copyFileACL(src, dst string) error {
+	sd, err := windows.GetNamedSecurityInfo(fixLongPath(src), windows.SE_FILE_OBJECT, windows.DACL_SECURITY_INFORMATION)
+	if err != nil {
+		return err
+	}
+	dacl, _, err := sd.DACL()
+	if err != nil {
+		return err
+	}
+	control, _, err := sd.Control()
+	if err != nil {
+		return err
+	}
+	info := windows.SECURITY_INFORMATION(windows.DACL_SECURITY_INFORMATION | windows.UNPROTECTED_DACL_SECURITY_INFORMATION)
+	if control&windows.SE_DACL_PROTECTED != 0 {
+		info = windows.DACL_SECURITY_INFORMATION | windows.PROTECTED_DACL_SECURITY_INFORMATION
+	}
+	return windows.SetNamedSecurityInfo(fixLongPath(dst), windows.SE_FILE_OBJECT, info, nil, nil, dacl, nil)
+}
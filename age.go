@@ -0,0 +1,78 @@
+package lumberjack
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/agessh"
+)
+
+// ageSuffix 是加密给 age 接收者的备份文件后缀
+const ageSuffix = ".age"
+
+// ageEncrypter 返回把备份加密给 AgeRecipients 的加密方式
+func (l *Logger) ageEncrypter() (*backupEncrypter, error) {
+	recipients, err := parseAgeRecipients(l.AgeRecipients)
+	if err != nil {
+		return nil, err
+	}
+	return &backupEncrypter{ageSuffix, func(w io.Writer) (io.WriteCloser, error) {
+		return age.Encrypt(w, recipients...)
+	}}, nil
+}
+
+// parseAgeRecipients 解析 age 公钥（age1...）和 SSH 公钥（ssh-ed25519、ssh-rsa）
+func parseAgeRecipients(keys []string) ([]age.Recipient, error) {
+	recipients := make([]age.Recipient, 0, len(keys))
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		var (
+			r   age.Recipient
+			err error
+		)
+		if strings.HasPrefix(key, "ssh-") {
+			r, err = agessh.ParseRecipient(key)
+		} else {
+			r, err = age.ParseX25519Recipient(key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid age recipient %q: %s", key, err)
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, nil
+}
+
+// DecryptAgeFile 用 identities 中的任一私钥解密 .age 备份 src，把明文写入 dst。
+// 也可以直接使用 age 命令行工具解密。
+func DecryptAgeFile(src, dst string, identities ...age.Identity) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("can't open encrypted backup: %s", err)
+	}
+	defer in.Close()
+
+	r, err := age.Decrypt(in, identities...)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt %s: %s", src, err)
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("can't open decrypted file: %s", err)
+	}
+	defer func() {
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(dst)
+		}
+	}()
+	if _, err := io.Copy(out, r); err != nil {
+		return fmt.Errorf("failed to decrypt %s: %s", src, err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func TestAgeRecipients(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestAgeRecipients", t)
+	defer os.RemoveAll(dir)
+
+	alice, err := age.GenerateX25519Identity()
+	isNil(err, t)
+	bob, err := age.GenerateX25519Identity()
+	isNil(err, t)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:      filename,
+		CompressSync:  true,
+		AgeRecipients: []string{alice.Recipient().String(), bob.Recipient().String()},
+	}
+	defer l.Close()
+
+	b := []byte("for your eyes only")
+	_, err = l.Write(b)
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+
+	backup := backupFile(dir)
+	notExist(backup, t)
+	exists(backup+ageSuffix, t)
+
+	// 任一接收者的私钥都能解密
+	for _, id := range []age.Identity{alice, bob} {
+		plain := filepath.Join(dir, "plain")
+		isNil(DecryptAgeFile(backup+ageSuffix, plain, id), t)
+		existsWithContent(plain, b, t)
+		isNil(os.Remove(plain), t)
+	}
+
+	other, err := age.GenerateX25519Identity()
+	isNil(err, t)
+	notNil(DecryptAgeFile(backup+ageSuffix, filepath.Join(dir, "plain"), other), t)
+	notExist(filepath.Join(dir, "plain"), t)
+
+	// .age 备份仍被识别为备份
+	files, err := l.oldLogFiles()
+	isNil(err, t)
+	equals(1, len(files), t)
+}
+
+func TestAgeRecipientsInvalid(t *testing.T) {
+	_, err := parseAgeRecipients([]string{"age1notakey"})
+	notNil(err, t)
+
+	l := &Logger{Encrypt: true, EncryptionKey: testKey, AgeRecipients: []string{"age1x"}}
+	_, err = l.encrypter()
+	notNil(err, t)
+}
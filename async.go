@@ -0,0 +1,159 @@
+package lumberjack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// DropPolicy 决定异步写入队列已满时如何处理新的写入
+type DropPolicy string
+
+const (
+	// DropBlock 阻塞 Write 直到队列有空位，不丢弃日志
+	DropBlock DropPolicy = "block"
+	// DropNewest 丢弃本次写入，Write 立即返回
+	DropNewest DropPolicy = "drop-newest"
+	// DropOldest 丢弃队列中最早的一条写入，为本次写入腾出空位
+	DropOldest DropPolicy = "drop-oldest"
+)
+
+// errClosed 是 Logger 关闭后写入返回的错误
+var errClosed = errors.New("logger is closed")
+
+// asyncItem 是异步队列中的一项。done 不为 nil 时表示 Flush 的屏障：
+// 后台 goroutine 处理到该项时刷新缓冲区，并把结果发送到 done。
+type asyncItem struct {
+	p    []byte
+	done chan error
+}
+
+// writeAsync 把 p 的副本放入异步队列，由后台 goroutine 写入文件。
+// DropBlock 策略下等待队列空位时 ctx 结束则返回 ctx 的错误。
+func (l *Logger) writeAsync(ctx context.Context, p []byte) (n int, err error) {
+	defer func() {
+		if err != nil {
+			l.metrics.writeErrors.Add(1)
+		}
+	}()
+
+	if int64(len(p)) > l.max() {
+		return 0, fmt.Errorf(
+			"write length %d exceeds maximum file size %d", len(p), l.max(),
+		)
+	}
+	switch l.DropPolicy {
+	case "", DropBlock, DropNewest, DropOldest:
+	default:
+		return 0, fmt.Errorf("unknown drop policy %q", l.DropPolicy)
+	}
+
+	l.asyncMu.RLock()
+	defer l.asyncMu.RUnlock()
+	if l.asyncClosed {
+		return 0, errClosed
+	}
+	l.asyncOnce.Do(l.startAsync)
+
+	// io.Writer 不允许保留调用方的切片
+	item := asyncItem{p: append([]byte(nil), p...)}
+	switch l.DropPolicy {
+	case DropNewest:
+		select {
+		case l.asyncQ <- item:
+		default:
+			l.metrics.droppedWrites.Add(1)
+		}
+	case DropOldest:
+		l.enqueueDropOldest(item)
+	default:
+		select {
+		case l.asyncQ <- item:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+	return len(p), nil
+}
+
+// enqueueDropOldest 在队列已满时丢弃最早的写入后入队。Flush 的屏障不会被
+// 丢弃，而是重新放回队尾。
+func (l *Logger) enqueueDropOldest(item asyncItem) {
+	for {
+		select {
+		case l.asyncQ <- item:
+			return
+		default:
+		}
+		select {
+		case old := <-l.asyncQ:
+			if old.done != nil {
+				l.asyncQ <- old
+				continue
+			}
+			l.metrics.droppedWrites.Add(1)
+		default:
+		}
+	}
+}
+
+// startAsync 创建异步队列并启动写入 goroutine
+func (l *Logger) startAsync() {
+	l.asyncQ = make(chan asyncItem, l.AsyncQueue)
+	l.asyncWg.Add(1)
+	go l.asyncRun()
+}
+
+// asyncRun 依次把队列中的数据写入文件，写入失败时通过 Errors 上报
+func (l *Logger) asyncRun() {
+	defer l.asyncWg.Done()
+	for item := range l.asyncQ {
+		if item.done != nil {
+			item.done <- l.syncFlush()
+			continue
+		}
+		if _, err := l.write(item.p); err != nil {
+			l.reportError(OpWrite, err)
+			l.logDebug("异步写入失败: %v，文件: %s", err, l.filename())
+		}
+	}
+}
+
+// flushAsync 等待异步队列中此前的写入全部完成并刷新缓冲区，ctx 结束时返回
+// ctx 的错误。未启用异步写入或已关闭时 ok 为 false。
+func (l *Logger) flushAsync(ctx context.Context) (ok bool, err error) {
+	l.asyncMu.RLock()
+	if l.asyncClosed || l.asyncQ == nil {
+		l.asyncMu.RUnlock()
+		return false, nil
+	}
+	done := make(chan error, 1)
+	select {
+	case l.asyncQ <- asyncItem{done: done}:
+	case <-ctx.Done():
+		l.asyncMu.RUnlock()
+		return true, ctx.Err()
+	}
+	l.asyncMu.RUnlock()
+	select {
+	case err := <-done:
+		return true, err
+	case <-ctx.Done():
+		return true, ctx.Err()
+	}
+}
+
+// closeAsync 停止接受新的异步写入，并等待队列中已有的数据写入完成
+func (l *Logger) closeAsync() {
+	l.asyncMu.Lock()
+	if l.asyncClosed {
+		l.asyncMu.Unlock()
+		return
+	}
+	l.asyncClosed = true
+	if l.asyncQ != nil {
+		close(l.asyncQ)
+	}
+	l.asyncMu.Unlock()
+	l.asyncWg.Wait()
+}
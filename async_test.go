@@ -0,0 +1,141 @@
+package lumberjack
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAsyncQueue(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestAsyncQueue", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		AsyncQueue: 16,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+	// 写入方可以立即复用切片
+	copy(b, "xxxx")
+
+	isNil(l.Flush(), t)
+	existsWithContent(filename, []byte("boo!"), t)
+
+	// Close 等待队列中的数据写完
+	_, err = l.Write([]byte("foo"))
+	isNil(err, t)
+	isNil(l.Close(), t)
+	existsWithContent(filename, []byte("boo!foo"), t)
+
+	_, err = l.Write([]byte("bar"))
+	notNil(err, t)
+	isNil(l.Flush(), t)
+}
+
+// fillAsyncQueue 使写入 goroutine 阻塞在 l.mu 上并写满长度为 2 的队列，
+// 返回解除阻塞的函数
+func fillAsyncQueue(l *Logger, t testing.TB) (release func()) {
+	l.mu.Lock()
+	_, err := l.Write([]byte("a"))
+	isNilUp(err, t, 1)
+	// 等待写入 goroutine 取走 "a" 后阻塞
+	for i := 0; i < 100 && len(l.asyncQ) > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, s := range []string{"b", "c"} {
+		_, err := l.Write([]byte(s))
+		isNilUp(err, t, 1)
+	}
+	return l.mu.Unlock
+}
+
+func TestDropNewest(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestDropNewest", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		AsyncQueue: 2,
+		DropPolicy: DropNewest,
+	}
+	defer l.Close()
+
+	release := fillAsyncQueue(l, t)
+	_, err := l.Write([]byte("d"))
+	isNil(err, t)
+	release()
+
+	isNil(l.Flush(), t)
+	existsWithContent(filename, []byte("abc"), t)
+	equals(int64(1), l.Stats().DroppedWrites, t)
+}
+
+func TestDropOldest(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestDropOldest", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		AsyncQueue: 2,
+		DropPolicy: DropOldest,
+	}
+	defer l.Close()
+
+	release := fillAsyncQueue(l, t)
+	_, err := l.Write([]byte("d"))
+	isNil(err, t)
+	release()
+
+	isNil(l.Flush(), t)
+	existsWithContent(filename, []byte("acd"), t)
+	equals(int64(1), l.Stats().DroppedWrites, t)
+}
+
+func TestAsyncWriteError(t *testing.T) {
+	dir := makeTempDir("TestAsyncWriteError", t)
+	defer os.RemoveAll(dir)
+
+	// 日志目录被同名文件占用，后台写入失败
+	notDir := filepath.Join(dir, "file")
+	isNil(os.WriteFile(notDir, nil, 0644), t)
+
+	l := &Logger{
+		Filename:   filepath.Join(notDir, "foobar.log"),
+		AsyncQueue: 4,
+	}
+	defer l.Close()
+	errs := l.Errors()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	select {
+	case err := <-errs:
+		var ae *AsyncError
+		assert(errors.As(err, &ae), t, "expected *AsyncError, got %T", err)
+		equals(OpWrite, ae.Op, t)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for async error")
+	}
+
+	l2 := &Logger{Filename: logFile(dir), AsyncQueue: 1, DropPolicy: "drop-random"}
+	defer l2.Close()
+	_, err = l2.Write([]byte("boo!"))
+	notNil(err, t)
+}
@@ -0,0 +1,101 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// AuditAction 是审计记录中的操作类型
+type AuditAction string
+
+const (
+	// AuditRotate 当前日志文件被轮转为备份 Path
+	AuditRotate AuditAction = "rotate"
+	// AuditCompress 备份 Source 被压缩、加密、打包或经 Processors 处理为 Path
+	AuditCompress AuditAction = "compress"
+	// AuditUpload 备份 Path 上传成功
+	AuditUpload AuditAction = "upload"
+	// AuditTrash 过期的备份 Source 被移入 TrashDir，新路径为 Path
+	AuditTrash AuditAction = "trash"
+	// AuditDelete 文件 Path 被删除
+	AuditDelete AuditAction = "delete"
+)
+
+// AuditRecord 是审计文件中的一条记录，每条记录占一行 JSON
+type AuditRecord struct {
+	Time   time.Time   `json:"time"`             // 操作完成的时间
+	Action AuditAction `json:"action"`           // 操作类型
+	Path   string      `json:"path"`             // 操作产生或删除的文件
+	Source string      `json:"source,omitempty"` // 被处理或移动的原文件
+	Size   int64       `json:"size"`             // Path 的大小，删除时为删除前的大小
+}
+
+// auditMu 串行化审计记录的写入，多个 Logger 可能共用同一个审计文件
+var auditMu sync.Mutex
+
+// auditFile 返回审计文件的路径，相对路径相对于日志文件所在目录
+func (l *Logger) auditFile() string {
+	if filepath.IsAbs(l.AuditFile) {
+		return l.AuditFile
+	}
+	return filepath.Join(l.dir(), l.AuditFile)
+}
+
+// audit 向 AuditFile 追加一条记录，未设置 AuditFile 时什么也不做。写入失败
+// 通过 Errors 通道上报，不影响操作本身。
+func (l *Logger) audit(action AuditAction, path, source string, size int64) {
+	if l.AuditFile == "" {
+		return
+	}
+	rec := AuditRecord{
+		Time:   l.now(),
+		Action: action,
+		Path:   path,
+		Source: source,
+		Size:   size,
+	}
+	if err := l.appendAudit(rec); err != nil {
+		l.reportError(OpAudit, fmt.Errorf("can't write audit record: %s", err))
+	}
+}
+
+// appendAudit 把 rec 追加到审计文件并刷盘
+func (l *Logger) appendAudit(rec AuditRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	name := l.auditFile()
+	if err := l.fsys().MkdirAll(filepath.Dir(name), l.dirMode()); err != nil {
+		return err
+	}
+	f, err := l.fsys().OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// auditSize 返回 name 的大小，文件不存在时为 0
+func (l *Logger) auditSize(name string) int64 {
+	if info, err := l.stat(name); err == nil {
+		return info.Size()
+	}
+	return 0
+}
@@ -0,0 +1,95 @@
+package lumberjack
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// readAudit 读取审计文件中的全部记录
+func readAudit(name string, t testing.TB) []AuditRecord {
+	f, err := os.Open(name)
+	isNil(err, t)
+	defer f.Close()
+	var records []AuditRecord
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var rec AuditRecord
+		isNil(json.Unmarshal(s.Bytes(), &rec), t)
+		records = append(records, rec)
+	}
+	isNil(s.Err(), t)
+	return records
+}
+
+func TestAuditFile(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestAuditFile", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:     logFile(dir),
+		AuditFile:    "audit.jsonl",
+		Compress:     true,
+		CompressSync: true,
+		MaxBackups:   1,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	first := backupFile(dir)
+
+	newFakeTime()
+	_, err = l.Write([]byte("foo"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	second := backupFile(dir)
+
+	records := readAudit(filepath.Join(dir, "audit.jsonl"), t)
+	var actions []AuditAction
+	for _, rec := range records {
+		actions = append(actions, rec.Action)
+	}
+	equals([]AuditAction{AuditRotate, AuditCompress, AuditRotate, AuditDelete, AuditCompress}, actions, t)
+
+	equals(first, records[0].Path, t)
+	equals(logFile(dir), records[0].Source, t)
+	equals(int64(4), records[0].Size, t)
+	equals(first+compressSuffix, records[1].Path, t)
+	equals(first, records[1].Source, t)
+	equals(second, records[2].Path, t)
+	equals(first+compressSuffix, records[3].Path, t)
+	assert(records[3].Size > 0, t, "deleted size not recorded: %+v", records[3])
+	equals(second+compressSuffix, records[4].Path, t)
+	equals(fakeTime().UnixNano(), records[4].Time.UnixNano(), t)
+
+	// 审计文件不被当作备份
+	backups, err := l.Backups()
+	isNil(err, t)
+	equals(1, len(backups), t)
+}
+
+func TestAuditTrash(t *testing.T) {
+	dir := makeTempDir("TestAuditTrash", t)
+	defer os.RemoveAll(dir)
+
+	names := makeBackups(dir, t, fakeTime(), fakeTime().Add(-time.Hour))
+	audit := filepath.Join(dir, "audit", "audit.jsonl")
+	l := &Logger{Filename: logFile(dir), AuditFile: audit, TrashDir: "trash", MaxBackups: 1}
+	defer l.Close()
+	_, err := l.Purge()
+	isNil(err, t)
+
+	records := readAudit(audit, t)
+	equals(1, len(records), t)
+	equals(AuditTrash, records[0].Action, t)
+	equals(filepath.Join(dir, "trash", names[1]), records[0].Path, t)
+	equals(filepath.Join(dir, names[1]), records[0].Source, t)
+	equals(int64(4), records[0].Size, t)
+}
@@ -0,0 +1,51 @@
+package lumberjack
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// azureAPIVersion 是请求使用的 Blob 服务 REST API 版本，单次 Put Blob
+// 最大支持 5000 MiB
+const azureAPIVersion = "2020-04-08"
+
+// AzureBlobUploader 以 Put Blob 请求把备份写入 Azure Blob Storage 的块 Blob，
+// Blob 名为 Prefix 与备份文件名拼接的结果。使用 SAS 令牌鉴权，不依赖 Azure SDK。
+type AzureBlobUploader struct {
+	// ContainerURL 是容器地址，例如
+	// https://account.blob.core.windows.net/container
+	ContainerURL string
+
+	// SASToken 是具有写权限的共享访问签名（不含开头的 "?"）。也可以直接包含
+	// 在 ContainerURL 的查询参数中。
+	SASToken string
+
+	// Prefix 是 Blob 名前缀，例如 "logs/app"
+	Prefix string
+
+	// Client 是发送请求使用的 HTTP 客户端，默认为 http.DefaultClient
+	Client *http.Client
+}
+
+// Upload 上传本地文件 path
+func (a *AzureBlobUploader) Upload(ctx context.Context, path string) error {
+	base, query, _ := strings.Cut(a.ContainerURL, "?")
+	var segments []string
+	for _, s := range strings.Split(objectName(a.Prefix, path), "/") {
+		segments = append(segments, url.PathEscape(s))
+	}
+	u := strings.TrimSuffix(base, "/") + "/" + strings.Join(segments, "/")
+	if sas := strings.TrimPrefix(a.SASToken, "?"); sas != "" {
+		query = sas
+	}
+	if query != "" {
+		u += "?" + query
+	}
+	return putFile(ctx, httpClient(a.Client), http.MethodPut, u, path, map[string]string{
+		"x-ms-blob-type": "BlockBlob",
+		"x-ms-version":   azureAPIVersion,
+		"Content-Type":   "application/octet-stream",
+	})
+}
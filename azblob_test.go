@@ -0,0 +1,45 @@
+package lumberjack
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAzureBlobUploader(t *testing.T) {
+	dir := makeTempDir("TestAzureBlobUploader", t)
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "app 2001.log")
+	isNil(os.WriteFile(name, []byte("boo!"), 0600), t)
+
+	var gotMethod, gotURI, gotType string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotURI = r.RequestURI
+		gotType = r.Header.Get("x-ms-blob-type")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	a := &AzureBlobUploader{
+		ContainerURL: srv.URL + "/container/",
+		SASToken:     "?sv=1&sig=x",
+		Prefix:       "logs",
+	}
+	isNil(a.Upload(context.Background(), name), t)
+	equals(http.MethodPut, gotMethod, t)
+	equals("/container/logs/app%202001.log?sv=1&sig=x", gotURI, t)
+	equals("BlockBlob", gotType, t)
+	equals("boo!", string(gotBody), t)
+
+	// SAS 令牌也可以包含在 ContainerURL 中
+	a = &AzureBlobUploader{ContainerURL: srv.URL + "/container?sig=y"}
+	isNil(a.Upload(context.Background(), name), t)
+	equals("/container/app%202001.log?sig=y", gotURI, t)
+}
@@ -0,0 +1,135 @@
+package lumberjack
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// multiCloser 读取 Reader，Close 时按相反顺序关闭 closers
+type multiCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (m *multiCloser) Close() error {
+	var err error
+	for i := len(m.closers) - 1; i >= 0; i-- {
+		if errClose := m.closers[i].Close(); err == nil {
+			err = errClose
+		}
+	}
+	return err
+}
+
+// OpenBackup 打开 Backups 返回的备份 info，返回其原始内容：压缩的备份在读取
+// 时解压，使用 EncryptionKey 或 EncryptionKeyFile 加密的备份在读取时解密，
+// 调用方无需区分压缩与未压缩的文件。按天打包的归档返回其中的 tar 数据流。
+// 加密给 age 接收者的备份以及由自定义 Compressor 压缩的备份无法打开。
+func (l *Logger) OpenBackup(info BackupInfo) (io.ReadCloser, error) {
+	r, err := l.openBackupReader(info.Path)
+	if err != nil {
+		return nil, fmt.Errorf("can't open backup %s: %s", info.Name, err)
+	}
+	return r, nil
+}
+
+// openBackupReader 打开备份 name，返回解密、解压后的内容。按天打包的归档
+// 只去掉加密和 gzip 层，返回 tar 数据流。加密给 age 接收者的备份和自定义
+// Compressor 压缩的备份无法读取。
+func (l *Logger) openBackupReader(name string) (io.ReadCloser, error) {
+	if strings.HasSuffix(name, ageSuffix) {
+		return nil, errors.New("backups encrypted to age recipients can't be read")
+	}
+	f, err := l.fsys().OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	rc := &multiCloser{Reader: f, closers: []io.Closer{f}}
+
+	plain := name
+	if strings.HasSuffix(name, encSuffix) {
+		key, err := l.encryptionKey()
+		if err != nil {
+			rc.Close()
+			return nil, err
+		}
+		if rc.Reader, err = NewDecryptReader(f, key); err != nil {
+			rc.Close()
+			return nil, err
+		}
+		plain = strings.TrimSuffix(name, encSuffix)
+	}
+
+	switch suffix := plain[len(trimCompressSuffix(plain)):]; suffix {
+	case "":
+	case compressSuffix:
+		gz, err := gzip.NewReader(rc.Reader)
+		if err != nil {
+			rc.Close()
+			return nil, fmt.Errorf("can't open gzip backup: %s", err)
+		}
+		rc.Reader = gz
+		rc.closers = append(rc.closers, gz)
+	case zstdSuffix:
+		zr, err := zstd.NewReader(rc.Reader)
+		if err != nil {
+			rc.Close()
+			return nil, fmt.Errorf("can't open zstd backup: %s", err)
+		}
+		rc.Reader = zr
+		rc.closers = append(rc.closers, zr.IOReadCloser())
+	case zipSuffix:
+		// zip 需要随机访问，加密后的 zip 先读入内存
+		var data []byte
+		if ra, ok := f.(io.ReaderAt); ok && rc.Reader == io.Reader(f) {
+			info, err := f.Stat()
+			if err != nil {
+				rc.Close()
+				return nil, err
+			}
+			entry, err := zipEntry(ra, info.Size())
+			if err != nil {
+				rc.Close()
+				return nil, err
+			}
+			rc.Reader = entry
+			rc.closers = append(rc.closers, entry)
+			break
+		}
+		if data, err = io.ReadAll(rc.Reader); err != nil {
+			rc.Close()
+			return nil, err
+		}
+		entry, err := zipEntry(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			rc.Close()
+			return nil, err
+		}
+		rc.Reader = entry
+		rc.closers = append(rc.closers, entry)
+	default:
+		rc.Close()
+		return nil, fmt.Errorf("can't read backups compressed with custom compressor %q", suffix)
+	}
+	return rc, nil
+}
+
+// zipEntry 打开 zip 备份中唯一的条目
+func zipEntry(r io.ReaderAt, size int64) (io.ReadCloser, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("can't open zip backup: %s", err)
+	}
+	if len(zr.File) == 0 {
+		return nil, errors.New("zip backup is empty")
+	}
+	return zr.File[0].Open()
+}
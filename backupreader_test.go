@@ -0,0 +1,67 @@
+package lumberjack
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenBackupReader(t *testing.T) {
+	dir := makeTempDir("TestOpenBackupReader", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir)}
+	content := []byte("boo!\nfoo\n")
+	for _, codec := range []string{compressionGzip, compressionZstd, compressionZip} {
+		src := filepath.Join(dir, "backup-"+codec+".log")
+		isNil(os.WriteFile(src, content, 0644), t)
+		suffix, err := compressionSuffix(codec)
+		isNil(err, t)
+		isNil(compressCodec(src, src+suffix, codec, 0), t)
+
+		r, err := l.openBackupReader(src + suffix)
+		isNil(err, t)
+		got, err := io.ReadAll(r)
+		isNil(err, t)
+		isNil(r.Close(), t)
+		equals(string(content), string(got), t)
+	}
+
+	_, err := l.openBackupReader(filepath.Join(dir, "backup.log"+ageSuffix))
+	notNil(err, t)
+}
+
+func TestOpenBackup(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestOpenBackup", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), Compress: true, CompressSync: true}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	newFakeTime()
+	l.Compress = false
+	_, err = l.Write([]byte("foo\n"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+
+	backups, err := l.Backups()
+	isNil(err, t)
+	equals(2, len(backups), t)
+	assert(!backups[0].Compressed && backups[1].Compressed, t, "unexpected backups: %+v", backups)
+	for i, want := range []string{"foo\n", "boo!\n"} {
+		r, err := l.OpenBackup(backups[i])
+		isNil(err, t)
+		got, err := io.ReadAll(r)
+		isNil(err, t)
+		isNil(r.Close(), t)
+		equals(want, string(got), t)
+	}
+
+	_, err = l.OpenBackup(BackupInfo{Name: "missing", Path: filepath.Join(dir, "missing")})
+	notNil(err, t)
+}
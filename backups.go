@@ -0,0 +1,106 @@
+package lumberjack
+
+import "time"
+
+// CleanupAction 是清理过程会对一个备份执行的操作，见 PreviewCleanup
+type CleanupAction string
+
+const (
+	// CleanupDelete 表示备份会按保留策略被删除（设置 TrashDir 时移入回收目录）
+	CleanupDelete CleanupAction = "delete"
+	// CleanupBundle 表示备份会被打包进所属日期的归档
+	CleanupBundle CleanupAction = "bundle"
+	// CleanupCompress 表示备份会被压缩（设置加密时随后加密）
+	CleanupCompress CleanupAction = "compress"
+	// CleanupEncrypt 表示已压缩的备份只会被加密
+	CleanupEncrypt CleanupAction = "encrypt"
+)
+
+// BackupInfo 描述 Logger 管理的一个备份文件
+type BackupInfo struct {
+	Name       string        `json:"name"`             // 文件名
+	Path       string        `json:"path"`             // 完整路径
+	Timestamp  time.Time     `json:"timestamp"`        // 文件名中编码的轮转时间，无法从文件名得到时为修改时间
+	Size       int64         `json:"size"`             // 文件大小
+	Compressed bool          `json:"compressed"`       // 是否已压缩（含按天打包的归档）
+	Encrypted  bool          `json:"encrypted"`        // 是否已加密
+	Held       bool          `json:"held"`             // 是否被 Hold 保留
+	Action     CleanupAction `json:"action,omitempty"` // 清理将执行的操作，仅 PreviewCleanup 设置
+}
+
+// Backups 返回 Logger 管理的所有备份，按从新到旧排序（序号命名模式下按序号
+// 从小到大）。识别规则与清理时一致，包括 BackupDir、按日期分区的子目录、
+// FilenamePattern 以及压缩、加密和按天打包后的文件。
+func (l *Logger) Backups() ([]BackupInfo, error) {
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return nil, err
+	}
+	backups := make([]BackupInfo, 0, len(files))
+	for _, f := range files {
+		backups = append(backups, l.backupInfo(f))
+	}
+	return backups, nil
+}
+
+// PreviewCleanup 按当前配置演练一次清理，返回会被删除、打包、压缩或加密的
+// 备份及对应的 Action，不修改任何文件。可以在生产目录上验证新的保留策略。
+// 结果先列出删除的备份，再列出其余操作，各自按从新到旧排序；被 Hold 保留的
+// 备份不会出现。回收目录中过期文件的清除不在结果中。
+func (l *Logger) PreviewCleanup() ([]BackupInfo, error) {
+	comp, err := l.compressor()
+	if err != nil {
+		return nil, err
+	}
+	enc, err := l.encrypter()
+	if err != nil {
+		return nil, err
+	}
+
+	l.backupMu.Lock()
+	defer l.backupMu.Unlock()
+
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return nil, err
+	}
+	files, remove := l.planRemoval(l.withoutHeld(files), time.Time{})
+
+	var preview []BackupInfo
+	add := func(f logInfo, action CleanupAction) {
+		b := l.backupInfo(f)
+		b.Action = action
+		preview = append(preview, b)
+	}
+	for _, f := range remove {
+		add(f, CleanupDelete)
+	}
+
+	today := l.backupDay(l.now())
+	bundle := l.BundleDaily && !l.NumberedBackups
+	for _, f := range files {
+		_, archive := l.bundleTime(f.Name())
+		switch {
+		case bundle && !archive && l.backupDay(f.timestamp) < today:
+			add(f, CleanupBundle)
+		case comp != nil && !isCompressed(trimEncryptSuffix(f.Name())):
+			add(f, CleanupCompress)
+		case enc != nil && !isEncrypted(f.Name()):
+			add(f, CleanupEncrypt)
+		}
+	}
+	return preview, nil
+}
+
+// backupInfo 返回备份 f 的描述
+func (l *Logger) backupInfo(f logInfo) BackupInfo {
+	return BackupInfo{
+		Name:       f.Name(),
+		Path:       f.path(),
+		Timestamp:  f.timestamp,
+		Size:       f.Size(),
+		Compressed: isCompressed(trimEncryptSuffix(f.Name())),
+		Encrypted:  isEncrypted(f.Name()),
+		Held:       l.isHeld(f.path()),
+	}
+}
@@ -0,0 +1,111 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackups(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestBackups", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:     logFile(dir),
+		Compress:     true,
+		CompressSync: true,
+		MaxBackups:   5,
+	}
+	defer l.Close()
+
+	backups, err := l.Backups()
+	isNil(err, t)
+	equals(0, len(backups), t)
+
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	first := backupFile(dir) + compressSuffix
+	firstTime := fakeTime().UTC()
+
+	newFakeTime()
+	_, err = l.Write([]byte("foo"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	second := backupFile(dir) + compressSuffix
+
+	backups, err = l.Backups()
+	isNil(err, t)
+	equals(2, len(backups), t)
+	equals(filepath.Base(second), backups[0].Name, t)
+	equals(second, backups[0].Path, t)
+	equals(first, backups[1].Path, t)
+	equals(firstTime.Format(backupTimeFormat), backups[1].Timestamp.Format(backupTimeFormat), t)
+	assert(backups[0].Compressed && !backups[0].Encrypted, t, "unexpected flags: %+v", backups[0])
+
+	info, err := os.Stat(first)
+	isNil(err, t)
+	equals(info.Size(), backups[1].Size, t)
+}
+
+func TestPreviewCleanup(t *testing.T) {
+	dir := makeTempDir("TestPreviewCleanup", t)
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+	names := makeBackups(dir, t,
+		now.Add(-time.Hour),
+		now.Add(-2*time.Hour),
+		now.Add(-3*time.Hour),
+		now.Add(-4*time.Hour),
+	)
+	gz := filepath.Join(dir, names[1]+compressSuffix)
+	isNil(os.Rename(filepath.Join(dir, names[1]), gz), t)
+
+	l := &Logger{Filename: logFile(dir), MaxBackups: 2, Compress: true}
+	l.Clock = &manualClock{now: now}
+	defer l.Close()
+	before := remainingBackups(dir, t)
+
+	preview, err := l.PreviewCleanup()
+	isNil(err, t)
+	equals(3, len(preview), t)
+	equals(names[2], preview[0].Name, t)
+	equals(CleanupDelete, preview[0].Action, t)
+	equals(names[3], preview[1].Name, t)
+	equals(CleanupDelete, preview[1].Action, t)
+	equals(names[0], preview[2].Name, t)
+	equals(CleanupCompress, preview[2].Action, t)
+
+	// 演练不修改任何文件
+	equals(before, remainingBackups(dir, t), t)
+
+	// 被保留的备份不参与清理
+	isNil(l.Hold(filepath.Join(dir, names[3])), t)
+	preview, err = l.PreviewCleanup()
+	isNil(err, t)
+	equals(2, len(preview), t)
+	equals(names[2], preview[0].Name, t)
+}
+
+func TestPreviewCleanupBundle(t *testing.T) {
+	dir := makeTempDir("TestPreviewCleanupBundle", t)
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+	names := makeBackups(dir, t, now.Add(-time.Hour), now.Add(-24*time.Hour))
+
+	l := &Logger{Filename: logFile(dir), BundleDaily: true}
+	l.Clock = &manualClock{now: now}
+	defer l.Close()
+
+	preview, err := l.PreviewCleanup()
+	isNil(err, t)
+	equals(1, len(preview), t)
+	equals(names[1], preview[0].Name, t)
+	equals(CleanupBundle, preview[0].Action, t)
+	equals([]string{names[1], names[0]}, remainingBackups(dir, t), t)
+}
@@ -0,0 +1,55 @@
+package lumberjack
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+)
+
+// output 返回写入当前文件使用的 writer，设置 StreamCompress 时为压缩流，配置了
+// BufferSize 时再包装一层缓冲区。
+// 调用方必须持有 l.mu，且 l.file 不为 nil。
+func (l *Logger) output() io.Writer {
+	var w io.Writer = l.file
+	if l.gz != nil {
+		w = l.gz
+	}
+	if l.BufferSize <= 0 {
+		return w
+	}
+	if l.buf == nil {
+		l.buf = bufio.NewWriterSize(w, l.BufferSize)
+	}
+	return l.buf
+}
+
+// flush 把缓冲区和压缩流中的数据写入当前文件。调用方必须持有 l.mu。
+func (l *Logger) flush() error {
+	if l.buf != nil {
+		if err := l.buf.Flush(); err != nil {
+			return fmt.Errorf("can't flush log buffer: %s", err)
+		}
+	}
+	return l.flushStream()
+}
+
+// Flush 把 BufferSize 缓冲区中尚未写入的数据写入日志文件。未设置 BufferSize
+// 时不做任何事。Close 以及轮转前会自动刷新。设置了 AsyncQueue 时先等待队列
+// 中此前的写入完成。Mirror 实现了 Flush() error 时一并刷新。
+func (l *Logger) Flush() error {
+	if ok, err := l.flushAsync(context.Background()); ok {
+		return err
+	}
+	return l.syncFlush()
+}
+
+// syncFlush 在持有 l.mu 的情况下刷新缓冲区
+func (l *Logger) syncFlush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.flush(); err != nil {
+		return err
+	}
+	return l.flushMirror()
+}
@@ -0,0 +1,67 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+)
+
+func TestBufferSize(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestBufferSize", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		BufferSize: 1024,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	existsWithContent(filename, []byte{}, t)
+	equals(int64(4), l.CurrentSize(), t)
+
+	isNil(l.Flush(), t)
+	existsWithContent(filename, []byte("boo!"), t)
+
+	// 轮转前刷新到被轮转的文件
+	_, err = l.Write([]byte("foo"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	existsWithContent(backupFile(dir), []byte("boo!foo"), t)
+
+	// Close 时刷新
+	_, err = l.Write([]byte("bar"))
+	isNil(err, t)
+	existsWithContent(filename, []byte{}, t)
+	isNil(l.Close(), t)
+	existsWithContent(filename, []byte("bar"), t)
+}
+
+func TestBufferSizeOverflow(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestBufferSizeOverflow", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		BufferSize: 4,
+	}
+	defer l.Close()
+
+	// 缓冲区满时写入文件
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	_, err = l.Write([]byte("foo"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("boo!"), t)
+
+	// 未设置 BufferSize 时 Flush 不做任何事
+	l2 := &Logger{Filename: filename + ".other"}
+	defer l2.Close()
+	isNil(l2.Flush(), t)
+}
@@ -0,0 +1,321 @@
+package lumberjack
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// errBundleSealed 表示当天已有的归档无法在本机解密，新的备份不能追加进去
+var errBundleSealed = errors.New("existing bundle is sealed to age recipients")
+
+const (
+	// bundleDayLayout 是按天归档文件名中的日期格式
+	bundleDayLayout = "2006-01-02"
+	bundleSuffix    = ".tar.gz"
+)
+
+// bundleName 返回某一天的归档文件名（不含目录），形如 name-2006-01-02.tar.gz
+func (l *Logger) bundleName(day string) string {
+	prefix, _ := l.prefixAndExt()
+	return prefix + day + bundleSuffix
+}
+
+// bundleTime 识别按天归档的文件名（可带加密后缀），返回该日零点
+func (l *Logger) bundleTime(filename string) (time.Time, bool) {
+	prefix, _ := l.prefixAndExt()
+	filename = trimEncryptSuffix(filename)
+	if !strings.HasPrefix(filename, prefix) || !strings.HasSuffix(filename, bundleSuffix) {
+		return time.Time{}, false
+	}
+	day := filename[len(prefix) : len(filename)-len(bundleSuffix)]
+	t, err := time.ParseInLocation(bundleDayLayout, day, l.location())
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// backupDay 返回备份所属的日历日。日期与备份文件名中时间戳的时区一致，
+// 由 Location、TimeZone 或 LocalTime 决定。
+func (l *Logger) backupDay(t time.Time) string {
+	return t.In(l.location()).Format(bundleDayLayout)
+}
+
+// bundleDaily 把今天之前的备份按日历日打包为 name-YYYY-MM-DD.tar.gz 并删除
+// 原文件，返回未被打包的备份和新生成的归档。当天已有归档时把新的备份追加进去。
+func (l *Logger) bundleDaily(files []logInfo) ([]logInfo, error) {
+	today := l.backupDay(l.now())
+
+	days := make(map[string][]logInfo)
+	var remaining []logInfo
+	for _, f := range files {
+		if _, ok := l.bundleTime(f.Name()); ok {
+			remaining = append(remaining, f)
+			continue
+		}
+		day := l.backupDay(f.timestamp)
+		if day >= today {
+			remaining = append(remaining, f)
+			continue
+		}
+		days[day] = append(days[day], f)
+	}
+
+	var firstErr error
+	for day, group := range days {
+		b, err := l.writeBundle(day, group)
+		if err == errBundleSealed {
+			l.logDebug("当天的归档已加密给 age 接收者，无法追加，备份单独保留: %s", day)
+			remaining = append(remaining, group...)
+			continue
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			remaining = append(remaining, group...)
+			continue
+		}
+		// 新生成的归档随其他备份一起参与后续的加密
+		remaining = append(remaining, b)
+	}
+	sort.Sort(byFormatTime(remaining))
+	return remaining, firstErr
+}
+
+// BundleProcessor 返回把备份打包进所属日期归档 name-YYYY-MM-DD.tar.gz 的
+// 处理器，归档格式与 BundleDaily 相同，之后的步骤（加密、上传等）作用于该
+// 归档。与 BundleDaily 只打包今天之前的备份不同，流水线对每个备份只执行一次，
+// 因此当天的备份也会立即追加进当天的归档，每次追加都会重写整个归档。
+//
+// 已加密的归档需要 Logger 的 EncryptionKey 或 EncryptionKeyFile 才能追加；
+// 加密给 age 接收者的归档无法追加，备份保持原样交给下一步。该处理器只能用于
+// Logger 的 Processors，NumberedBackups 模式下不做任何处理。
+func BundleProcessor() Processor {
+	return ProcessorFunc(func(ctx context.Context, path string) error {
+		step := contextStep(ctx)
+		if step == nil {
+			return errors.New("BundleProcessor can only be used in Logger.Processors")
+		}
+		return step.l.bundleBackup(step, path)
+	})
+}
+
+// bundleBackup 把备份 path 打包进所属日期的归档，并把归档记录为 step 的输出
+func (l *Logger) bundleBackup(step *processStep, path string) error {
+	if l.NumberedBackups {
+		return nil
+	}
+	if _, ok := l.bundleTime(filepath.Base(path)); ok {
+		return nil
+	}
+
+	l.backupMu.Lock()
+	defer l.backupMu.Unlock()
+
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.path() != path {
+			continue
+		}
+		b, err := l.writeBundle(l.backupDay(f.timestamp), []logInfo{f})
+		if err == errBundleSealed {
+			l.logDebug("当天的归档已加密给 age 接收者，无法追加，备份单独保留: %s", path)
+			return nil
+		}
+		if b.FileInfo != nil {
+			step.output = b.path()
+		}
+		return err
+	}
+	// 不是 Logger 管理的备份，例如已被清理
+	return nil
+}
+
+// writeBundle 把同一天的备份写入该日的归档，返回归档的信息。先写临时文件再
+// 重命名，成功后才删除原文件，中途失败不会丢失备份。
+func (l *Logger) writeBundle(day string, files []logInfo) (b logInfo, err error) {
+	// 归档放在当天最早一个备份所在的目录，按日期分目录时即该日的子目录
+	sort.Sort(sort.Reverse(byFormatTime(files)))
+	dir := files[0].dir
+	dst := filepath.Join(dir, l.bundleName(day))
+	tmp := dst + tmpSuffix
+
+	end := l.startOp(OpBundle, dst)
+	defer func() { end(err) }()
+
+	// 当天已有的归档（可能已被加密），其内容会被复制到新归档中
+	existing := ""
+	for _, name := range []string{dst, dst + encSuffix, dst + ageSuffix} {
+		if _, err := os.Stat(name); err == nil {
+			existing = name
+			break
+		}
+	}
+	if strings.HasSuffix(existing, ageSuffix) {
+		// 加密给 age 接收者的归档在本机无法解密，不能追加
+		return logInfo{}, errBundleSealed
+	}
+	var key []byte
+	if isEncrypted(existing) {
+		if key, err = l.encryptionKey(); err != nil {
+			return logInfo{}, err
+		}
+	}
+
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return logInfo{}, fmt.Errorf("failed to open bundle file: %s", err)
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmp)
+		}
+	}()
+
+	h := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(out, h))
+	tw := tar.NewWriter(gz)
+
+	if existing != "" {
+		if err := copyBundle(tw, existing, key); err != nil {
+			out.Close()
+			return logInfo{}, fmt.Errorf("failed to read existing bundle %s: %s", existing, err)
+		}
+	}
+	for _, f := range files {
+		if err := addBundleEntry(tw, f.path()); err != nil {
+			out.Close()
+			return logInfo{}, fmt.Errorf("failed to add %s to bundle: %s", f.path(), err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		out.Close()
+		return logInfo{}, err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		return logInfo{}, err
+	}
+	if err := out.Close(); err != nil {
+		return logInfo{}, err
+	}
+	if err := l.applyOwner(tmp); err != nil {
+		return logInfo{}, fmt.Errorf("failed to chown bundle file: %s", err)
+	}
+	if l.Checksum {
+		sum := h.Sum(nil)
+		if err := verifyWritten(tmp, sum); err != nil {
+			return logInfo{}, err
+		}
+		if err := writeChecksum(dst, sum); err != nil {
+			return logInfo{}, err
+		}
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return logInfo{}, fmt.Errorf("failed to rename bundle file: %s", err)
+	}
+
+	if existing != "" && existing != dst {
+		size := l.auditSize(existing)
+		if errRemove := removeBackup(l.retryPolicy(), existing); errRemove != nil {
+			if err == nil {
+				err = errRemove
+			}
+		} else {
+			l.audit(AuditDelete, existing, "", size)
+			l.notifyRemove(existing)
+		}
+	}
+	for _, f := range files {
+		if errRemove := removeBackup(l.retryPolicy(), f.path()); errRemove != nil {
+			if err == nil {
+				err = errRemove
+			}
+		} else {
+			l.notifyCompress(f.path(), dst)
+		}
+	}
+
+	fi, errStat := os.Stat(dst)
+	if errStat != nil {
+		return logInfo{}, errStat
+	}
+	t, _ := l.bundleTime(fi.Name())
+	return logInfo{t, 0, dir, fi}, err
+}
+
+// addBundleEntry 把文件 name 作为一个条目写入归档，保留文件名、权限和修改时间
+func addBundleEntry(tw *tar.Writer, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr, err := tar.FileInfoHeader(fi, "")
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// copyBundle 把已有归档 name 中的全部条目复制到 tw，key 非空时先解密
+func copyBundle(tw *tar.Writer, name string, key []byte) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if key != nil {
+		if r, err = NewDecryptReader(f, key); err != nil {
+			return err
+		}
+	}
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+}
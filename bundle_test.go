@@ -0,0 +1,173 @@
+package lumberjack
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// readBundle 返回归档中各条目的名称与内容
+func readBundle(name string, t testing.TB) map[string]string {
+	f, err := os.Open(name)
+	isNilUp(err, t, 1)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	isNilUp(err, t, 1)
+	tr := tar.NewReader(gz)
+	entries := make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		isNilUp(err, t, 1)
+		b, err := ioutil.ReadAll(tr)
+		isNilUp(err, t, 1)
+		entries[hdr.Name] = string(b)
+	}
+	return entries
+}
+
+func TestBundleDaily(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestBundleDaily", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		BundleDaily:  true,
+		CompressSync: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("day one"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	first := backupFile(dir)
+	day := fakeTime().UTC().Format(bundleDayLayout)
+	bundle := filepath.Join(dir, "foobar-"+day+".tar.gz")
+
+	// 当天的备份保持原样
+	exists(first, t)
+	notExist(bundle, t)
+
+	// 同一天较早的另一个备份，打包时应一并归档
+	earlier := filepath.Join(dir, "foobar-"+day+"T00-00-00.000.log")
+	isNil(ioutil.WriteFile(earlier, []byte("early"), 0644), t)
+
+	newFakeTime()
+	_, err = l.Write([]byte("day three"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+
+	notExist(first, t)
+	notExist(earlier, t)
+	exists(backupFile(dir), t)
+	equals(map[string]string{
+		filepath.Base(first):   "day one",
+		filepath.Base(earlier): "early",
+	}, readBundle(bundle, t), t)
+
+	// 归档被识别为一个备份
+	files, err := l.oldLogFiles()
+	isNil(err, t)
+	equals(2, len(files), t)
+	equals(filepath.Base(bundle), files[1].Name(), t)
+
+	// 之后补到这一天的备份追加进已有归档
+	late := filepath.Join(dir, "foobar-"+day+"T23-00-00.000.log")
+	isNil(ioutil.WriteFile(late, []byte("late"), 0644), t)
+	isNil(l.Rotate(), t)
+	notExist(late, t)
+	equals(3, len(readBundle(bundle, t)), t)
+	equals("late", readBundle(bundle, t)[filepath.Base(late)], t)
+}
+
+func TestBundleDailyMaxAge(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestBundleDailyMaxAge", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		BundleDaily:  true,
+		CompressSync: true,
+		MaxAge:       3,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("foo"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	bundle := filepath.Join(dir, "foobar-"+fakeTime().UTC().Format(bundleDayLayout)+".tar.gz")
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	exists(bundle, t)
+
+	// 超过 MaxAge 后整个归档被删除
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	notExist(bundle, t)
+}
+
+func TestBundleProcessor(t *testing.T) {
+	dir := makeTempDir("TestBundleProcessor", t)
+	defer os.RemoveAll(dir)
+
+	var uploads []string
+	clock := &manualClock{now: time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC)}
+	l := &Logger{
+		Filename:     logFile(dir),
+		Clock:        clock,
+		CompressSync: true,
+		Processors: []Processor{
+			BundleProcessor(),
+			UploadProcessor(UploaderFunc(func(ctx context.Context, path string) error {
+				uploads = append(uploads, path)
+				return nil
+			})),
+		},
+	}
+	defer l.Close()
+
+	bundle := filepath.Join(dir, "foobar-2030-01-01.tar.gz")
+
+	_, err := l.Write([]byte("first"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	first := filepath.Join(dir, "foobar-2030-01-01T10-00-00.000.log")
+
+	// 当天的备份立即打包，上传的是归档
+	notExist(first, t)
+	equals([]string{bundle}, uploads, t)
+	equals(map[string]string{filepath.Base(first): "first"}, readBundle(bundle, t), t)
+
+	// 同一天的下一个备份追加进已有归档
+	clock.Advance(time.Hour)
+	_, err = l.Write([]byte("second"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+
+	equals([]string{bundle, bundle}, uploads, t)
+	equals(map[string]string{
+		filepath.Base(first):                 "first",
+		"foobar-2030-01-01T11-00-00.000.log": "second",
+	}, readBundle(bundle, t), t)
+	fileCount(dir, 2, t)
+
+	// 不在 Logger 的流水线中时报错
+	notNil(BundleProcessor().Process(context.Background(), logFile(dir)), t)
+}
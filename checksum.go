@@ -0,0 +1,120 @@
+package lumberjack
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// checksumSuffix 是校验和旁路文件的后缀
+const checksumSuffix = ".sha256"
+
+// fileChecksum 计算本地文件内容的 SHA-256
+func fileChecksum(name string) ([]byte, error) {
+	return fsChecksum(OSFilesystem{}, name)
+}
+
+// fsChecksum 通过 fsys 读取文件 name 并计算其内容的 SHA-256
+func fsChecksum(fsys Filesystem, name string) ([]byte, error) {
+	f, err := fsys.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// verifyWritten 重新从磁盘读取 name 并与写入时计算出的 want 比较，
+// 用于发现写入过程中的静默损坏
+func verifyWritten(name string, want []byte) error {
+	got, err := fileChecksum(name)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("checksum mismatch for %s: wrote %x, read back %x", name, want, got)
+	}
+	return nil
+}
+
+// writeChecksum 为 name 写入 name.sha256 旁路文件，格式与 sha256sum 一致，
+// 可直接用 sha256sum -c 校验
+func writeChecksum(name string, sum []byte) error {
+	line := hex.EncodeToString(sum) + "  " + filepath.Base(name) + "\n"
+	if err := ioutil.WriteFile(name+checksumSuffix, []byte(line), 0644); err != nil {
+		return fmt.Errorf("failed to write checksum file: %s", err)
+	}
+	return nil
+}
+
+// moveChecksum 在备份从 oldname 重命名为 newname 后同步移动其旁路文件，
+// 并更新其中记录的文件名。备份没有旁路文件时什么也不做。
+func moveChecksum(oldname, newname string) error {
+	b, err := ioutil.ReadFile(oldname + checksumSuffix)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fields := strings.Fields(string(b))
+	if len(fields) == 0 {
+		return fmt.Errorf("empty checksum file %s", oldname+checksumSuffix)
+	}
+	sum, err := hex.DecodeString(fields[0])
+	if err != nil {
+		return fmt.Errorf("malformed checksum file %s: %s", oldname+checksumSuffix, err)
+	}
+	if err := writeChecksum(newname, sum); err != nil {
+		return err
+	}
+	return os.Remove(oldname + checksumSuffix)
+}
+
+// removeBackup 删除备份文件及其校验和旁路文件，失败时按 p 重试
+func removeBackup(p RetryPolicy, name string) error {
+	if err := removeFileRetry(p, name); err != nil {
+		return err
+	}
+	if err := removeFileRetry(p, name+checksumSuffix); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// VerifyChecksum 按 name.sha256 旁路文件校验备份文件 name 的完整性，
+// 旁路文件缺失、格式错误或校验和不一致时返回错误。适合在上传或归档
+// 备份之前调用。
+func VerifyChecksum(name string) error {
+	b, err := ioutil.ReadFile(name + checksumSuffix)
+	if err != nil {
+		return fmt.Errorf("can't read checksum file: %s", err)
+	}
+	fields := strings.Fields(string(b))
+	if len(fields) == 0 {
+		return fmt.Errorf("empty checksum file %s", name+checksumSuffix)
+	}
+	want, err := hex.DecodeString(fields[0])
+	if err != nil || len(want) != sha256.Size {
+		return fmt.Errorf("malformed checksum file %s", name+checksumSuffix)
+	}
+	got, err := fileChecksum(name)
+	if err != nil {
+		return fmt.Errorf("can't read backup file: %s", err)
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("checksum mismatch for %s: expected %x, got %x", name, want, got)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package lumberjack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChecksumSidecar(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestChecksumSidecar", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		Compress:     true,
+		CompressSync: true,
+		Checksum:     true,
+		MaxBackups:   1,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+
+	first := backupFile(dir) + compressSuffix
+	exists(first, t)
+	isNil(VerifyChecksum(first), t)
+
+	b, err := ioutil.ReadFile(first + checksumSuffix)
+	isNil(err, t)
+	compressed, err := ioutil.ReadFile(first)
+	isNil(err, t)
+	sum := sha256.Sum256(compressed)
+	equals(hex.EncodeToString(sum[:])+"  "+filepath.Base(first)+"\n", string(b), t)
+
+	// 旁路文件不被视为备份，且随备份一起被清理
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	notExist(first, t)
+	notExist(first+checksumSuffix, t)
+	exists(backupFile(dir)+compressSuffix+checksumSuffix, t)
+	fileCount(dir, 3, t)
+}
+
+func TestVerifyChecksumMismatch(t *testing.T) {
+	dir := makeTempDir("TestVerifyChecksumMismatch", t)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "foobar.log.gz")
+	isNil(ioutil.WriteFile(name, []byte("data"), 0644), t)
+
+	// 缺少旁路文件
+	notNil(VerifyChecksum(name), t)
+
+	sum := sha256.Sum256([]byte("data"))
+	isNil(writeChecksum(name, sum[:]), t)
+	isNil(VerifyChecksum(name), t)
+
+	// 文件被篡改后校验失败
+	isNil(ioutil.WriteFile(name, []byte("dat4"), 0644), t)
+	err := VerifyChecksum(name)
+	notNil(err, t)
+	assert(strings.Contains(err.Error(), "checksum mismatch"), t, "unexpected error: %v", err)
+}
+
+func TestChecksumNumberedBackups(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestChecksumNumberedBackups", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:        filename,
+		NumberedBackups: true,
+		Compress:        true,
+		CompressSync:    true,
+		Checksum:        true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("first"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	isNil(VerifyChecksum(filename+".1.gz"), t)
+
+	// 序号后移时旁路文件随之移动，并记录新的文件名
+	isNil(l.Rotate(), t)
+	isNil(VerifyChecksum(filename+".2.gz"), t)
+	b, err := ioutil.ReadFile(filename + ".2.gz" + checksumSuffix)
+	isNil(err, t)
+	assert(strings.HasSuffix(string(b), "  foobar.log.2.gz\n"), t, "unexpected checksum file: %q", b)
+}
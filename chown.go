@@ -7,6 +7,6 @@ import (
 	"os"
 )
 
-func chown(_ string, _ os.FileInfo) error {
+func chown(_ string, _ os.FileMode, _ os.FileInfo) error {
 	return nil
 }
@@ -5,11 +5,9 @@ import (
 	"syscall"
 )
 
-// osChown is a var so we can mock it out during tests.
-var osChown = os.Chown
-
-func chown(name string, info os.FileInfo) error {
-	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+// chown 以 mode 创建 name，并把属主设置为与 info 相同
+func chown(name string, mode os.FileMode, info os.FileInfo) error {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,102 @@
+package lumberjack
+
+import (
+	"context"
+	"time"
+)
+
+// Clock 提供当前时间和计时器。Logger 以 Clock 生成备份时间戳、判断 MaxAge
+// 以及调度 RotationInterval、RotateDaily 和 RotateSchedule 的定时轮转。
+// 实现必须可以被并发调用。
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer 是 Clock 创建的计时器，语义与 time.Timer 相同
+type Timer interface {
+	// C 返回计时器到期时接收时间的通道
+	C() <-chan time.Time
+	// Stop 停止计时器，计时器尚未到期时返回 true
+	Stop() bool
+	// Reset 使计时器在 d 之后到期，计时器尚未到期时返回 true
+	Reset(d time.Duration) bool
+}
+
+// systemClock 是默认的系统时钟
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return currentTime() }
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+// systemTimer 包装 time.Timer
+type systemTimer struct {
+	t *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time        { return t.t.C }
+func (t systemTimer) Stop() bool                 { return t.t.Stop() }
+func (t systemTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+
+// clock 返回 Logger 使用的时钟
+func (l *Logger) clock() Clock {
+	if l.Clock != nil {
+		return l.Clock
+	}
+	return systemClock{}
+}
+
+// newTimer 以 Logger 的时钟创建计时器
+func (l *Logger) newTimer(d time.Duration) Timer {
+	return l.clock().NewTimer(d)
+}
+
+// ticker 以 Clock 的计时器实现周期触发。与 time.Ticker 不同，每次从 C 接收
+// 后需要调用 next 安排下一次触发。零值的 ticker 永不触发。
+type ticker struct {
+	t        Timer
+	interval time.Duration
+}
+
+// newTicker 创建每隔 d 触发一次的 ticker
+func (l *Logger) newTicker(d time.Duration) *ticker {
+	return &ticker{t: l.newTimer(d), interval: d}
+}
+
+// C 返回触发时接收时间的通道，零值的 ticker 返回 nil，在 select 中永远不会
+// 被选中
+func (t *ticker) C() <-chan time.Time {
+	if t.t == nil {
+		return nil
+	}
+	return t.t.C()
+}
+
+// next 安排下一次触发
+func (t *ticker) next() {
+	if t.t != nil {
+		t.t.Reset(t.interval)
+	}
+}
+
+// stop 停止 ticker
+func (t *ticker) stop() {
+	if t.t != nil {
+		t.t.Stop()
+	}
+}
+
+// sleep 按 c 等待 d，ctx 先结束时返回 false
+func sleep(ctx context.Context, c Clock, d time.Duration) bool {
+	t := c.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C():
+		return true
+	}
+}
@@ -0,0 +1,174 @@
+package lumberjack
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// manualClock 是测试用的时钟，只在调用 Advance 时前进
+type manualClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*manualTimer
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &manualTimer{c: c, ch: make(chan time.Time, 1), at: c.now.Add(d), active: true}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance 使时钟前进 d，并触发到期的计时器
+func (c *manualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, t := range c.timers {
+		if t.active && !t.at.After(c.now) {
+			t.active = false
+			select {
+			case t.ch <- c.now:
+			default:
+			}
+		}
+	}
+}
+
+type manualTimer struct {
+	c      *manualClock
+	ch     chan time.Time
+	at     time.Time
+	active bool
+}
+
+func (t *manualTimer) C() <-chan time.Time { return t.ch }
+
+func (t *manualTimer) Stop() bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	was := t.active
+	t.active = false
+	return was
+}
+
+func (t *manualTimer) Reset(d time.Duration) bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	was := t.active
+	t.at = t.c.now.Add(d)
+	t.active = true
+	return was
+}
+
+// waitFor 等待 cond 成立，最多等待一秒
+func waitFor(cond func() bool, t testing.TB) {
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestClockIntervalRotation(t *testing.T) {
+	dir := makeTempDir("TestClockIntervalRotation", t)
+	defer os.RemoveAll(dir)
+
+	clock := &manualClock{now: time.Date(2024, 3, 19, 23, 30, 0, 0, time.UTC)}
+	l := &Logger{Filename: logFile(dir), RotateDaily: true, Clock: clock}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	isNil(l.WaitIdle(context.Background()), t)
+	fileCount(dir, 1, t)
+
+	// 时钟越过零点后计时器到期，无需写入即完成轮转
+	clock.Advance(31 * time.Minute)
+	name := backupName(dir, logFile(dir), clock.Now().Format(backupTimeFormat))
+	waitFor(func() bool {
+		_, err := os.Stat(name)
+		return err == nil
+	}, t)
+	existsWithContent(name, b, t)
+	existsWithContent(logFile(dir), []byte{}, t)
+}
+
+func TestClockMaxAge(t *testing.T) {
+	dir := makeTempDir("TestClockMaxAge", t)
+	defer os.RemoveAll(dir)
+
+	clock := &manualClock{now: time.Date(2024, 3, 19, 12, 0, 0, 0, time.UTC)}
+	l := &Logger{Filename: logFile(dir), MaxAge: 1, Clock: clock}
+	defer l.Close()
+
+	_, err := l.Write([]byte("old"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	first := backupName(dir, logFile(dir), clock.Now().Format(backupTimeFormat))
+	exists(first, t)
+
+	// 两天后轮转时，第一个备份已超过 MaxAge
+	clock.Advance(48 * time.Hour)
+	_, err = l.Write([]byte("new"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	isNil(l.WaitIdle(context.Background()), t)
+	notExist(first, t)
+	exists(backupName(dir, logFile(dir), clock.Now().Format(backupTimeFormat)), t)
+}
+
+func TestClockTickers(t *testing.T) {
+	dir := makeTempDir("TestClockTickers", t)
+	defer os.RemoveAll(dir)
+
+	clock := &manualClock{now: time.Date(2024, 3, 19, 12, 0, 0, 0, time.UTC)}
+	l := &Logger{
+		Filename:            logFile(dir),
+		SyncPolicy:          SyncPeriodic,
+		SyncEvery:           time.Hour,
+		StreamCompress:      true,
+		StreamFlushInterval: time.Minute,
+		Clock:               clock,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.WaitIdle(context.Background()), t)
+	equals(int64(4), unsyncedBytes(l), t)
+
+	dirty := func() bool {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.gzDirty
+	}
+	equals(true, dirty(), t)
+
+	// 定期刷新压缩流和 fsync 的计时器同样由 Clock 驱动
+	clock.Advance(time.Minute)
+	waitFor(func() bool { return !dirty() }, t)
+	equals(int64(4), unsyncedBytes(l), t)
+
+	clock.Advance(time.Hour)
+	waitFor(func() bool { return unsyncedBytes(l) == 0 }, t)
+
+	// 触发后重新计时
+	_, err = l.Write([]byte("foo"))
+	isNil(err, t)
+	clock.Advance(time.Hour)
+	waitFor(func() bool { return unsyncedBytes(l) == 0 }, t)
+}
@@ -0,0 +1,485 @@
+// Command lumberjack 检查和维护由 lumberjack.Logger 管理的日志目录，使用与
+// 库相同的备份命名、保留策略和清单逻辑，供运维人员手动处理。
+//
+//	lumberjack [-config app.yaml] [-file /var/log/myapp/foo.log] <命令> [参数]
+//
+// -config 指定与 lumberjack.ConfigFromFile 相同格式的配置文件，-file 指定日志
+// 文件并覆盖配置文件中的 filename。支持的命令：
+//
+//	list      列出全部备份
+//	rotate    立即轮转当前日志文件
+//	purge     按保留策略清理旧备份
+//	compress  只压缩尚未压缩的备份，不删除任何文件
+//	verify    校验备份的清单、校验和、封存记录以及压缩数据
+//	cat       按时间顺序输出备份和当前日志文件
+//
+// 对正在写入的进程使用 rotate 时，该进程需要设置 ReopenCheckInterval，否则会
+// 继续写入被移走的文件。
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ai-mmo/lumberjack"
+)
+
+// errUsage 表示命令行参数错误，已向 stderr 输出用法
+var errUsage = errors.New("usage")
+
+// command 是一个子命令
+type command struct {
+	summary string
+	run     func(l *lumberjack.Logger, args []string, stdout, stderr io.Writer) error
+}
+
+var commands = map[string]command{
+	"list":     {"list all backups", runList},
+	"rotate":   {"rotate the log file now", runRotate},
+	"purge":    {"remove old backups according to the retention policy", runPurge},
+	"compress": {"compress backups without removing any", runCompress},
+	"verify":   {"verify manifest, checksums, seals and compressed data of backups", runVerify},
+	"cat":      {"print backups and the log file in time order", runCat},
+}
+
+// commandOrder 是用法中列出命令的顺序
+var commandOrder = []string{"list", "rotate", "purge", "compress", "verify", "cat"}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run 执行命令行，返回进程退出码：成功为 0，执行失败为 1，参数错误为 2
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lumberjack", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	config := flags.String("config", "", "JSON or YAML config `file` of the logger")
+	file := flags.String("file", "", "log `file`, overrides filename in the config")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: lumberjack [-config file] [-file file] <command> [flags]\n\ncommands:\n")
+		for _, name := range commandOrder {
+			fmt.Fprintf(stderr, "  %-9s %s\n", name, commands[name].summary)
+		}
+		fmt.Fprintf(stderr, "\nflags:\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	name := flags.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "lumberjack: unknown command %q\n", name)
+		flags.Usage()
+		return 2
+	}
+
+	l, err := loadLogger(*config, *file)
+	if err != nil {
+		fmt.Fprintf(stderr, "lumberjack: %s\n", err)
+		return 1
+	}
+	err = cmd.run(l, flags.Args()[1:], stdout, stderr)
+	if errClose := l.Close(); err == nil && errClose != nil {
+		err = errClose
+	}
+	if err == errUsage {
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "lumberjack %s: %s\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+// loadLogger 按配置文件和 -file 创建 Logger，不会打开日志文件
+func loadLogger(config, file string) (*lumberjack.Logger, error) {
+	l := &lumberjack.Logger{}
+	if config != "" {
+		var err error
+		if l, err = lumberjack.ConfigFromFile(config); err != nil {
+			return nil, err
+		}
+	}
+	if file != "" {
+		l.Filename = file
+	}
+	if l.Filename == "" {
+		return nil, errors.New("no log file given, use -file or -config")
+	}
+	if err := l.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %s", err)
+	}
+	return l, nil
+}
+
+// parseFlags 解析子命令的参数，不接受多余的位置参数
+func parseFlags(flags *flag.FlagSet, args []string, stderr io.Writer) error {
+	flags.SetOutput(stderr)
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(stderr, "unexpected arguments: %s\n", strings.Join(flags.Args(), " "))
+		flags.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+func runList(l *lumberjack.Logger, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print backups as JSON")
+	if err := parseFlags(flags, args, stderr); err != nil {
+		return err
+	}
+	backups, err := l.Backups()
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return writeJSON(stdout, backups)
+	}
+	return writeBackups(stdout, backups, false)
+}
+
+func runRotate(l *lumberjack.Logger, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("rotate", flag.ContinueOnError)
+	if err := parseFlags(flags, args, stderr); err != nil {
+		return err
+	}
+	name, err := l.RotateWithResult()
+	if err != nil {
+		return err
+	}
+	// 等待压缩、清理等后台处理完成后再退出
+	if err := l.WaitIdle(context.Background()); err != nil {
+		return err
+	}
+	if name != "" {
+		fmt.Fprintln(stdout, name)
+	}
+	return nil
+}
+
+func runPurge(l *lumberjack.Logger, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("purge", flag.ContinueOnError)
+	olderThan := flags.String("older-than", "", "also remove backups older than `age`, e.g. 72h or 30d")
+	dryRun := flags.Bool("dry-run", false, "only print what would be done")
+	if err := parseFlags(flags, args, stderr); err != nil {
+		return err
+	}
+	var before time.Time
+	if *olderThan != "" {
+		age, err := parseAge(*olderThan)
+		if err != nil {
+			return err
+		}
+		before = time.Now().Add(-age)
+	}
+
+	if *dryRun {
+		preview, err := l.PreviewCleanup()
+		if err != nil {
+			return err
+		}
+		if !before.IsZero() {
+			preview, err = withOlderThan(l, preview, before)
+			if err != nil {
+				return err
+			}
+		}
+		return writeBackups(stdout, preview, true)
+	}
+
+	var removed []string
+	var err error
+	if before.IsZero() {
+		removed, err = l.Purge()
+	} else {
+		removed, err = l.PurgeOlderThan(before)
+	}
+	for _, name := range removed {
+		fmt.Fprintln(stdout, name)
+	}
+	return err
+}
+
+// withOlderThan 把时间戳早于 before、尚未被删除的备份作为删除项加入演练结果
+func withOlderThan(l *lumberjack.Logger, preview []lumberjack.BackupInfo, before time.Time) ([]lumberjack.BackupInfo, error) {
+	backups, err := l.Backups()
+	if err != nil {
+		return nil, err
+	}
+	deleted := make(map[string]bool)
+	for _, b := range preview {
+		if b.Action == lumberjack.CleanupDelete {
+			deleted[b.Path] = true
+		}
+	}
+	var extra []lumberjack.BackupInfo
+	for _, b := range backups {
+		if !b.Held && !deleted[b.Path] && b.Timestamp.Before(before) {
+			b.Action = lumberjack.CleanupDelete
+			extra = append(extra, b)
+		}
+	}
+	// 删除项在前，其余操作中去掉将被删除的备份
+	var rest []lumberjack.BackupInfo
+	for _, b := range preview {
+		if b.Action == lumberjack.CleanupDelete {
+			extra = append(extra, b)
+		} else if !b.Timestamp.Before(before) {
+			rest = append(rest, b)
+		}
+	}
+	return append(extra, rest...), nil
+}
+
+func runCompress(l *lumberjack.Logger, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("compress", flag.ContinueOnError)
+	codec := flags.String("codec", "", "compression `algorithm` (gzip, zstd or zip), defaults to the config or gzip")
+	if err := parseFlags(flags, args, stderr); err != nil {
+		return err
+	}
+	if *codec != "" {
+		l.Compression = *codec
+		l.Compressor = nil
+	} else if l.Compression == "" && l.Compressor == nil {
+		l.Compress = true
+	}
+	// 关闭全部保留策略，使清理只压缩（以及按配置打包、加密）备份
+	l.MaxBackups = 0
+	l.MaxAge = 0
+	l.MaxAgeDuration = 0
+	l.MaxAgeStr = ""
+	l.MaxTotalSize = 0
+	l.KeepDaily, l.KeepWeekly, l.KeepMonthly = 0, 0, 0
+	l.ThinAfter = 0
+	l.TrashTTL = 0
+	if err := l.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %s", err)
+	}
+
+	before, err := l.Backups()
+	if err != nil {
+		return err
+	}
+	if _, err := l.Purge(); err != nil {
+		return err
+	}
+	after, err := l.Backups()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "compressed %d backups\n", countPlain(before)-countPlain(after))
+	return nil
+}
+
+// countPlain 统计未压缩的备份数量
+func countPlain(backups []lumberjack.BackupInfo) int {
+	n := 0
+	for _, b := range backups {
+		if !b.Compressed {
+			n++
+		}
+	}
+	return n
+}
+
+func runVerify(l *lumberjack.Logger, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	if err := parseFlags(flags, args, stderr); err != nil {
+		return err
+	}
+	backups, err := l.Backups()
+	if err != nil {
+		return err
+	}
+	sums := make(map[string]string)
+	if l.Manifest != "" {
+		path := l.Manifest
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(l.Filename), path)
+		}
+		m, err := lumberjack.ReadManifest(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if m != nil {
+			for _, e := range m.Backups {
+				sums[e.Path] = e.SHA256
+			}
+		}
+	}
+
+	failed := 0
+	for _, b := range backups {
+		if err := verifyBackup(l, b, sums[b.Path]); err != nil {
+			failed++
+			fmt.Fprintf(stdout, "FAIL %s: %s\n", b.Path, err)
+			continue
+		}
+		fmt.Fprintf(stdout, "ok   %s\n", b.Path)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d backups failed verification", failed, len(backups))
+	}
+	return nil
+}
+
+// verifyBackup 校验单个备份：与清单中的校验和 sum 比较、按校验和旁路文件
+// 校验、检查封存记录，并完整读取一遍压缩或加密的数据
+func verifyBackup(l *lumberjack.Logger, b lumberjack.BackupInfo, sum string) error {
+	if sum != "" {
+		got, err := sha256File(b.Path)
+		if err != nil {
+			return err
+		}
+		if got != sum {
+			return fmt.Errorf("checksum mismatch with manifest: expected %s, got %s", sum, got)
+		}
+	}
+	if _, err := os.Stat(b.Path + ".sha256"); err == nil {
+		if err := lumberjack.VerifyChecksum(b.Path); err != nil {
+			return err
+		}
+	}
+	if l.Seal && !b.Compressed && !b.Encrypted {
+		if _, err := lumberjack.VerifySeal(b.Path); err != nil {
+			return err
+		}
+	}
+	if (b.Compressed || b.Encrypted) && !strings.HasSuffix(b.Name, ".age") {
+		r, err := l.OpenBackup(b)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			return fmt.Errorf("can't read backup: %s", err)
+		}
+	}
+	return nil
+}
+
+// sha256File 返回文件内容的 SHA-256（十六进制）
+func sha256File(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func runCat(l *lumberjack.Logger, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("cat", flag.ContinueOnError)
+	since := flags.String("since", "", "only files overlapping the window starting at `time` (RFC 3339 or 2006-01-02)")
+	until := flags.String("until", "", "only files overlapping the window ending at `time` (RFC 3339 or 2006-01-02)")
+	if err := parseFlags(flags, args, stderr); err != nil {
+		return err
+	}
+	from, err := parseTime(*since)
+	if err != nil {
+		return err
+	}
+	to, err := parseTime(*until)
+	if err != nil {
+		return err
+	}
+	return l.Concat(context.Background(), stdout, from, to)
+}
+
+// writeBackups 以表格输出备份，withAction 为 true 时包含清理操作一列
+func writeBackups(w io.Writer, backups []lumberjack.BackupInfo, withAction bool) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if withAction {
+		fmt.Fprint(tw, "ACTION\t")
+	}
+	fmt.Fprintln(tw, "TIMESTAMP\tSIZE\tFLAGS\tPATH")
+	for _, b := range backups {
+		if withAction {
+			fmt.Fprintf(tw, "%s\t", b.Action)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", b.Timestamp.Format(time.RFC3339), b.Size, backupFlags(b), b.Path)
+	}
+	return tw.Flush()
+}
+
+// backupFlags 以逗号分隔列出备份的状态，没有时为 "-"
+func backupFlags(b lumberjack.BackupInfo) string {
+	var flags []string
+	if b.Compressed {
+		flags = append(flags, "compressed")
+	}
+	if b.Encrypted {
+		flags = append(flags, "encrypted")
+	}
+	if b.Held {
+		flags = append(flags, "held")
+	}
+	if len(flags) == 0 {
+		return "-"
+	}
+	return strings.Join(flags, ",")
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// parseAge 解析时长，除 time.ParseDuration 的格式外还接受 "30d" 这样以天为
+// 单位的写法
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return d, nil
+}
+
+// parseTime 解析 RFC 3339 时间或本地时区的日期，空串返回零值
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, want RFC 3339 or 2006-01-02", s)
+	}
+	return t, nil
+}
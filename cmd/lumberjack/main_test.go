@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ai-mmo/lumberjack"
+	"github.com/ai-mmo/lumberjack/lumberjacktest"
+)
+
+// setup 在临时目录中写入 n 个备份和当前日志文件，返回日志文件路径。
+// 第 i 个备份的内容为 "backup i\n"，时间戳间隔一小时。
+func setup(n int, t *testing.T, opts ...lumberjack.Option) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "app.log")
+	clock := lumberjacktest.NewFakeClock(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	opts = append(opts, lumberjack.WithClock(clock))
+	l, err := lumberjack.New(name, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	for i := 0; i < n; i++ {
+		if _, err := l.Write([]byte("backup " + string(rune('0'+i)) + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		clock.Advance(time.Hour)
+		if err := l.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := l.Write([]byte("current\n")); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+// runCmd 执行命令行，返回退出码和标准输出
+func runCmd(t *testing.T, args ...string) (int, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	if code != 0 {
+		t.Logf("stderr: %s", stderr.String())
+	}
+	return code, stdout.String()
+}
+
+func backupCount(name string, t *testing.T) int {
+	t.Helper()
+	l := &lumberjack.Logger{Filename: name}
+	backups, err := l.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(backups)
+}
+
+func TestList(t *testing.T) {
+	name := setup(2, t)
+	code, out := runCmd(t, "-file", name, "list")
+	if code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	if got := strings.Count(out, "app-2024-03-01T0"); got != 2 {
+		t.Fatalf("expected 2 backups in output, got %d:\n%s", got, out)
+	}
+
+	code, out = runCmd(t, "-file", name, "list", "-json")
+	if code != 0 || !strings.Contains(out, `"timestamp"`) {
+		t.Fatalf("unexpected JSON output (exit %d):\n%s", code, out)
+	}
+}
+
+func TestRotateAndCat(t *testing.T) {
+	name := setup(1, t)
+	code, out := runCmd(t, "-file", name, "rotate")
+	if code != 0 || strings.TrimSpace(out) == "" {
+		t.Fatalf("unexpected rotate output (exit %d): %q", code, out)
+	}
+	if n := backupCount(name, t); n != 2 {
+		t.Fatalf("expected 2 backups, got %d", n)
+	}
+
+	code, out = runCmd(t, "-file", name, "cat")
+	if code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	if out != "backup 0\ncurrent\n" {
+		t.Fatalf("unexpected cat output %q", out)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	name := setup(3, t)
+	config := filepath.Join(filepath.Dir(name), "app.json")
+	if err := os.WriteFile(config, []byte(`{"filename": "`+filepath.ToSlash(name)+`", "maxbackups": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	code, out := runCmd(t, "-config", config, "purge", "-dry-run")
+	if code != 0 || strings.Count(out, "delete") != 2 {
+		t.Fatalf("unexpected dry run output (exit %d):\n%s", code, out)
+	}
+	if n := backupCount(name, t); n != 3 {
+		t.Fatalf("dry run removed backups, %d left", n)
+	}
+
+	code, out = runCmd(t, "-config", config, "purge")
+	if code != 0 || strings.Count(out, "\n") != 2 {
+		t.Fatalf("unexpected purge output (exit %d):\n%s", code, out)
+	}
+	if n := backupCount(name, t); n != 1 {
+		t.Fatalf("expected 1 backup after purge, got %d", n)
+	}
+
+	// 时间戳都早于现在，全部删除
+	code, _ = runCmd(t, "-file", name, "purge", "-older-than", "1d")
+	if code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	if n := backupCount(name, t); n != 0 {
+		t.Fatalf("expected no backups, got %d", n)
+	}
+}
+
+func TestCompressAndVerify(t *testing.T) {
+	name := setup(2, t, func(l *lumberjack.Logger) { l.Checksum = true })
+	code, out := runCmd(t, "-file", name, "compress")
+	if code != 0 || strings.TrimSpace(out) != "compressed 2 backups" {
+		t.Fatalf("unexpected compress output (exit %d): %q", code, out)
+	}
+	if n := backupCount(name, t); n != 2 {
+		t.Fatalf("compress removed backups, %d left", n)
+	}
+
+	code, out = runCmd(t, "-file", name, "verify")
+	if code != 0 || strings.Count(out, "ok ") != 2 {
+		t.Fatalf("unexpected verify output (exit %d):\n%s", code, out)
+	}
+	code, out = runCmd(t, "-file", name, "cat")
+	if code != 0 || out != "backup 0\nbackup 1\ncurrent\n" {
+		t.Fatalf("unexpected cat output (exit %d): %q", code, out)
+	}
+
+	// 损坏一个备份后校验失败
+	l := &lumberjack.Logger{Filename: name}
+	backups, err := l.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(backups[0].Path, []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	code, out = runCmd(t, "-file", name, "verify")
+	if code != 1 || !strings.Contains(out, "FAIL "+backups[0].Path) {
+		t.Fatalf("expected verify to fail (exit %d):\n%s", code, out)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	if code, _ := runCmd(t); code != 2 {
+		t.Fatalf("expected exit code 2 without command, got %d", code)
+	}
+	if code, _ := runCmd(t, "-file", "app.log", "bogus"); code != 2 {
+		t.Fatalf("expected exit code 2 for unknown command, got %d", code)
+	}
+	if code, _ := runCmd(t, "list"); code != 1 {
+		t.Fatalf("expected exit code 1 without log file, got %d", code)
+	}
+	if code, _ := runCmd(t, "-file", "app.log", "purge", "-older-than", "soon"); code != 1 {
+		t.Fatalf("expected exit code 1 for invalid age, got %d", code)
+	}
+}
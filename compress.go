@@ -0,0 +1,283 @@
+package lumberjack
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// 支持的压缩算法
+const (
+	compressionGzip = "gzip"
+	compressionZstd = "zstd"
+	compressionZip  = "zip"
+
+	zstdSuffix = ".zst"
+	zipSuffix  = ".zip"
+)
+
+// compressionCustom 是设置了 Logger.Compressor 时 compression 返回的名称
+const compressionCustom = "custom"
+
+// compressSuffixes 是内置压缩算法的备份后缀
+var compressSuffixes = []string{compressSuffix, zstdSuffix, zipSuffix}
+
+// Compressor 是备份的压缩算法。Compress 读取 src 并把压缩结果写入 dst，
+// dst 已以 src 的权限和属主创建；修改时间、校验和以及删除 src 由调用方负责。
+// Ext 返回压缩文件的后缀（例如 ".sz"），用于生成和识别压缩后的备份。
+// 实现必须可以被并发调用。
+type Compressor interface {
+	Compress(src, dst string) error
+	Ext() string
+}
+
+// GzipCompressor 是默认的 gzip 实现，Level 为 0 时使用默认级别
+type GzipCompressor struct {
+	Level int
+}
+
+// Compress 把 src 以 gzip 压缩写入 dst
+func (g GzipCompressor) Compress(src, dst string) error {
+	return compressCodec(src, dst, compressionGzip, g.Level)
+}
+
+// Ext 返回 ".gz"
+func (GzipCompressor) Ext() string {
+	return compressSuffix
+}
+
+// codecCompressor 是内置的 zstd 和 zip 实现
+type codecCompressor struct {
+	codec string
+	level int
+}
+
+func (c codecCompressor) Compress(src, dst string) error {
+	return compressCodec(src, dst, c.codec, c.level)
+}
+
+func (c codecCompressor) Ext() string {
+	suffix, _ := compressionSuffix(c.codec)
+	return suffix
+}
+
+// 由 RegisterCompressor 注册的压缩算法，以及所有已知的自定义后缀
+var (
+	compressorsMu  sync.RWMutex
+	compressors    = map[string]Compressor{}
+	customSuffixes []string
+)
+
+// RegisterCompressor 以 name 注册压缩算法，之后即可在 Compression 中（包括
+// 配置文件和环境变量）按名称使用，例如注册 snappy 实现后设置
+// Compression: "snappy"。name 不区分大小写，与内置算法或已注册的算法重名、
+// c 为 nil 或 Ext 为空时 panic。
+func RegisterCompressor(name string, c Compressor) {
+	name = strings.ToLower(name)
+	if c == nil || c.Ext() == "" {
+		panic("lumberjack: RegisterCompressor requires a compressor with an extension")
+	}
+	if _, err := compressionSuffix(name); err == nil || name == compressionCustom {
+		panic("lumberjack: RegisterCompressor called twice for " + name)
+	}
+	compressorsMu.Lock()
+	if _, ok := compressors[name]; ok {
+		compressorsMu.Unlock()
+		panic("lumberjack: RegisterCompressor called twice for " + name)
+	}
+	compressors[name] = c
+	compressorsMu.Unlock()
+	addCompressSuffix(c.Ext())
+}
+
+// addCompressSuffix 把自定义压缩后缀加入可识别的后缀列表，较长的后缀排在
+// 前面，避免 ".tar.gz" 这类后缀被 ".gz" 截断
+func addCompressSuffix(ext string) {
+	compressorsMu.Lock()
+	defer compressorsMu.Unlock()
+	for _, s := range customSuffixes {
+		if s == ext {
+			return
+		}
+	}
+	customSuffixes = append(customSuffixes, ext)
+	sort.SliceStable(customSuffixes, func(i, j int) bool {
+		return len(customSuffixes[i]) > len(customSuffixes[j])
+	})
+}
+
+// allCompressSuffixes 返回自定义后缀和内置后缀
+func allCompressSuffixes() []string {
+	compressorsMu.RLock()
+	defer compressorsMu.RUnlock()
+	if len(customSuffixes) == 0 {
+		return compressSuffixes
+	}
+	all := make([]string, 0, len(customSuffixes)+len(compressSuffixes))
+	all = append(all, customSuffixes...)
+	return append(all, compressSuffixes...)
+}
+
+// compressor 返回轮转后备份使用的压缩算法，不压缩时返回 nil。优先使用
+// Compressor，其次按 compression 的名称查找内置或已注册的算法。
+func (l *Logger) compressor() (Compressor, error) {
+	if l.Compressor != nil {
+		if l.Compressor.Ext() == "" {
+			return nil, errors.New("Compressor has an empty extension")
+		}
+		addCompressSuffix(l.Compressor.Ext())
+		return l.Compressor, nil
+	}
+	codec := l.compression()
+	if codec == "" {
+		return nil, nil
+	}
+	return newCompressor(codec, l.CompressionLevel)
+}
+
+// newCompressor 按名称返回内置或已注册的压缩算法，level 只对内置算法生效
+func newCompressor(codec string, level int) (Compressor, error) {
+	switch codec {
+	case compressionGzip:
+		return GzipCompressor{Level: level}, nil
+	case compressionZstd, compressionZip:
+		return codecCompressor{codec, level}, nil
+	}
+	compressorsMu.RLock()
+	c, ok := compressors[codec]
+	compressorsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown compression %q", codec)
+	}
+	return c, nil
+}
+
+// compression 返回轮转后备份使用的压缩算法，返回空串表示不压缩。
+// 设置了 Compressor 时返回 "custom"；设置了 Compression 时以其为准，
+// 否则 Compress 为 true 时使用 gzip。
+func (l *Logger) compression() string {
+	if l.Compressor != nil {
+		return compressionCustom
+	}
+	if l.Compression != "" {
+		return strings.ToLower(l.Compression)
+	}
+	if l.Compress {
+		return compressionGzip
+	}
+	return ""
+}
+
+// compressionSuffix 返回压缩算法对应的文件后缀
+func compressionSuffix(codec string) (string, error) {
+	switch codec {
+	case compressionGzip:
+		return compressSuffix, nil
+	case compressionZstd:
+		return zstdSuffix, nil
+	case compressionZip:
+		return zipSuffix, nil
+	}
+	return "", fmt.Errorf("unknown compression %q", codec)
+}
+
+// newCompressWriter 返回写入 w 的压缩 writer，level 为 0 时使用算法的默认级别。
+// fi 是被压缩的原文件，zip 格式用它作为归档内唯一条目的名称和修改时间。
+func newCompressWriter(w io.Writer, codec string, level int, fi os.FileInfo) (io.WriteCloser, error) {
+	switch codec {
+	case compressionGzip:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		return gzip.NewWriterLevel(w, level)
+	case compressionZstd:
+		opts := []zstd.EOption{}
+		if level != 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		return zstd.NewWriter(w, opts...)
+	case compressionZip:
+		zw := zip.NewWriter(w)
+		if level != 0 {
+			zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+				return flate.NewWriter(out, level)
+			})
+		}
+		entry, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     fi.Name(),
+			Method:   zip.Deflate,
+			Modified: fi.ModTime(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &zipEntryWriter{Writer: entry, zw: zw}, nil
+	}
+	return nil, fmt.Errorf("unknown compression %q", codec)
+}
+
+// compressCodec 以内置算法 codec 把 src 压缩写入 dst，不删除 src
+func compressCodec(src, dst, codec string, level int) (err error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, fi.Mode())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if errClose := out.Close(); err == nil {
+			err = errClose
+		}
+	}()
+	w, err := newCompressWriter(out, codec, level, fi)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// zipEntryWriter 写入 zip 归档中的单个条目，Close 时结束整个归档
+type zipEntryWriter struct {
+	io.Writer
+	zw *zip.Writer
+}
+
+func (z *zipEntryWriter) Close() error {
+	return z.zw.Close()
+}
+
+// trimCompressSuffix 去掉文件名中的加密后缀和已知的压缩后缀
+func trimCompressSuffix(name string) string {
+	name = trimEncryptSuffix(name)
+	for _, suffix := range allCompressSuffixes() {
+		if strings.HasSuffix(name, suffix) {
+			return strings.TrimSuffix(name, suffix)
+		}
+	}
+	return name
+}
+
+// isCompressed 判断文件名是否带有已知的压缩后缀。加密文件的内容无法再压缩，
+// 同样视为已压缩。
+func isCompressed(name string) bool {
+	return trimCompressSuffix(name) != name
+}
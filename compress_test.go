@@ -0,0 +1,228 @@
+package lumberjack
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestCompressZstd(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestCompressZstd", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:         filename,
+		Compression:      "zstd",
+		CompressionLevel: 3,
+		MaxBackups:       1,
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	first := backupFile(dir)
+
+	// we need to wait a little bit since the files get compressed on a different
+	// goroutine.
+	<-time.After(300 * time.Millisecond)
+
+	notExist(first, t)
+	f, err := os.Open(first + zstdSuffix)
+	isNil(err, t)
+	defer f.Close()
+	zr, err := zstd.NewReader(f)
+	isNil(err, t)
+	defer zr.Close()
+	got, err := ioutil.ReadAll(zr)
+	isNil(err, t)
+	equals(b, got, t)
+
+	// .zst 备份同样参与 MaxBackups 清理
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	<-time.After(300 * time.Millisecond)
+	notExist(first+zstdSuffix, t)
+	exists(backupFile(dir)+zstdSuffix, t)
+	fileCount(dir, 2, t)
+}
+
+func TestUnknownCompression(t *testing.T) {
+	_, err := compressionSuffix("lz4")
+	notNil(err, t)
+	_, err = newCompressWriter(ioutil.Discard, "lz4", 0, nil)
+	notNil(err, t)
+}
+
+func TestCompressZip(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestCompressZip", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		Compression:  "zip",
+		CompressSync: true,
+		MaxBackups:   1,
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	first := backupFile(dir)
+	notExist(first, t)
+
+	// 归档中只有一个与原备份同名的条目
+	zr, err := zip.OpenReader(first + zipSuffix)
+	isNil(err, t)
+	equals(1, len(zr.File), t)
+	equals(filepath.Base(first), zr.File[0].Name, t)
+	rc, err := zr.File[0].Open()
+	isNil(err, t)
+	got, err := ioutil.ReadAll(rc)
+	isNil(err, t)
+	equals(b, got, t)
+	rc.Close()
+	zr.Close()
+
+	// .zip 备份同样参与 MaxBackups 清理
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	notExist(first+zipSuffix, t)
+	exists(backupFile(dir)+zipSuffix, t)
+	fileCount(dir, 2, t)
+}
+
+// reverseCompressor 是测试用的自定义压缩算法，把内容逆序写入 dst
+type reverseCompressor struct{ ext string }
+
+func (r reverseCompressor) Compress(src, dst string) error {
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return os.WriteFile(dst, b, 0600)
+}
+
+func (r reverseCompressor) Ext() string { return r.ext }
+
+func TestCustomCompressor(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestCustomCompressor", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:     logFile(dir),
+		MaxBackups:   1,
+		Checksum:     true,
+		CompressSync: true,
+		Compressor:   reverseCompressor{".rev"},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	first := backupFile(dir)
+	isNil(l.Rotate(), t)
+	existsWithContent(first+".rev", []byte("!oob"), t)
+	notExist(first, t)
+	isNil(VerifyChecksum(first+".rev"), t)
+
+	// 自定义后缀的备份同样计入 MaxBackups
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+	newFakeTime()
+	second := backupFile(dir)
+	isNil(l.Rotate(), t)
+	existsWithContent(second+".rev", []byte("!oof"), t)
+	notExist(first+".rev", t)
+}
+
+func TestRegisterCompressor(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestRegisterCompressor", t)
+	defer os.RemoveAll(dir)
+
+	RegisterCompressor("Reverse", reverseCompressor{".rvs"})
+	defer func() {
+		compressorsMu.Lock()
+		delete(compressors, "reverse")
+		compressorsMu.Unlock()
+	}()
+
+	l := &Logger{Filename: logFile(dir), Compression: "reverse", CompressSync: true}
+	defer l.Close()
+	isNil(l.Validate(), t)
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	existsWithContent(backupFile(dir)+".rvs", []byte("!oob"), t)
+
+	defer func() {
+		assert(recover() != nil, t, "expected panic for builtin name")
+	}()
+	RegisterCompressor("gzip", reverseCompressor{".x"})
+}
+
+func TestRegisterCompressorTwice(t *testing.T) {
+	RegisterCompressor("twice", reverseCompressor{".tw"})
+	defer func() {
+		compressorsMu.Lock()
+		delete(compressors, "twice")
+		compressorsMu.Unlock()
+	}()
+
+	defer func() {
+		assert(recover() != nil, t, "expected panic for duplicate name")
+		compressorsMu.RLock()
+		defer compressorsMu.RUnlock()
+		equals(".tw", compressors["twice"].Ext(), t)
+	}()
+	RegisterCompressor("Twice", reverseCompressor{".tw2"})
+}
+
+func TestGzipCompressor(t *testing.T) {
+	dir := makeTempDir("TestGzipCompressor", t)
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "a.log")
+	isNil(os.WriteFile(src, []byte("boo!"), 0600), t)
+
+	var c Compressor = GzipCompressor{Level: 9}
+	equals(".gz", c.Ext(), t)
+	isNil(c.Compress(src, src+c.Ext()), t)
+	exists(src, t)
+
+	f, err := os.Open(src + c.Ext())
+	isNil(err, t)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	isNil(err, t)
+	b, err := io.ReadAll(gz)
+	isNil(err, t)
+	equals("boo!", string(b), t)
+}
@@ -0,0 +1,42 @@
+package lumberjack
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestConcat(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestConcat", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), Compress: true, CompressSync: true}
+	defer l.Close()
+	for _, s := range []string{"one\n", "two", "three\n"} {
+		_, err := l.Write([]byte(s))
+		isNil(err, t)
+		newFakeTime()
+		isNil(l.Rotate(), t)
+	}
+	_, err := l.Write([]byte("four\n"))
+	isNil(err, t)
+
+	var buf bytes.Buffer
+	isNil(l.Concat(context.Background(), &buf, time.Time{}, time.Time{}), t)
+	equals("one\ntwo\nthree\nfour\n", buf.String(), t)
+
+	// 只选择内容与时间窗口有交集的文件
+	backups, err := l.Backups()
+	isNil(err, t)
+	buf.Reset()
+	isNil(l.Concat(context.Background(), &buf, backups[1].Timestamp.Add(time.Second), backups[0].Timestamp.Add(-time.Second)), t)
+	equals("three\n", buf.String(), t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	notNil(l.Concat(ctx, &buf, time.Time{}, time.Time{}), t)
+}
@@ -0,0 +1,164 @@
+package lumberjack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v2"
+)
+
+// 以 MB 为单位的配置项，配置文件中可以写成 "100MiB" 这样带单位的字符串
+var megabyteKeys = map[string]bool{
+	"maxsize":     true,
+	"mindiskfree": true,
+}
+
+// 以字节为单位的配置项，同样接受带单位的字符串
+var byteKeys = map[string]bool{
+	"buffersize":        true,
+	"syncbytes":         true,
+	"maxbytespersecond": true,
+	"maxlinebytes":      true,
+	"maxtotalsize":      true,
+}
+
+// time.Duration 类型的配置项，配置文件中必须写成 "72h" 这样的字符串
+var durationKeys = map[string]bool{
+	"maxageduration":      true,
+	"reopencheckinterval": true,
+	"syncevery":           true,
+	"rotationinterval":    true,
+	"processorretrydelay": true,
+	"thinafter":           true,
+	"trashttl":            true,
+	"streamflushinterval": true,
+	"diskcheckinterval":   true,
+	"uploadtimeout":       true,
+}
+
+// ConfigFromFile 从 JSON（.json）或 YAML（.yaml、.yml）配置文件创建 Logger，
+// 键名与 Logger 字段的 json/yaml 标签相同。大小类配置项（maxsize、
+// mindiskfree 以 MB 为单位，maxtotalsize、buffersize、syncbytes、
+// maxbytespersecond、maxlinebytes 以字节为单位）既可以写数字，也可以写
+// "100MiB"、"1.5GB" 这样带单位的字符串，单位均按 1024 进制换算；时长类配置项
+// （maxageduration、reopencheckinterval、syncevery、rotationinterval、
+// processorretrydelay、thinafter、trashttl、streamflushinterval、
+// diskcheckinterval、uploadtimeout）必须写成 "72h"、"30d" 这样的字符串，见
+// MaxAgeStr。未知的键名和写成数字的时长都会返回错误。返回的 Logger 尚未打开
+// 文件，其余配置错误在首次写入时才会报告。
+func ConfigFromFile(path string) (*Logger, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		err = json.Unmarshal(data, &raw)
+	case ".yaml", ".yml":
+		var v interface{}
+		if err = yaml.Unmarshal(data, &v); err == nil && v != nil {
+			m, ok := normalizeYAML(v).(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("config %s: expected a mapping at top level", path)
+			}
+			raw = m
+		}
+	default:
+		return nil, fmt.Errorf("config %s: unsupported format %q", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config %s: %s", path, err)
+	}
+
+	l, err := loggerFromConfig(raw)
+	if err != nil {
+		return nil, fmt.Errorf("config %s: %s", path, err)
+	}
+	return l, nil
+}
+
+// loggerFromConfig 按 json 标签把配置项转换为 Logger，带单位的大小和时长
+// 字符串先转换为对应字段的数值
+func loggerFromConfig(raw map[string]interface{}) (*Logger, error) {
+	if err := normalizeConfig(raw); err != nil {
+		return nil, err
+	}
+	// 统一转换为 JSON 后按 json 标签解析，各种配置来源的行为保持一致
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	// 拼错的键名（例如 maxbackup）作为错误报告，而不是被静默忽略
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	l := &Logger{}
+	if err := dec.Decode(l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// normalizeYAML 把 YAML 解析出的 map[interface{}]interface{} 递归转换为
+// map[string]interface{}，使其可以编码为 JSON
+func normalizeYAML(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[fmt.Sprint(k)] = normalizeYAML(val)
+		}
+		return m
+	case []interface{}:
+		for i, val := range v {
+			v[i] = normalizeYAML(val)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// normalizeConfig 把配置中带单位的大小和时长字符串转换为对应字段的数值。
+// 时长必须写成字符串，裸数字会被当作纳秒，容易误配，直接报错。
+func normalizeConfig(raw map[string]interface{}) error {
+	for k, v := range raw {
+		key := strings.ToLower(k)
+		s, ok := v.(string)
+		if !ok {
+			if durationKeys[key] {
+				return fmt.Errorf("%s: duration must be a string such as \"72h\", got %v", k, v)
+			}
+			continue
+		}
+		switch {
+		case megabyteKeys[key]:
+			n, err := parseSize(s)
+			if err != nil {
+				return fmt.Errorf("%s: %s", k, err)
+			}
+			if n%(1<<20) != 0 {
+				return fmt.Errorf("%s: %q is not a whole number of megabytes", k, s)
+			}
+			raw[k] = n >> 20
+		case byteKeys[key]:
+			n, err := parseSize(s)
+			if err != nil {
+				return fmt.Errorf("%s: %s", k, err)
+			}
+			raw[k] = n
+		case durationKeys[key]:
+			d, err := parseDuration(s)
+			if err != nil {
+				return fmt.Errorf("%s: %s", k, err)
+			}
+			raw[k] = int64(d)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfig(dir, name, content string, t testing.TB) string {
+	path := filepath.Join(dir, name)
+	isNilUp(ioutil.WriteFile(path, []byte(content), 0644), t, 1)
+	return path
+}
+
+func TestConfigFromFileJSON(t *testing.T) {
+	dir := makeTempDir("TestConfigFromFileJSON", t)
+	defer os.RemoveAll(dir)
+
+	path := writeConfig(dir, "log.json", `{
+	"filename": "/var/log/app.log",
+	"maxsize": "1.5GiB",
+	"maxbackups": 3,
+	"maxtotalsize": 200,
+	"maxageduration": "72h",
+	"buffersize": "64KiB",
+	"compress": true,
+	"droppolicy": "drop-oldest"
+}`, t)
+	l, err := ConfigFromFile(path)
+	isNil(err, t)
+	equals("/var/log/app.log", l.Filename, t)
+	equals(1536, l.MaxSize, t)
+	equals(3, l.MaxBackups, t)
+	equals(int64(200), l.MaxTotalSize, t)
+	equals(72*time.Hour, l.MaxAgeDuration, t)
+	equals(64*1024, l.BufferSize, t)
+	equals(true, l.Compress, t)
+	equals(DropOldest, l.DropPolicy, t)
+}
+
+func TestConfigFromFileYAML(t *testing.T) {
+	dir := makeTempDir("TestConfigFromFileYAML", t)
+	defer os.RemoveAll(dir)
+
+	path := writeConfig(dir, "log.yaml", `
+filename: /var/log/app.log
+maxsize: 100MiB
+mindiskfree: 1G
+syncevery: 500ms
+rotationinterval: 24h
+localtime: true
+agerecipients:
+  - age1abc
+`, t)
+	l, err := ConfigFromFile(path)
+	isNil(err, t)
+	equals("/var/log/app.log", l.Filename, t)
+	equals(100, l.MaxSize, t)
+	equals(1024, l.MinDiskFree, t)
+	equals(500*time.Millisecond, l.SyncEvery, t)
+	equals(24*time.Hour, l.RotationInterval, t)
+	equals(true, l.LocalTime, t)
+	equals([]string{"age1abc"}, l.AgeRecipients, t)
+}
+
+func TestConfigFromFileErrors(t *testing.T) {
+	dir := makeTempDir("TestConfigFromFileErrors", t)
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		"bad.toml":     `filename = "a.log"`,
+		"syntax.json":  `{"filename": `,
+		"size.json":    `{"maxsize": "lots"}`,
+		"partial.yaml": `maxsize: 512KB`,
+		"dur.yaml":     `syncevery: soon`,
+		"type.json":    `{"maxbackups": "three"}`,
+		"list.yaml":    `- a`,
+		"nanos.json":   `{"maxageduration": 72}`,
+		"nanos.yaml":   `syncevery: 500`,
+		"typo.json":    `{"maxbackup": 3}`,
+		"typo.yaml":    `compres: true`,
+	} {
+		_, err := ConfigFromFile(writeConfig(dir, name, content, t))
+		assert(err != nil, t, "expected error for %s", name)
+	}
+	_, err := ConfigFromFile(filepath.Join(dir, "missing.json"))
+	notNil(err, t)
+}
@@ -0,0 +1,121 @@
+package lumberjack
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// errAbandoned 表示 WriteContext 的调用方在写入开始前已经返回
+var errAbandoned = errors.New("write abandoned by caller")
+
+// WriteContext 与 Write 相同，但最多阻塞到 ctx 结束，用于限制写入在挂起的
+// NFS 挂载点、Windows 上重试的重命名或已满的异步队列（DropBlock）上等待的
+// 时间，避免拖住整个应用。ctx 结束时返回 ctx.Err()。写入尚未开始（还没有
+// 取得 Logger 的锁）时数据不会再写入文件；已经开始的写入无法中断，会在之后
+// 完成，但仍排在此后的写入之前，不会打乱顺序。同一时刻最多只有一个这样的
+// 写入在后台进行，其余调用在 ctx 结束前等待。
+func (l *Logger) WriteContext(ctx context.Context, p []byte) (n int, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	q, err := l.prepareRecord(p)
+	if err != nil {
+		l.metrics.writeErrors.Add(1)
+		return 0, err
+	}
+	if l.AsyncQueue > 0 {
+		n, err = l.writeAsync(ctx, q)
+		return recordN(p, q, n, err), err
+	}
+
+	l.ctxSlotOnce.Do(func() { l.ctxSlot = make(chan struct{}, 1) })
+	select {
+	case l.ctxSlot <- struct{}{}:
+	case <-ctx.Done():
+		l.metrics.writeErrors.Add(1)
+		return 0, ctx.Err()
+	}
+
+	// 超时返回后写入仍可能进行，不能继续引用调用方的切片
+	buf := append([]byte(nil), q...)
+	// state 为 0 表示等待中，1 表示写入已开始，2 表示调用方已放弃
+	var state atomic.Int32
+	type result struct {
+		n   int
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() { <-l.ctxSlot }()
+		n, err := l.writeClaimed(buf, func() bool {
+			return state.CompareAndSwap(0, 1)
+		})
+		done <- result{n, err}
+	}()
+
+	select {
+	case r := <-done:
+		return recordN(p, buf, r.n, r.err), r.err
+	case <-ctx.Done():
+		if !state.CompareAndSwap(0, 2) {
+			// 写入已经开始，数据会在稍后写入
+			l.logDebug("WriteContext 超时，已开始的写入将在后台完成，文件: %s", l.filename())
+		}
+		l.metrics.writeErrors.Add(1)
+		return 0, ctx.Err()
+	}
+}
+
+// WaitIdle 等待此前排队的后台工作（异步队列中的写入、轮转后的压缩、加密、
+// 打包与清理）全部完成后返回，不关闭 Logger。测试和 CI 可以据此确定地检查
+// 备份状态，而不必 sleep。ctx 结束时返回 ctx.Err()。
+func (l *Logger) WaitIdle(ctx context.Context) error {
+	if ok, err := l.flushAsync(ctx); ok && err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	idle, done := l.idleCh, l.done
+	l.mu.Unlock()
+	if idle == nil {
+		// 后台 goroutine 尚未启动，没有排队的工作
+		return nil
+	}
+
+	reply := make(chan struct{})
+	select {
+	case idle <- reply:
+	case <-done:
+		// 已关闭，后台 goroutine 退出前会完成正在进行的工作
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-reply:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CloseContext 与 Close 相同，并在后台 goroutine 退出前完成已排队的压缩与
+// 清理，使返回时备份目录处于确定的状态，不会留下处理到一半的文件。异步
+// 队列中的数据也会先写完。ctx 结束时返回 ctx.Err()，关闭仍在后台继续进行。
+func (l *Logger) CloseContext(ctx context.Context) error {
+	l.drain.Store(true)
+	done := make(chan error, 1)
+	go func() {
+		done <- l.Close()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		// 不再等待尚未完成的上传，使后台的关闭能够结束
+		l.cancelMill()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,186 @@
+package lumberjack
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWriteContext(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestWriteContext", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+	}
+	defer l.Close()
+
+	n, err := l.WriteContext(context.Background(), []byte("boo!"))
+	isNil(err, t)
+	equals(4, n, t)
+	existsWithContent(filename, []byte("boo!"), t)
+
+	// 模拟写入被阻塞
+	l.mu.Lock()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = l.WriteContext(ctx, []byte("foo"))
+	equals(context.DeadlineExceeded, err, t)
+	l.mu.Unlock()
+
+	// 超时时尚未开始的写入被放弃，不会在之后的写入后面落盘
+	n, err = l.WriteContext(context.Background(), []byte("bar"))
+	isNil(err, t)
+	equals(3, n, t)
+	existsWithContent(filename, []byte("boo!bar"), t)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = l.WriteContext(cancelled, []byte("baz"))
+	equals(context.Canceled, err, t)
+	existsWithContent(filename, []byte("boo!bar"), t)
+}
+
+func TestWriteContextSerialized(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestWriteContextSerialized", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename}
+	defer l.Close()
+
+	// 写入长时间阻塞时，后续调用在各自的 ctx 结束时返回，不会堆积 goroutine
+	l.mu.Lock()
+	for i := 0; i < 10; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		_, err := l.WriteContext(ctx, []byte("x"))
+		cancel()
+		equals(context.DeadlineExceeded, err, t)
+	}
+	l.mu.Unlock()
+
+	_, err := l.WriteContext(context.Background(), []byte("done"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("done"), t)
+}
+
+func TestWriteContextAsync(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestWriteContextAsync", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		AsyncQueue: 2,
+	}
+	defer l.Close()
+
+	// 队列已满时等待空位直到超时
+	release := fillAsyncQueue(l, t)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := l.WriteContext(ctx, []byte("d"))
+	equals(context.DeadlineExceeded, err, t)
+	release()
+
+	isNil(l.Flush(), t)
+	existsWithContent(filename, []byte("abc"), t)
+}
+
+func TestCloseContext(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestCloseContext", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		Compress: true,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+
+	// 返回时后台压缩已经完成
+	isNil(l.CloseContext(context.Background()), t)
+	notExist(backupFile(dir), t)
+	exists(backupFile(dir)+compressSuffix, t)
+	fileCount(dir, 2, t)
+}
+
+func TestCloseContextDeadline(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestCloseContextDeadline", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+	}
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// 模拟仍在进行的写入
+	l.mu.Lock()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	equals(context.DeadlineExceeded, l.CloseContext(ctx), t)
+	l.mu.Unlock()
+
+	// 关闭在后台继续完成
+	for i := 0; i < 100; i++ {
+		if _, err = l.Write([]byte("foo")); err != nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	notNil(err, t)
+}
+
+func TestWaitIdle(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestWaitIdle", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		Compress:   true,
+		AsyncQueue: 4,
+	}
+	defer l.Close()
+
+	// 后台 goroutine 尚未启动
+	isNil(l.WaitIdle(context.Background()), t)
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.WaitIdle(context.Background()), t)
+	existsWithContent(filename, []byte("boo!"), t)
+
+	for i := 0; i < 3; i++ {
+		isNil(l.Rotate(), t)
+		isNil(l.WaitIdle(context.Background()), t)
+		// 返回时压缩已经完成，Logger 仍可继续使用
+		notExist(backupFile(dir), t)
+		exists(backupFile(dir)+compressSuffix, t)
+		newFakeTime()
+		_, err = l.Write([]byte("foo"))
+		isNil(err, t)
+	}
+	isNil(l.WaitIdle(context.Background()), t)
+	fileCount(dir, 4, t)
+}
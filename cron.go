@@ -0,0 +1,179 @@
+package lumberjack
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule 是解析后的 5 字段 cron 表达式（分 时 日 月 周），
+// 每个字段用位图记录允许的取值。
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar / dowStar 记录日、周字段是否以 "*" 开头（包括 "*/2" 这样的
+	// 步长），用于实现标准 cron 的 "日与周任一匹配即可" 语义
+	domStar, dowStar bool
+}
+
+// cronBounds 描述一个 cron 字段的取值范围
+type cronBounds struct {
+	min, max int
+}
+
+var (
+	cronMinute = cronBounds{0, 59}
+	cronHour   = cronBounds{0, 23}
+	cronDom    = cronBounds{1, 31}
+	cronMonth  = cronBounds{1, 12}
+	cronDow    = cronBounds{0, 7} // 0 和 7 都表示周日
+)
+
+// cronDescriptors 是常用的 cron 缩写
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// parseCron 解析标准的 5 字段 cron 表达式，支持 "*"、"a-b"、"*/n"、"a-b/n"
+// 以及逗号分隔的列表，另外支持 @daily、@hourly 等缩写。
+func parseCron(spec string) (*cronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if d, ok := cronDescriptors[spec]; ok {
+		spec = d
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields in cron expression %q, got %d", spec, len(fields))
+	}
+
+	s := &cronSchedule{}
+	var err error
+	if s.minute, err = parseCronField(fields[0], cronMinute); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseCronField(fields[1], cronHour); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseCronField(fields[2], cronDom); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseCronField(fields[3], cronMonth); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseCronField(fields[4], cronDow); err != nil {
+		return nil, err
+	}
+	// 把 7 归一为 0（周日）
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	// 与 vixie cron 一致，以 "*" 开头的字段都不算限定
+	s.domStar = strings.HasPrefix(fields[2], "*")
+	s.dowStar = strings.HasPrefix(fields[4], "*")
+	return s, nil
+}
+
+// parseCronField 把单个 cron 字段解析为位图
+func parseCronField(field string, b cronBounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in cron field %q", field)
+			}
+			rng, step = part[:i], n
+		}
+
+		lo, hi := b.min, b.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			i := strings.Index(rng, "-")
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(rng[:i])
+			hi, err2 = strconv.Atoi(rng[i+1:])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range in cron field %q", field)
+			}
+		default:
+			n, err := strconv.Atoi(rng)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value in cron field %q", field)
+			}
+			lo, hi = n, n
+			if step > 1 {
+				hi = b.max
+			}
+		}
+		if lo < b.min || hi > b.max || lo > hi {
+			return 0, fmt.Errorf("cron field %q out of range [%d, %d]", field, b.min, b.max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// next 返回 t 之后（不含 t）第一个满足表达式的时间点，时区与 t 相同。
+// 五年内都没有匹配时返回零值。
+func (s *cronSchedule) next(t time.Time) time.Time {
+	loc := t.Location()
+	// 从下一个整分钟开始查找
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	yearLimit := t.Year() + 5
+
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for s.month&(1<<uint(t.Month())) == 0 {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+
+	for !s.dayMatches(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+
+	for s.hour&(1<<uint(t.Hour())) == 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+
+	for s.minute&(1<<uint(t.Minute())) == 0 {
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+
+	return t
+}
+
+// dayMatches 判断 t 的日期是否同时满足日、周字段。与标准 cron 一致，
+// 日和周都被限定时任一匹配即可。
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
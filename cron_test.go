@@ -0,0 +1,50 @@
+package lumberjack
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	from := time.Date(2024, 5, 31, 13, 47, 12, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 5, 31, 13, 48, 0, 0, time.UTC)},
+		{"0 0 * * *", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 5, 31, 14, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 5, 31, 14, 0, 0, 0, time.UTC)},
+		{"30 2-4 * * *", time.Date(2024, 6, 1, 2, 30, 0, 0, time.UTC)},
+		{"0 12 1,15 * *", time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)},
+		{"0 0 * * 1", time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 1 *", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// 带步长的日字段视为 "*"，日与周需同时匹配：6 月 3 日是单日且为周一
+		{"0 0 */2 * 1", time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		s, err := parseCron(test.spec)
+		isNil(err, t)
+		equals(test.want, s.next(from), t)
+	}
+}
+
+func TestCronInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		_, err := parseCron(spec)
+		notNil(err, t)
+	}
+}
@@ -0,0 +1,139 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// diskCheckInterval 是未设置 DiskCheckInterval 时两次磁盘空间检查之间的最小
+// 间隔，避免每次写入都调用 statfs
+const diskCheckInterval = time.Second
+
+// diskFree and diskTotal exist so they can be mocked out by tests.
+var (
+	diskFree  = freeSpace
+	diskTotal = totalSpace
+)
+
+// diskWatched 判断是否配置了 MinDiskFree 或 DiskUsageWatermark
+func (l *Logger) diskWatched() bool {
+	return l.MinDiskFree > 0 || l.DiskUsageWatermark > 0
+}
+
+// checkDiskFree 在配置了 MinDiskFree 或 DiskUsageWatermark 时检查日志所在卷的
+// 空间，距上次检查不足检查间隔时沿用上次的结果。空间不足时先从最旧的备份开始
+// 删除；删除全部备份后剩余空间仍低于 MinDiskFree 则返回错误，暂停写入，直到
+// 空间恢复。设置 DropOnDiskPressure 时改为返回 drop 为 true，由调用方丢弃本次
+// 写入，使用率超过 DiskUsageWatermark 时同样如此。调用方必须持有 l.mu。
+func (l *Logger) checkDiskFree() (drop bool, err error) {
+	if !l.diskWatched() {
+		return false, nil
+	}
+	now := l.now()
+	if l.lastDiskCheck.IsZero() || now.Sub(l.lastDiskCheck) >= l.diskCheckInterval() {
+		l.lastDiskCheck = now
+		l.checkDiskSpace()
+	}
+	if l.DropOnDiskPressure {
+		return l.diskLow || l.diskOver, nil
+	}
+	if l.diskLow {
+		return false, fmt.Errorf("free disk space below MinDiskFree of %d MB", l.MinDiskFree)
+	}
+	return false, nil
+}
+
+// diskCheckInterval 返回两次磁盘空间检查之间的最小间隔
+func (l *Logger) diskCheckInterval() time.Duration {
+	if l.DiskCheckInterval > 0 {
+		return l.DiskCheckInterval
+	}
+	return diskCheckInterval
+}
+
+// checkDiskSpace 获取日志所在卷的剩余空间，低于 MinDiskFree 或使用率超过
+// DiskUsageWatermark 时清理备份，并记录清理后的结果。调用方必须持有 l.mu。
+func (l *Logger) checkDiskSpace() {
+	free, err := diskFree(l.dir())
+	if err != nil {
+		// 无法获取剩余空间（例如平台不支持）时不阻塞写入
+		l.logDebug("获取磁盘剩余空间失败: %v，文件: %s", err, l.filename())
+		l.diskLow, l.diskOver = false, false
+		return
+	}
+	min := uint64(l.MinDiskFree) * uint64(megabyte)
+
+	// 使用率水位换算为需要保留的剩余空间
+	var watermark uint64
+	if l.DiskUsageWatermark > 0 {
+		total, err := diskTotal(l.dir())
+		if err != nil {
+			l.logDebug("获取磁盘容量失败: %v，文件: %s", err, l.filename())
+		} else {
+			watermark = total - uint64(float64(total)*l.DiskUsageWatermark/100)
+		}
+	}
+
+	target := min
+	if watermark > target {
+		target = watermark
+	}
+	if free < target {
+		free = l.purgeForSpace(free, target)
+	}
+	l.diskLow = free < min
+	l.diskOver = free < watermark
+	if l.diskLow || l.diskOver {
+		l.logDebug("删除全部备份后磁盘空间仍然不足，剩余 %d 字节，文件: %s", free, l.filename())
+	}
+}
+
+// diskTicker 返回后台 goroutine 定期检查磁盘空间使用的 ticker，
+// 未设置 DiskCheckInterval 时返回永不触发的 ticker
+func (l *Logger) diskTicker() *ticker {
+	if !l.diskWatched() || l.DiskCheckInterval <= 0 {
+		return &ticker{}
+	}
+	return l.newTicker(l.DiskCheckInterval)
+}
+
+// watchDisk 由后台 goroutine 定期调用，写入停止时也能及时清理备份并更新
+// 磁盘空间状态
+func (l *Logger) watchDisk() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
+	l.lastDiskCheck = l.now()
+	l.checkDiskSpace()
+}
+
+// purgeForSpace 从最旧的备份开始逐个删除，直到剩余空间达到 min 或没有备份可删，
+// 返回删除后的剩余空间。与 purge 一样持有 backupMu、跳过被保留的备份并通过
+// expire 删除，设置 TrashDir 时备份移入回收站（与日志在同一卷上时不释放空间）。
+func (l *Logger) purgeForSpace(free, min uint64) uint64 {
+	l.backupMu.Lock()
+	defer l.backupMu.Unlock()
+
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return free
+	}
+	files = l.withoutHeld(files)
+	for i := len(files) - 1; i >= 0 && free < min; i-- {
+		name := files[i].path()
+		if err := l.expire(name); err != nil && !os.IsNotExist(err) {
+			continue
+		}
+		l.metrics.removals.Add(1)
+		l.notifyRemove(name)
+		l.pruneBackupDir(files[i].dir)
+		l.logDebug("磁盘剩余空间不足，删除备份: %s", name)
+		if f, err := diskFree(l.dir()); err == nil {
+			free = f
+		}
+	}
+	return free
+}
@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !freebsd && !windows
+// +build !linux,!darwin,!freebsd,!windows
+
+package lumberjack
+
+import (
+	"errors"
+)
+
+// freeSpace 在不支持的平台上总是返回错误，MinDiskFree 检查因此被跳过
+func freeSpace(_ string) (uint64, error) {
+	return 0, errors.New("free disk space check not supported on this platform")
+}
+
+// totalSpace 在不支持的平台上总是返回错误，DiskUsageWatermark 检查因此被跳过
+func totalSpace(_ string) (uint64, error) {
+	return 0, errors.New("disk capacity check not supported on this platform")
+}
@@ -0,0 +1,186 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestMinDiskFree(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	// goroutine_leak_test.go 中的测试依赖默认的 megabyte，这里需要还原
+	defer func() { megabyte = 1024 * 1024 }()
+
+	dir := makeTempDir("TestMinDiskFree", t)
+	defer os.RemoveAll(dir)
+
+	// 模拟一个容量为 capacity 字节的卷，剩余空间为容量减去目录中文件的总大小
+	capacity := uint64(18)
+	diskFree = func(dir string) (uint64, error) {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return 0, err
+		}
+		var used uint64
+		for _, f := range files {
+			used += uint64(f.Size())
+		}
+		if used > capacity {
+			return 0, nil
+		}
+		return capacity - used, nil
+	}
+	defer func() { diskFree = freeSpace }()
+
+	data := []byte("data")
+	older := backupFile(dir)
+	isNil(ioutil.WriteFile(older, data, 0644), t)
+	newFakeTime()
+	newer := backupFile(dir)
+	isNil(ioutil.WriteFile(newer, data, 0644), t)
+	newFakeTime()
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:    filename,
+		MinDiskFree: 11,
+	}
+	defer l.Close()
+
+	// 剩余 10 字节低于 11，删除最旧的备份后恢复到 14 字节，写入成功
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+	notExist(older, t)
+	exists(newer, t)
+
+	// 卷容量变小，删除全部备份后仍然不足，写入被拒绝
+	capacity = 12
+	newFakeTime()
+	n, err = l.Write(b)
+	notNil(err, t)
+	equals(0, n, t)
+	notExist(newer, t)
+	existsWithContent(filename, b, t)
+
+	// 空间恢复后写入继续
+	capacity = 100
+	newFakeTime()
+	n, err = l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+	existsWithContent(filename, append(b, b...), t)
+}
+
+func TestDiskUsageWatermark(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestDiskUsageWatermark", t)
+	defer os.RemoveAll(dir)
+
+	// 模拟一个容量为 100 字节的卷，其他程序占用 used 字节
+	used := uint64(80)
+	diskFree = func(dir string) (uint64, error) {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return 0, err
+		}
+		var size uint64
+		for _, f := range files {
+			size += uint64(f.Size())
+		}
+		if used+size > 100 {
+			return 0, nil
+		}
+		return 100 - used - size, nil
+	}
+	diskTotal = func(string) (uint64, error) { return 100, nil }
+	defer func() {
+		diskFree = freeSpace
+		diskTotal = totalSpace
+	}()
+
+	backup := backupFile(dir)
+	isNil(ioutil.WriteFile(backup, []byte("0123456789"), 0644), t)
+	newFakeTime()
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:           filename,
+		DiskUsageWatermark: 85,
+		DropOnDiskPressure: true,
+	}
+	defer l.Close()
+
+	// 使用率 90% 超过水位，删除备份后回到 80%，写入成功
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	notExist(backup, t)
+	existsWithContent(filename, b, t)
+
+	// 删除全部备份后仍超过水位，写入被丢弃并计数
+	used = 95
+	newFakeTime()
+	n, err := l.Write([]byte("foo"))
+	isNil(err, t)
+	equals(3, n, t)
+	existsWithContent(filename, b, t)
+	equals(int64(1), l.Stats().DiskDropped, t)
+
+	// 压力解除后继续写入
+	used = 0
+	newFakeTime()
+	_, err = l.Write([]byte("bar"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("boo!bar"), t)
+}
+
+func TestValidateDiskUsageWatermark(t *testing.T) {
+	l := &Logger{Filename: "foo.log", DiskUsageWatermark: 120}
+	notNil(l.Validate(), t)
+
+	l = &Logger{Filename: "foo.log", DiskUsageWatermark: 90, DiskCheckInterval: -1}
+	notNil(l.Validate(), t)
+}
+
+func TestMinDiskFreeHeldAndChecksum(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	defer func() { megabyte = 1024 * 1024 }()
+
+	dir := makeTempDir("TestMinDiskFreeHeldAndChecksum", t)
+	defer os.RemoveAll(dir)
+
+	held := backupFile(dir)
+	isNil(ioutil.WriteFile(held, []byte("held"), 0644), t)
+	newFakeTime()
+	older := backupFile(dir)
+	isNil(ioutil.WriteFile(older, []byte("data"), 0644), t)
+	sum, err := fileChecksum(older)
+	isNil(err, t)
+	isNil(writeChecksum(older, sum), t)
+	newFakeTime()
+
+	// older 删除前空间不足
+	diskFree = func(string) (uint64, error) {
+		if _, err := os.Stat(older); err == nil {
+			return 0, nil
+		}
+		return 100, nil
+	}
+	defer func() { diskFree = freeSpace }()
+
+	l := &Logger{Filename: logFile(dir), MinDiskFree: 10}
+	defer l.Close()
+	isNil(l.Hold(held), t)
+
+	// 被保留的备份不删除，删除的备份连同校验和旁路文件一起移除
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+	exists(held, t)
+	notExist(older, t)
+	notExist(older+checksumSuffix, t)
+}
@@ -0,0 +1,26 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package lumberjack
+
+import (
+	"syscall"
+)
+
+// freeSpace 返回 dir 所在卷上非特权用户可用的剩余字节数
+func freeSpace(dir string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
+
+// totalSpace 返回 dir 所在卷的总容量（字节）
+func totalSpace(dir string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Blocks) * uint64(st.Bsize), nil
+}
@@ -0,0 +1,41 @@
+//go:build windows
+// +build windows
+
+package lumberjack
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var procGetDiskFreeSpaceExW = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// freeSpace 返回 dir 所在卷上当前用户可用的剩余字节数
+func freeSpace(dir string) (uint64, error) {
+	avail, _, err := diskSpace(dir)
+	return avail, err
+}
+
+// totalSpace 返回 dir 所在卷的总容量（字节）
+func totalSpace(dir string) (uint64, error) {
+	_, total, err := diskSpace(dir)
+	return total, err
+}
+
+// diskSpace 以 GetDiskFreeSpaceExW 获取当前用户可用的剩余字节数和卷的总容量
+func diskSpace(dir string) (avail, total uint64, err error) {
+	pathp, err := syscall.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	r, _, e := procGetDiskFreeSpaceExW.Call(
+		uintptr(unsafe.Pointer(pathp)),
+		uintptr(unsafe.Pointer(&avail)),
+		uintptr(unsafe.Pointer(&total)),
+		0,
+	)
+	if r == 0 {
+		return 0, 0, e
+	}
+	return avail, total, nil
+}
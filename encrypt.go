@@ -0,0 +1,384 @@
+package lumberjack
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const (
+	// encSuffix 是加密备份的文件后缀
+	encSuffix = ".enc"
+
+	// encMagic 是加密文件的头部标识，其后紧跟 encPrefixSize 字节的随机 nonce 前缀
+	encMagic      = "LJE1"
+	encPrefixSize = 7
+	// encChunkSize 是每个加密分块的明文长度
+	encChunkSize = 64 * 1024
+)
+
+// 加密文件格式：
+//
+//	magic(4) | nonce 前缀(7) | 分块 1 | 分块 2 | ...
+//
+// 每个分块是最多 encChunkSize 字节明文的 AES-256-GCM 密文（含 16 字节认证标签）。
+// 分块的 nonce 为 前缀(7) | 分块序号(4，大端) | 末块标志(1)，末块标志使截断的
+// 文件无法通过校验。
+
+// encryptSuffixes 是所有可识别的加密备份后缀
+var encryptSuffixes = []string{encSuffix, ageSuffix}
+
+// trimEncryptSuffix 去掉文件名中已知的加密后缀
+func trimEncryptSuffix(name string) string {
+	for _, suffix := range encryptSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return strings.TrimSuffix(name, suffix)
+		}
+	}
+	return name
+}
+
+// isEncrypted 判断文件名是否带有已知的加密后缀
+func isEncrypted(name string) bool {
+	return trimEncryptSuffix(name) != name
+}
+
+// sourceName 返回生成 name 的上一步文件名：加密文件对应加密前的文件，
+// 压缩文件对应压缩前的文件，其余返回 name 本身
+func sourceName(name string) string {
+	if isEncrypted(name) {
+		return trimEncryptSuffix(name)
+	}
+	return trimCompressSuffix(name)
+}
+
+// backupEncrypter 描述备份的加密方式：加密后的文件后缀，以及把明文加密后
+// 写入下层 writer 的构造函数
+type backupEncrypter struct {
+	suffix    string
+	newWriter func(w io.Writer) (io.WriteCloser, error)
+}
+
+// encrypter 返回配置的加密方式，未启用加密时返回 nil
+func (l *Logger) encrypter() (*backupEncrypter, error) {
+	if l.Encrypt && len(l.AgeRecipients) > 0 {
+		return nil, errors.New("Encrypt cannot be combined with AgeRecipients")
+	}
+	if l.Encrypt {
+		key, err := l.encryptionKey()
+		if err != nil {
+			return nil, err
+		}
+		return &backupEncrypter{encSuffix, func(w io.Writer) (io.WriteCloser, error) {
+			return newEncryptWriter(w, key)
+		}}, nil
+	}
+	if len(l.AgeRecipients) > 0 {
+		return l.ageEncrypter()
+	}
+	return nil, nil
+}
+
+// encryptionKey 返回加密备份使用的密钥，优先使用 EncryptionKey，
+// 否则从 EncryptionKeyFile 读取
+func (l *Logger) encryptionKey() ([]byte, error) {
+	key := l.EncryptionKey
+	if len(key) == 0 {
+		if l.EncryptionKeyFile == "" {
+			return nil, errors.New("Encrypt requires EncryptionKey or EncryptionKeyFile")
+		}
+		var err error
+		if key, err = ReadKeyFile(l.EncryptionKeyFile); err != nil {
+			return nil, err
+		}
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+// ReadKeyFile 读取密钥文件。文件内容可以是 32 字节的原始密钥，也可以是
+// 64 个十六进制字符（首尾空白会被忽略）。
+func ReadKeyFile(name string) ([]byte, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("can't read encryption key file: %s", err)
+	}
+	if s := strings.TrimSpace(string(b)); len(s) == 64 {
+		if key, err := hex.DecodeString(s); err == nil {
+			return key, nil
+		}
+	}
+	if len(b) != 32 {
+		return nil, fmt.Errorf("encryption key file %s must contain 32 raw bytes or 64 hex characters", name)
+	}
+	return b, nil
+}
+
+// newGCM 用 32 字节密钥创建 AES-256-GCM
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce 返回第 n 个分块的 nonce
+func chunkNonce(prefix []byte, n uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[encPrefixSize:], n)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// encryptWriter 把写入的明文分块加密后写入下层 writer，Close 时写出末块
+type encryptWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	prefix []byte
+	n      uint32
+	buf    []byte
+	closed bool
+}
+
+// newEncryptWriter 返回把数据加密后写入 w 的 writer，调用方必须调用 Close
+// 才能写出最后一个分块
+func newEncryptWriter(w io.Writer, key []byte) (io.WriteCloser, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	prefix := make([]byte, encPrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(w, encMagic); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(prefix); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{w: w, aead: aead, prefix: prefix, buf: make([]byte, 0, encChunkSize)}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("write to closed encrypt writer")
+	}
+	written := 0
+	for len(p) > 0 {
+		// 缓冲区已满且还有数据时才写出，保证 Close 时总有一个末块
+		if len(e.buf) == encChunkSize {
+			if err := e.flush(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(e.buf[len(e.buf):encChunkSize], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (e *encryptWriter) flush(last bool) error {
+	out := e.aead.Seal(nil, chunkNonce(e.prefix, e.n, last), e.buf, nil)
+	e.n++
+	e.buf = e.buf[:0]
+	_, err := e.w.Write(out)
+	return err
+}
+
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.flush(true)
+}
+
+// decryptReader 逐块解密由 encryptWriter 写出的数据
+type decryptReader struct {
+	r      *bufio.Reader
+	aead   cipher.AEAD
+	prefix []byte
+	n      uint32
+	chunk  []byte // 待解密的密文分块
+	plain  []byte // 解密后尚未读出的明文
+	done   bool
+}
+
+// NewDecryptReader 返回解密 r 中加密备份内容的 reader。数据被篡改或截断时
+// Read 返回错误。
+func NewDecryptReader(r io.Reader, key []byte) (io.Reader, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, len(encMagic)+encPrefixSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("can't read encryption header: %s", err)
+	}
+	if string(header[:len(encMagic)]) != encMagic {
+		return nil, errors.New("not an encrypted backup")
+	}
+	return &decryptReader{
+		r:      bufio.NewReader(r),
+		aead:   aead,
+		prefix: header[len(encMagic):],
+		chunk:  make([]byte, encChunkSize+aead.Overhead()),
+	}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// next 读取并解密下一个分块
+func (d *decryptReader) next() error {
+	n, err := io.ReadFull(d.r, d.chunk)
+	last := false
+	switch err {
+	case nil:
+		// 分块读满时，后面没有数据说明它就是末块
+		if _, err := d.r.Peek(1); err == io.EOF {
+			last = true
+		}
+	case io.ErrUnexpectedEOF:
+		last = true
+	case io.EOF:
+		return errors.New("encrypted backup is truncated")
+	default:
+		return err
+	}
+	plain, err := d.aead.Open(d.chunk[:0:0], chunkNonce(d.prefix, d.n, last), d.chunk[:n], nil)
+	if err != nil {
+		return errors.New("encrypted backup is corrupt or truncated, or the key is wrong")
+	}
+	d.n++
+	d.plain = plain
+	d.done = last
+	return nil
+}
+
+// DecryptFile 用 key 解密加密备份 src，把明文写入 dst
+func DecryptFile(src, dst string, key []byte) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("can't open encrypted backup: %s", err)
+	}
+	defer in.Close()
+
+	r, err := NewDecryptReader(in, key)
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("can't open decrypted file: %s", err)
+	}
+	defer func() {
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(dst)
+		}
+	}()
+	if _, err := io.Copy(out, r); err != nil {
+		return fmt.Errorf("failed to decrypt %s: %s", src, err)
+	}
+	return nil
+}
+
+// encryptBackup 按 enc 加密备份 src，生成带加密后缀的文件，成功后按重试策略 p
+// 删除 src
+func encryptBackup(src string, enc *backupEncrypter, checksum bool, p RetryPolicy) (err error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open backup for encryption: %v", err)
+	}
+	defer f.Close()
+
+	fi, err := osStat(src)
+	if err != nil {
+		return fmt.Errorf("failed to stat backup for encryption: %v", err)
+	}
+
+	dst := src + enc.suffix
+	if err := chown(dst, fi.Mode(), fi); err != nil {
+		return fmt.Errorf("failed to chown encrypted backup: %v", err)
+	}
+	out, err := openFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, fi.Mode())
+	if err != nil {
+		return fmt.Errorf("failed to open encrypted backup: %v", err)
+	}
+	defer out.Close()
+
+	defer func() {
+		if err != nil {
+			os.Remove(dst)
+			err = fmt.Errorf("failed to encrypt backup: %v", err)
+		}
+	}()
+
+	h := sha256.New()
+	w, err := enc.newWriter(io.MultiWriter(out, h))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if checksum {
+		sum := h.Sum(nil)
+		if err := verifyWritten(dst, sum); err != nil {
+			return err
+		}
+		if err := writeChecksum(dst, sum); err != nil {
+			return err
+		}
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chtimes(dst, fi.ModTime(), fi.ModTime()); err != nil {
+		return err
+	}
+	return removeBackup(p, src)
+}
@@ -0,0 +1,140 @@
+package lumberjack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testKey = bytes.Repeat([]byte{0x42}, 32)
+
+func encryptBytes(data, key []byte, t testing.TB) []byte {
+	var buf bytes.Buffer
+	w, err := newEncryptWriter(&buf, key)
+	isNilUp(err, t, 1)
+	_, err = w.Write(data)
+	isNilUp(err, t, 1)
+	isNilUp(w.Close(), t, 1)
+	return buf.Bytes()
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 1, encChunkSize - 1, encChunkSize, encChunkSize + 1, 3 * encChunkSize} {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i * 7)
+		}
+		r, err := NewDecryptReader(bytes.NewReader(encryptBytes(data, testKey, t)), testKey)
+		isNil(err, t)
+		got, err := ioutil.ReadAll(r)
+		isNil(err, t)
+		assert(bytes.Equal(data, got), t, "round trip mismatch for size %d", size)
+	}
+}
+
+func TestDecryptDetectsTampering(t *testing.T) {
+	data := bytes.Repeat([]byte("log line\n"), encChunkSize/4)
+	enc := encryptBytes(data, testKey, t)
+
+	// 截断在分块边界上
+	truncated := enc[:len(encMagic)+encPrefixSize+encChunkSize+16]
+	r, err := NewDecryptReader(bytes.NewReader(truncated), testKey)
+	isNil(err, t)
+	_, err = ioutil.ReadAll(r)
+	notNil(err, t)
+
+	// 修改一个字节
+	tampered := append([]byte(nil), enc...)
+	tampered[len(tampered)-1] ^= 1
+	r, err = NewDecryptReader(bytes.NewReader(tampered), testKey)
+	isNil(err, t)
+	_, err = ioutil.ReadAll(r)
+	notNil(err, t)
+
+	// 错误的密钥
+	r, err = NewDecryptReader(bytes.NewReader(enc), bytes.Repeat([]byte{1}, 32))
+	isNil(err, t)
+	_, err = ioutil.ReadAll(r)
+	notNil(err, t)
+
+	_, err = NewDecryptReader(bytes.NewReader([]byte("plain text")), testKey)
+	notNil(err, t)
+}
+
+func TestEncryptBackups(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestEncryptBackups", t)
+	defer os.RemoveAll(dir)
+
+	keyFile := filepath.Join(dir, "key")
+	isNil(ioutil.WriteFile(keyFile, []byte(hex.EncodeToString(testKey)+"\n"), 0600), t)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:          filename,
+		Compress:          true,
+		CompressSync:      true,
+		Encrypt:           true,
+		EncryptionKeyFile: keyFile,
+		MaxBackups:        1,
+	}
+	defer l.Close()
+
+	b := []byte("secret!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+
+	first := backupFile(dir)
+	notExist(first, t)
+	notExist(first+compressSuffix, t)
+	exists(first+compressSuffix+encSuffix, t)
+
+	plain := filepath.Join(dir, "decrypted.gz")
+	isNil(DecryptFile(first+compressSuffix+encSuffix, plain, testKey), t)
+	f, err := os.Open(plain)
+	isNil(err, t)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	isNil(err, t)
+	got, err := ioutil.ReadAll(gz)
+	isNil(err, t)
+	equals(b, got, t)
+	isNil(os.Remove(plain), t)
+
+	// 加密后的备份同样参与 MaxBackups 清理
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	notExist(first+compressSuffix+encSuffix, t)
+	exists(backupFile(dir)+compressSuffix+encSuffix, t)
+	fileCount(dir, 3, t)
+}
+
+func TestEncryptionKey(t *testing.T) {
+	dir := makeTempDir("TestEncryptionKey", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Encrypt: true}
+	_, err := l.encryptionKey()
+	notNil(err, t)
+
+	l.EncryptionKey = []byte("short")
+	_, err = l.encryptionKey()
+	notNil(err, t)
+
+	raw := filepath.Join(dir, "raw")
+	isNil(ioutil.WriteFile(raw, testKey, 0600), t)
+	key, err := ReadKeyFile(raw)
+	isNil(err, t)
+	equals(testKey, key, t)
+
+	bad := filepath.Join(dir, "bad")
+	isNil(ioutil.WriteFile(bad, []byte("not a key"), 0600), t)
+	_, err = ReadKeyFile(bad)
+	notNil(err, t)
+}
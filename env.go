@@ -0,0 +1,94 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// FromEnv 从环境变量创建 Logger，适合不便挂载配置文件的容器部署。变量名由
+// prefix、下划线与字段名的大写下划线形式组成，例如 prefix 为 "LUMBERJACK" 时
+// 读取 LUMBERJACK_FILENAME、LUMBERJACK_MAX_SIZE、LUMBERJACK_COMPRESS 等，
+// prefix 为空时使用 "LUMBERJACK"。取值规则与 ConfigFromFile 相同：大小可以写
+// 数字或 "100MiB"，时长可以写 "72h"；布尔值接受 strconv.ParseBool 的写法；
+// FILE_MODE、DIR_MODE 按八进制解析；AGE_RECIPIENTS 以逗号分隔。未设置的变量
+// 保持字段的零值，函数、接口等无法序列化的字段不能通过环境变量设置。
+func FromEnv(prefix string) (*Logger, error) {
+	if prefix == "" {
+		prefix = "LUMBERJACK"
+	}
+	raw := make(map[string]interface{})
+	t := reflect.TypeOf(Logger{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")[0]
+		if f.PkgPath != "" || tag == "" || tag == "-" {
+			continue
+		}
+		name := prefix + "_" + envName(f.Name)
+		s, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		v, err := envValue(f.Type, s)
+		if err != nil {
+			return nil, fmt.Errorf("env %s: %s", name, err)
+		}
+		raw[tag] = v
+	}
+	l, err := loggerFromConfig(raw)
+	if err != nil {
+		return nil, fmt.Errorf("env %s_*: %s", prefix, err)
+	}
+	return l, nil
+}
+
+// envName 把字段名转换为大写下划线形式，例如 MaxAgeDuration 转换为
+// MAX_AGE_DURATION，CopyACL 转换为 COPY_ACL
+func envName(field string) string {
+	r := []rune(field)
+	var b strings.Builder
+	for i, c := range r {
+		if i > 0 && unicode.IsUpper(c) &&
+			(unicode.IsLower(r[i-1]) || (i+1 < len(r) && unicode.IsLower(r[i+1]))) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(c))
+	}
+	return b.String()
+}
+
+// envValue 按字段类型解析环境变量的值。整数字段的值不是纯数字时原样返回，
+// 交给 normalizeConfig 按大小或时长解析。
+func envValue(t reflect.Type, s string) (interface{}, error) {
+	switch t.Kind() {
+	case reflect.String:
+		return s, nil
+	case reflect.Bool:
+		return strconv.ParseBool(s)
+	case reflect.Int, reflect.Int64:
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n, nil
+		}
+		return s, nil
+	case reflect.Float64:
+		return strconv.ParseFloat(s, 64)
+	case reflect.Uint32:
+		// os.FileMode
+		return strconv.ParseUint(s, 8, 32)
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String {
+			var list []string
+			for _, item := range strings.Split(s, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, item)
+				}
+			}
+			return list, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported field type %s", t)
+}
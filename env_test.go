@@ -0,0 +1,89 @@
+package lumberjack
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("LUMBERJACK_FILENAME", "/var/log/app.log")
+	t.Setenv("LUMBERJACK_MAX_SIZE", "500")
+	t.Setenv("LUMBERJACK_MAX_TOTAL_SIZE", "2GiB")
+	t.Setenv("LUMBERJACK_MAX_BACKUPS", "7")
+	t.Setenv("LUMBERJACK_MAX_AGE_DURATION", "72h")
+	t.Setenv("LUMBERJACK_COMPRESS", "true")
+	t.Setenv("LUMBERJACK_LOCAL_TIME", "1")
+	t.Setenv("LUMBERJACK_FILE_MODE", "0640")
+	t.Setenv("LUMBERJACK_INSTANCE_ID", "web-1")
+	t.Setenv("LUMBERJACK_COPY_ACL", "directory")
+	t.Setenv("LUMBERJACK_AGE_RECIPIENTS", "age1abc, age1def")
+
+	l, err := FromEnv("")
+	isNil(err, t)
+	equals("/var/log/app.log", l.Filename, t)
+	equals(500, l.MaxSize, t)
+	equals(int64(2<<30), l.MaxTotalSize, t)
+	equals(7, l.MaxBackups, t)
+	equals(72*time.Hour, l.MaxAgeDuration, t)
+	equals(true, l.Compress, t)
+	equals(true, l.LocalTime, t)
+	equals(os.FileMode(0640), l.FileMode, t)
+	equals("web-1", l.InstanceID, t)
+	equals(ACLFromDir, l.CopyACL, t)
+	equals([]string{"age1abc", "age1def"}, l.AgeRecipients, t)
+}
+
+func TestFromEnvPrefix(t *testing.T) {
+	t.Setenv("LUMBERJACK_FILENAME", "default.log")
+	t.Setenv("APP_LOG_FILENAME", "app.log")
+
+	l, err := FromEnv("APP_LOG")
+	isNil(err, t)
+	equals("app.log", l.Filename, t)
+	equals(0, l.MaxSize, t)
+}
+
+func TestFromEnvErrors(t *testing.T) {
+	for name, value := range map[string]string{
+		"LUMBERJACK_COMPRESS":   "maybe",
+		"LUMBERJACK_MAX_SIZE":   "huge",
+		"LUMBERJACK_FILE_MODE":  "rw",
+		"LUMBERJACK_SYNC_EVERY": "often",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			_, err := FromEnv("LUMBERJACK")
+			notNil(err, t)
+			assert(strings.Contains(err.Error(), "LUMBERJACK"), t, "expected variable name in error, got %v", err)
+		})
+	}
+}
+
+// 所有可序列化的配置项都可以通过环境变量设置
+func TestFromEnvFieldTypes(t *testing.T) {
+	typ := reflect.TypeOf(Logger{})
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != "" || f.Tag.Get("json") == "-" {
+			continue
+		}
+		_, err := envValue(f.Type, "1")
+		isNil(err, t)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	for field, want := range map[string]string{
+		"Filename":            "FILENAME",
+		"MaxSize":             "MAX_SIZE",
+		"MaxAgeDuration":      "MAX_AGE_DURATION",
+		"InstanceID":          "INSTANCE_ID",
+		"CopyACL":             "COPY_ACL",
+		"ReopenCheckInterval": "REOPEN_CHECK_INTERVAL",
+	} {
+		equals(want, envName(field), t)
+	}
+}
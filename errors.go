@@ -0,0 +1,50 @@
+package lumberjack
+
+import "fmt"
+
+// errorsBuffer 是 Errors 通道的缓冲大小
+const errorsBuffer = 64
+
+// AsyncError 是后台操作（清理、压缩、定时轮转等）失败时通过 Errors 通道
+// 投递的错误
+type AsyncError struct {
+	Filename string    // 出错的 Logger 的日志文件
+	Op       Operation // 失败的操作
+	Err      error
+}
+
+func (e *AsyncError) Error() string {
+	return fmt.Sprintf("lumberjack: %s %s: %s", e.Op, e.Filename, e.Err)
+}
+
+func (e *AsyncError) Unwrap() error {
+	return e.Err
+}
+
+// Errors 返回接收后台异步错误的通道，错误的具体类型为 *AsyncError。这些错误
+// 发生在后台 goroutine 或轮转后的处理中，无法由 Write 返回。通道带有缓冲，
+// 已满时新错误会被丢弃（计入 Stats 的 DroppedErrors），不会阻塞后台处理。
+// 通道永远不会被关闭。
+func (l *Logger) Errors() <-chan error {
+	return l.errorsChan()
+}
+
+func (l *Logger) errorsChan() chan error {
+	l.errOnce.Do(func() {
+		l.errCh = make(chan error, errorsBuffer)
+	})
+	return l.errCh
+}
+
+// reportError 记录一次后台错误，并以非阻塞方式投递到 Errors 通道，
+// 配置了 EventLogSource 时同时写入事件日志
+func (l *Logger) reportError(op Operation, err error) {
+	l.metrics.millErrors.Add(1)
+	ae := &AsyncError{Filename: l.filename(), Op: op, Err: err}
+	l.logEvent(ae)
+	select {
+	case l.errorsChan() <- ae:
+	default:
+		l.metrics.droppedErrs.Add(1)
+	}
+}
@@ -0,0 +1,49 @@
+package lumberjack
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestErrorsChannel(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestErrorsChannel", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:    logFile(dir),
+		Compression: "lz4",
+	}
+	defer l.Close()
+	errs := l.Errors()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// 未知的压缩算法导致后台处理失败，Write 本身不受影响
+	select {
+	case err := <-errs:
+		var ae *AsyncError
+		assert(errors.As(err, &ae), t, "expected *AsyncError, got %T", err)
+		equals(OpCleanup, ae.Op, t)
+		equals(logFile(dir), ae.Filename, t)
+		notNil(ae.Unwrap(), t)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for async error")
+	}
+}
+
+func TestErrorsChannelNonBlocking(t *testing.T) {
+	l := &Logger{Filename: "foo.log"}
+
+	for i := 0; i < errorsBuffer+5; i++ {
+		l.reportError(OpCompress, errors.New("boom"))
+	}
+	equals(errorsBuffer, len(l.Errors()), t)
+	s := l.Stats()
+	equals(int64(errorsBuffer+5), s.BackgroundErrors, t)
+	equals(int64(5), s.DroppedErrors, t)
+}
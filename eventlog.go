@@ -0,0 +1,52 @@
+package lumberjack
+
+// eventID 是写入 Windows 事件日志的事件 ID
+const eventID = 1
+
+// eventSink 是系统事件日志，Windows 上为 *eventlog.Log
+type eventSink interface {
+	Error(eid uint32, msg string) error
+	Close() error
+}
+
+// openEventLog exists so it can be mocked out by tests.
+var openEventLog = openSystemEventLog
+
+// logEvent 在配置了 EventLogSource 时把后台错误写入系统事件日志。事件日志在
+// 首次写入时打开，打开失败后不再重试。
+func (l *Logger) logEvent(err error) {
+	if l.EventLogSource == "" {
+		return
+	}
+	l.eventMu.Lock()
+	defer l.eventMu.Unlock()
+
+	if !l.eventOpened {
+		l.eventOpened = true
+		sink, err := openEventLog(l.EventLogSource)
+		if err != nil {
+			l.logDebug("打开事件日志失败: %v，来源: %s", err, l.EventLogSource)
+			return
+		}
+		l.events = sink
+	}
+	if l.events == nil {
+		return
+	}
+	if err := l.events.Error(eventID, err.Error()); err != nil {
+		l.logDebug("写入事件日志失败: %v，来源: %s", err, l.EventLogSource)
+	}
+}
+
+// closeEventLog 关闭事件日志，之后的后台错误不再写入事件日志
+func (l *Logger) closeEventLog() error {
+	l.eventMu.Lock()
+	defer l.eventMu.Unlock()
+	l.eventOpened = true
+	if l.events == nil {
+		return nil
+	}
+	err := l.events.Close()
+	l.events = nil
+	return err
+}
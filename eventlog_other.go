@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package lumberjack
+
+// openSystemEventLog 在 Windows 以外的平台上不可用，EventLogSource 不生效
+func openSystemEventLog(source string) (eventSink, error) {
+	return nil, nil
+}
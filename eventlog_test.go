@@ -0,0 +1,106 @@
+package lumberjack
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeEventSink 记录写入的事件，代替系统事件日志
+type fakeEventSink struct {
+	mu     sync.Mutex
+	msgs   []string
+	closed bool
+}
+
+func (s *fakeEventSink) Error(eid uint32, msg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.msgs = append(s.msgs, msg)
+	return nil
+}
+
+func (s *fakeEventSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *fakeEventSink) messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.msgs...)
+}
+
+func TestEventLogSource(t *testing.T) {
+	sink := &fakeEventSink{}
+	opened := 0
+	var source string
+	openEventLog = func(s string) (eventSink, error) {
+		opened++
+		source = s
+		return sink, nil
+	}
+	defer func() { openEventLog = openSystemEventLog }()
+
+	dir := makeTempDir("TestEventLogSource", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:       logFile(dir),
+		EventLogSource: "myservice",
+	}
+	l.reportError(OpCompress, errors.New("boom"))
+	l.reportError(OpCleanup, errors.New("bang"))
+
+	equals(1, opened, t)
+	equals("myservice", source, t)
+	msgs := sink.messages()
+	equals(2, len(msgs), t)
+	assert(strings.Contains(msgs[0], "boom"), t, "expected event to contain error, got %q", msgs[0])
+	assert(strings.Contains(msgs[1], "bang"), t, "expected event to contain error, got %q", msgs[1])
+
+	// 错误仍然投递到 Errors 通道
+	equals(2, len(l.Errors()), t)
+
+	isNil(l.Close(), t)
+	assert(sink.closed, t, "expected event log to be closed")
+
+	// 关闭后不再写入事件日志
+	l.reportError(OpCompress, errors.New("late"))
+	equals(2, len(sink.messages()), t)
+	equals(1, opened, t)
+}
+
+func TestEventLogSourceUnset(t *testing.T) {
+	openEventLog = func(s string) (eventSink, error) {
+		t.Fatal("event log should not be opened without EventLogSource")
+		return nil, nil
+	}
+	defer func() { openEventLog = openSystemEventLog }()
+
+	l := &Logger{Filename: "foo.log"}
+	l.reportError(OpCompress, errors.New("boom"))
+	isNil(l.Close(), t)
+}
+
+func TestEventLogOpenError(t *testing.T) {
+	opened := 0
+	openEventLog = func(s string) (eventSink, error) {
+		opened++
+		return nil, errors.New("no such source")
+	}
+	defer func() { openEventLog = openSystemEventLog }()
+
+	l := &Logger{Filename: "foo.log", EventLogSource: "missing"}
+	l.reportError(OpCompress, errors.New("boom"))
+	l.reportError(OpCompress, errors.New("boom"))
+
+	// 打开失败后不再重试，错误仍然投递到 Errors 通道
+	equals(1, opened, t)
+	equals(2, len(l.Errors()), t)
+	isNil(l.Close(), t)
+}
@@ -0,0 +1,11 @@
+//go:build windows
+// +build windows
+
+package lumberjack
+
+import "golang.org/x/sys/windows/svc/eventlog"
+
+// openSystemEventLog 打开名为 source 的 Windows 事件日志来源
+func openSystemEventLog(source string) (eventSink, error) {
+	return eventlog.Open(source)
+}
@@ -0,0 +1,129 @@
+package lumberjack
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ExportOptions 控制 ExportTo 导出的内容
+type ExportOptions struct {
+	// Since 和 Until 按备份文件名中的时间戳选择要导出的备份，规则与
+	// SearchOptions 相同。零值表示不限制。
+	Since, Until time.Time
+
+	// SkipActive 为 true 时不导出当前日志文件
+	SkipActive bool
+
+	// Gzip 为 true 时输出 tar.gz，否则输出未压缩的 tar
+	Gzip bool
+}
+
+// ExportTo 把当前日志文件和选中的备份以 tar（或 tar.gz）流写入 w，适合管理
+// 后台的“下载诊断信息”接口。备份按原样导出，已压缩或加密的备份不会被解开；
+// 条目名为文件相对于日志文件所在目录的路径。当前文件只导出开始导出时已写入
+// 磁盘的部分。
+func (l *Logger) ExportTo(w io.Writer, opts ExportOptions) (err error) {
+	files, err := l.filesBetween(opts.Since, opts.Until, !opts.SkipActive)
+	if err != nil {
+		return err
+	}
+
+	if opts.Gzip {
+		gz := gzip.NewWriter(w)
+		defer func() {
+			if errClose := gz.Close(); err == nil {
+				err = errClose
+			}
+		}()
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	for _, name := range files {
+		if err := l.exportFile(tw, name); err != nil {
+			return fmt.Errorf("can't export %s: %s", name, err)
+		}
+	}
+	return tw.Close()
+}
+
+// exportFile 把文件 name 写入归档。文件在导出过程中可能继续增长，只写入
+// 打开时的长度。
+func (l *Logger) exportFile(tw *tar.Writer, name string) error {
+	f, err := l.fsys().OpenFile(name, os.O_RDONLY, 0)
+	if os.IsNotExist(err) {
+		// 导出过程中被清理
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr, err := tar.FileInfoHeader(fi, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = l.exportName(name)
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, io.LimitReader(f, fi.Size()))
+	return err
+}
+
+// exportName 返回文件在导出归档中的条目名：相对于日志文件所在目录的路径，
+// 不在该目录之下时为文件名
+func (l *Logger) exportName(name string) string {
+	rel, err := filepath.Rel(l.dir(), name)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.Base(name)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// filesBetween 返回与时间范围 [since, until] 有交集的备份，按写入时间从旧到
+// 新排列，active 为 true 时最后是当前日志文件。一个备份包含上一次轮转到它的
+// 时间戳之间写入的内容。
+func (l *Logger) filesBetween(since, until time.Time, active bool) ([]string, error) {
+	l.backupMu.Lock()
+	files, err := l.oldLogFiles()
+	l.backupMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// end 为文件内容的结束时间，start 为上一个备份的时间戳
+	overlaps := func(start, end time.Time) bool {
+		if !since.IsZero() && !end.IsZero() && end.Before(since) {
+			return false
+		}
+		if !until.IsZero() && !start.IsZero() && start.After(until) {
+			return false
+		}
+		return true
+	}
+	var paths []string
+	var start time.Time
+	for i := len(files) - 1; i >= 0; i-- {
+		if overlaps(start, files[i].timestamp) {
+			paths = append(paths, files[i].path())
+		}
+		start = files[i].timestamp
+	}
+	if active && overlaps(start, time.Time{}) {
+		if _, err := l.stat(l.activeName()); err == nil {
+			paths = append(paths, l.activeName())
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,64 @@
+package lumberjack
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// readTar 返回 tar 流中条目名到内容的映射，以及条目的顺序
+func readTar(r io.Reader, t testing.TB) (map[string]string, []string) {
+	contents := make(map[string]string)
+	var names []string
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return contents, names
+		}
+		isNil(err, t)
+		b, err := io.ReadAll(tr)
+		isNil(err, t)
+		contents[hdr.Name] = string(b)
+		names = append(names, hdr.Name)
+	}
+}
+
+func TestExportTo(t *testing.T) {
+	dir := makeTempDir("TestExportTo", t)
+	defer os.RemoveAll(dir)
+	isNil(os.Mkdir(filepath.Join(dir, "old"), 0755), t)
+
+	base := time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)
+	older := backupName(filepath.Join(dir, "old"), logFile(dir), base.Format(backupTimeFormat))
+	newer := backupName(filepath.Join(dir, "old"), logFile(dir), base.Add(time.Hour).Format(backupTimeFormat))
+	isNil(os.WriteFile(older, []byte("older"), 0644), t)
+	isNil(os.WriteFile(newer, []byte("newer"), 0644), t)
+	isNil(os.WriteFile(logFile(dir), []byte("active"), 0644), t)
+
+	l := &Logger{Filename: logFile(dir), BackupDir: "old"}
+	defer l.Close()
+
+	var buf bytes.Buffer
+	isNil(l.ExportTo(&buf, ExportOptions{Gzip: true}), t)
+	gz, err := gzip.NewReader(&buf)
+	isNil(err, t)
+	contents, names := readTar(gz, t)
+	equals([]string{
+		"old/" + filepath.Base(older),
+		"old/" + filepath.Base(newer),
+		filepath.Base(logFile(dir)),
+	}, names, t)
+	equals("newer", contents["old/"+filepath.Base(newer)], t)
+	equals("active", contents[filepath.Base(logFile(dir))], t)
+
+	buf.Reset()
+	isNil(l.ExportTo(&buf, ExportOptions{Since: base.Add(30 * time.Minute), SkipActive: true}), t)
+	_, names = readTar(&buf, t)
+	equals([]string{"old/" + filepath.Base(newer)}, names, t)
+}
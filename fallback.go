@@ -0,0 +1,48 @@
+package lumberjack
+
+import (
+	"fmt"
+	"time"
+)
+
+// fallbackRetryInterval 是写入 Fallback 期间重试日志文件的间隔
+const fallbackRetryInterval = time.Second
+
+// writeOrFallback 写入日志文件，失败时改写入 Fallback。处于备用状态时每隔
+// fallbackRetryInterval 重试一次日志文件，成功后切回。调用方必须持有 l.mu。
+func (l *Logger) writeOrFallback(p []byte) (n int, err error) {
+	now := l.now()
+	if l.fallbackActive && now.Before(l.fallbackRetry) {
+		return l.writeFallback(p)
+	}
+
+	n, err = l.writeFile(p)
+	if err == nil {
+		if l.fallbackActive {
+			l.fallbackActive = false
+			l.logDebug("日志文件恢复写入，停止使用备用输出: %s", l.filename())
+		}
+		return n, nil
+	}
+
+	if !l.fallbackActive {
+		l.fallbackActive = true
+		l.reportError(OpWrite, err)
+		l.logDebug("写入日志文件失败: %v，改用备用输出: %s", err, l.filename())
+	}
+	l.fallbackRetry = now.Add(fallbackRetryInterval)
+
+	// 已写入文件的部分不再重复写入
+	m, ferr := l.writeFallback(p[n:])
+	if ferr != nil {
+		return n + m, fmt.Errorf("%s; fallback: %s", err, ferr)
+	}
+	return len(p), nil
+}
+
+// writeFallback 把 p 写入 Fallback
+func (l *Logger) writeFallback(p []byte) (int, error) {
+	n, err := l.Fallback.Write(p)
+	l.metrics.fallbackWrites.Add(1)
+	return n, err
+}
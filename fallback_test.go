@@ -0,0 +1,62 @@
+package lumberjack
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestFallback(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestFallback", t)
+	defer os.RemoveAll(dir)
+
+	// 模拟磁盘已满
+	var free uint64
+	diskFree = func(string) (uint64, error) { return free, nil }
+	defer func() { diskFree = freeSpace }()
+
+	var fallback bytes.Buffer
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:    filename,
+		MinDiskFree: 1,
+		Fallback:    &fallback,
+	}
+	defer l.Close()
+	errs := l.Errors()
+
+	n, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	equals(4, n, t)
+	equals("boo!", fallback.String(), t)
+	notExist(filename, t)
+
+	select {
+	case err := <-errs:
+		var ae *AsyncError
+		assert(errors.As(err, &ae), t, "expected *AsyncError, got %T", err)
+		equals(OpWrite, ae.Op, t)
+	default:
+		t.Fatal("expected error when switching to fallback")
+	}
+
+	// 重试间隔内直接写入 Fallback
+	free = uint64(megabyte) * 10
+	_, err = l.Write([]byte("foo"))
+	isNil(err, t)
+	equals("boo!foo", fallback.String(), t)
+
+	// 恢复后切回日志文件
+	newFakeTime()
+	_, err = l.Write([]byte("bar"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("bar"), t)
+	equals("boo!foo", fallback.String(), t)
+
+	s := l.Stats()
+	equals(int64(2), s.FallbackWrites, t)
+	equals(int64(0), s.WriteErrors, t)
+}
@@ -0,0 +1,141 @@
+package lumberjack
+
+import (
+	"io"
+	"unsafe"
+)
+
+// readFromChunk 是 ReadFrom 不能直接拷贝到文件时每次写入的最大字节数
+const readFromChunk = 32 * 1024
+
+// 编译期检查 *Logger 满足 io.StringWriter 与 io.ReaderFrom
+var (
+	_ io.StringWriter = (*Logger)(nil)
+	_ io.ReaderFrom   = (*Logger)(nil)
+)
+
+// WriteString 与 Write 相同，但直接写入 s 的内容，不需要先转换为 []byte。
+// 轮转、统计与 Write 完全一致。
+func (l *Logger) WriteString(s string) (n int, err error) {
+	// io.Writer 约定实现不得修改或保留 p，因此可以安全地共享 s 的底层内存
+	return l.Write(unsafe.Slice(unsafe.StringData(s), len(s)))
+}
+
+// ReadFrom 实现 io.ReaderFrom，把 r 中的数据全部写入日志，适合一次写入崩溃
+// 堆栈这类较大的内容。r 没有实现 io.WriterTo 时 io.Copy(logger, r) 会自动
+// 使用它。与 Write 不同，超过 MaxSize 的内容会在文件写满时轮转并继续写入新
+// 文件，因此可能被拆分到多个文件中。未启用 AsyncQueue、BufferSize、Mirror、
+// Fallback、MaxLines、Transforms、脱敏、StreamCompress 与 DropOnDiskPressure
+// 时，数据由 (*os.File).ReadFrom 直接
+// 拷贝到文件（在支持的平台上避免经过用户态缓冲区），期间一直持有写锁，因此
+// r 应当是本地数据；否则按块写入。数据块不是完整的记录，不受 MaxLineBytes 与
+// AppendNewline 影响。
+func (l *Logger) ReadFrom(r io.Reader) (n int64, err error) {
+	if l.AsyncQueue > 0 || l.BufferSize > 0 || l.Mirror != nil || l.Fallback != nil || l.MaxLines > 0 || l.transforming() || l.StreamCompress || l.DropOnDiskPressure {
+		return l.readFromChunked(r)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	defer func() {
+		if err != nil {
+			l.metrics.writeErrors.Add(1)
+		}
+	}()
+	if l.closed {
+		return 0, errClosed
+	}
+	for {
+		m, more, err := l.spliceFrom(r)
+		n += m
+		if err != nil || !more {
+			return n, err
+		}
+	}
+}
+
+// spliceFrom 把 r 中的数据拷贝到当前文件，直到文件写满或 r 读完，more 表示
+// 文件已写满、r 中可能还有数据。调用方必须持有 l.mu。
+func (l *Logger) spliceFrom(r io.Reader) (n int64, more bool, err error) {
+	// 设置 DropOnDiskPressure 时 ReadFrom 按块写入，这里不会要求丢弃
+	if _, err := l.checkDiskFree(); err != nil {
+		return 0, false, err
+	}
+	unlock, err := l.lockShared()
+	if err != nil {
+		return 0, false, err
+	}
+	defer unlock()
+
+	if l.file == nil {
+		if err := l.openExistingOrNew(0); err != nil {
+			return 0, false, err
+		}
+	}
+	if err := l.checkMoved(); err != nil {
+		return 0, false, err
+	}
+
+	if l.size >= l.max() || l.rotationDue() {
+		// 先读取一个字节确认还有数据再轮转，避免 r 恰好读完时产生多余的轮转
+		var b [1]byte
+		k, rerr := io.ReadFull(r, b[:])
+		if k == 0 {
+			if rerr == io.EOF {
+				rerr = nil
+			}
+			return 0, false, rerr
+		}
+		if err := l.rotate(); err != nil {
+			return 0, false, err
+		}
+		k, err = l.file.Write(b[:])
+		n += int64(k)
+		l.size += int64(k)
+		if err != nil {
+			l.metrics.bytesWritten.Add(n)
+			return n, false, err
+		}
+	}
+
+	remaining := l.max() - l.size
+	m, err := io.Copy(l.file, io.LimitReader(r, remaining))
+	n += m
+	l.size += m
+	l.metrics.bytesWritten.Add(n)
+	if err == nil {
+		err = l.syncAfterWrite(n)
+	}
+	return n, err == nil && m == remaining, err
+}
+
+// readFromChunked 按块读取 r 并调用 Write，使缓冲、镜像、备用输出和行数统计
+// 等功能照常生效
+func (l *Logger) readFromChunked(r io.Reader) (n int64, err error) {
+	size := int64(readFromChunk)
+	if max := l.max(); max < size {
+		size = max
+	}
+	buf := make([]byte, size)
+	for {
+		k, rerr := r.Read(buf)
+		if k > 0 {
+			// 数据块不是完整的记录，只应用 Transforms 和脱敏
+			chunk, werr := l.applyTransforms(buf[:k])
+			if werr != nil {
+				return n, werr
+			}
+			w, werr := l.writeRaw(chunk)
+			n += int64(recordN(buf[:k], chunk, w, werr))
+			if werr != nil {
+				return n, werr
+			}
+		}
+		if rerr == io.EOF {
+			return n, nil
+		}
+		if rerr != nil {
+			return n, rerr
+		}
+	}
+}
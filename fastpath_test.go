@@ -0,0 +1,128 @@
+package lumberjack
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// readAllLogs 按文件名顺序读取 dir 中所有日志文件并拼接其内容
+func readAllLogs(dir string, t testing.TB) (contents string, files int) {
+	entries, err := os.ReadDir(dir)
+	isNilUp(err, t, 1)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	// 时间戳相同的备份依次追加 -1、-2 后缀，按长度和名称排序即为轮转顺序，
+	// 当前文件最后写入
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) < len(names[j])
+		}
+		return names[i] < names[j]
+	})
+	var b strings.Builder
+	for _, name := range names {
+		if name == "foobar.log" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		isNilUp(err, t, 1)
+		b.Write(data)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "foobar.log"))
+	isNilUp(err, t, 1)
+	b.Write(data)
+	return b.String(), len(names)
+}
+
+func TestWriteString(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	defer func() { megabyte = 1024 * 1024 }()
+
+	dir := makeTempDir("TestWriteString", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), MaxSize: 10}
+	defer l.Close()
+
+	n, err := l.WriteString("boo!")
+	isNil(err, t)
+	equals(4, n, t)
+	n, err = io.WriteString(l, "foooooo!")
+	isNil(err, t)
+	equals(8, n, t)
+
+	existsWithContent(logFile(dir), []byte("foooooo!"), t)
+	existsWithContent(backupFile(dir), []byte("boo!"), t)
+	equals(int64(12), l.Stats().BytesWritten, t)
+
+	_, err = l.WriteString("this is too long")
+	notNil(err, t)
+}
+
+func TestReadFrom(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	defer func() { megabyte = 1024 * 1024 }()
+
+	dir := makeTempDir("TestReadFrom", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), MaxSize: 10}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// 超过 MaxSize 的内容在文件写满时轮转并继续写入
+	payload := "0123456789abcdefghijklmnopqrstuvwxyz"
+	n, err := l.ReadFrom(strings.NewReader(payload))
+	isNil(err, t)
+	equals(int64(len(payload)), n, t)
+
+	contents, files := readAllLogs(dir, t)
+	equals("boo!"+payload, contents, t)
+	equals(4, files, t)
+	equals(int64(3), l.Stats().Rotations, t)
+	equals(int64(10), l.CurrentSize(), t)
+	equals(int64(4+len(payload)), l.Stats().BytesWritten, t)
+
+	// 文件恰好写满时不会因为空的 r 轮转
+	n, err = l.ReadFrom(strings.NewReader(""))
+	isNil(err, t)
+	equals(int64(0), n, t)
+	equals(int64(3), l.Stats().Rotations, t)
+
+	isNil(l.Close(), t)
+	_, err = l.ReadFrom(strings.NewReader("late"))
+	notNil(err, t)
+}
+
+func TestReadFromChunked(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	defer func() { megabyte = 1024 * 1024 }()
+
+	dir := makeTempDir("TestReadFromChunked", t)
+	defer os.RemoveAll(dir)
+
+	var mirror bytes.Buffer
+	l := &Logger{Filename: logFile(dir), MaxSize: 10, Mirror: &mirror}
+	defer l.Close()
+
+	payload := "0123456789abcdefghijklmnopqrstuvwxyz"
+	n, err := l.ReadFrom(strings.NewReader(payload))
+	isNil(err, t)
+	equals(int64(len(payload)), n, t)
+
+	contents, _ := readAllLogs(dir, t)
+	equals(payload, contents, t)
+	equals(payload, mirror.String(), t)
+}
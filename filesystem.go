@@ -0,0 +1,184 @@
+package lumberjack
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// File 是 Filesystem 打开的文件，*os.File 实现了该接口
+type File interface {
+	io.Reader
+	io.Writer
+	io.Closer
+	Stat() (os.FileInfo, error)
+	Sync() error
+	Truncate(size int64) error
+}
+
+// Filesystem 抽象 Logger 对日志文件和备份的文件操作，用于在测试中使用内存
+// 文件系统，或在生产环境中接入 FUSE、对象存储网关等后端。实现必须可以被
+// 并发调用，Rename 在目标已存在时应当覆盖目标。
+type Filesystem interface {
+	OpenFile(name string, flag int, perm os.FileMode) (File, error)
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	Stat(name string) (os.FileInfo, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	MkdirAll(path string, perm os.FileMode) error
+}
+
+// OSFilesystem 是默认的本地文件系统实现。在 Windows 上以允许删除和重命名的
+// 共享模式打开文件。
+type OSFilesystem struct{}
+
+// OpenFile 打开本地文件
+func (OSFilesystem) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	return openFileOnce(name, flag, perm)
+}
+
+// Rename 重命名本地文件
+func (OSFilesystem) Rename(oldpath, newpath string) error {
+	return renameFileOnce(oldpath, newpath)
+}
+
+// Remove 删除本地文件
+func (OSFilesystem) Remove(name string) error {
+	return os.Remove(name)
+}
+
+// Stat 返回本地文件的信息
+func (OSFilesystem) Stat(name string) (os.FileInfo, error) {
+	return osStat(name)
+}
+
+// ReadDir 列出本地目录
+func (OSFilesystem) ReadDir(name string) ([]os.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+// MkdirAll 创建本地目录
+func (OSFilesystem) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+// fsys 返回 Logger 使用的文件系统
+func (l *Logger) fsys() Filesystem {
+	if l.Filesystem == nil {
+		return OSFilesystem{}
+	}
+	return l.Filesystem
+}
+
+// customFS 判断是否配置了本地文件系统之外的 Filesystem
+func (l *Logger) customFS() bool {
+	switch l.Filesystem.(type) {
+	case nil, OSFilesystem, *OSFilesystem:
+		return false
+	}
+	return true
+}
+
+// checkFilesystem 检查与自定义 Filesystem 同时配置的选项。压缩、加密、打包、
+// 上传等后台处理以及锁文件、符号链接、属主和权限设置直接操作本地文件，磁盘
+// 空间检查按本地路径统计所在卷，都不能用于自定义的文件系统。
+func (l *Logger) checkFilesystem() error {
+	if !l.customFS() {
+		return nil
+	}
+	if l.compression() != "" || l.Encrypt || len(l.AgeRecipients) > 0 || l.BundleDaily || l.Checksum ||
+		l.TrashDir != "" || l.PartitionByDate || len(l.Processors) > 0 || l.Uploader != nil ||
+		l.UploadTarget != "" || len(l.PostRotateCommand) > 0 || l.SymlinkName != "" ||
+		l.MinDiskFree > 0 || l.DiskUsageWatermark > 0 || l.DropOnDiskPressure || l.InterProcessLock ||
+		l.NamedMutex || l.Owner != 0 || l.Group != 0 || l.CopyACL != "" {
+		return errors.New("Filesystem cannot be combined with compression, encryption, bundling, checksums, " +
+			"TrashDir, PartitionByDate, uploads, Processors, PostRotateCommand, SymlinkName, MinDiskFree, " +
+			"DiskUsageWatermark, DropOnDiskPressure, inter-process locking, Owner, Group or CopyACL")
+	}
+	return nil
+}
+
+// stat 通过 Filesystem 获取文件信息
+func (l *Logger) stat(name string) (os.FileInfo, error) {
+	return l.fsys().Stat(name)
+}
+
+// openLogFile 通过 Filesystem 打开日志文件，失败时按 RetryPolicy 重试
+func (l *Logger) openLogFile(name string, flag int, perm os.FileMode) (f File, err error) {
+	err = retryOp(l.retryPolicy(), RetryOpOpen, func() error {
+		f, err = l.fsys().OpenFile(name, flag, perm)
+		return err
+	})
+	return f, err
+}
+
+// readFile 通过 Filesystem 读取文件 name 的全部内容
+func (l *Logger) readFile(name string) ([]byte, error) {
+	f, err := l.fsys().OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// replaceFile 通过 Filesystem 先把 data 写入临时文件再重命名为 name，读取方
+// 不会看到写了一半的内容
+func (l *Logger) replaceFile(name string, data []byte, perm os.FileMode) error {
+	tmp := name + tmpSuffix
+	f, err := l.fsys().OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = l.fsys().Rename(tmp, name)
+	}
+	if err != nil {
+		l.fsys().Remove(tmp)
+	}
+	return err
+}
+
+// globDirs 通过 Filesystem 逐级列出 dir 下名称依次匹配 patterns 的子目录，
+// 模式语法与 filepath.Match 相同。与 filepath.Glob 一样忽略无法读取的目录。
+func (l *Logger) globDirs(dir string, patterns ...string) ([]string, error) {
+	dirs := []string{dir}
+	for _, pattern := range patterns {
+		var next []string
+		for _, d := range dirs {
+			entries, err := l.fsys().ReadDir(d)
+			if err != nil {
+				continue
+			}
+			for _, e := range entries {
+				if !e.IsDir() {
+					continue
+				}
+				ok, err := filepath.Match(pattern, e.Name())
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					next = append(next, filepath.Join(d, e.Name()))
+				}
+			}
+		}
+		dirs = next
+	}
+	return dirs, nil
+}
+
+// removeFile 删除备份 name 及其校验和旁路文件，失败时按 RetryPolicy 重试
+func (l *Logger) removeFile(name string) error {
+	if !l.customFS() {
+		return removeBackup(l.retryPolicy(), name)
+	}
+	return retryOp(l.retryPolicy(), RetryOpRemove, func() error {
+		return l.fsys().Remove(name)
+	})
+}
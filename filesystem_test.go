@@ -0,0 +1,340 @@
+package lumberjack
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memFS 是测试用的内存文件系统，只支持单层目录
+type memFS struct {
+	mu    sync.Mutex
+	files map[string]*memData
+}
+
+type memData struct {
+	data    []byte
+	modTime time.Time
+}
+
+func newMemFS() *memFS {
+	return &memFS{files: make(map[string]*memData)}
+}
+
+func (m *memFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.files[name]
+	if !ok {
+		if flag&os.O_CREATE == 0 {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		d = &memData{modTime: currentTime()}
+		m.files[name] = d
+	} else if flag&os.O_EXCL != 0 {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	}
+	if flag&os.O_TRUNC != 0 {
+		d.data = nil
+	}
+	return &memFile{fs: m, name: name, d: d, append: flag&os.O_APPEND != 0}, nil
+}
+
+func (m *memFS) Rename(oldpath, newpath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.files[oldpath]
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrNotExist}
+	}
+	delete(m.files, oldpath)
+	m.files[newpath] = d
+	return nil
+}
+
+func (m *memFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.files, name)
+	return nil
+}
+
+func (m *memFS) Stat(name string) (os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return memInfo{name: filepath.Base(name), size: int64(len(d.data)), modTime: d.modTime}, nil
+}
+
+func (m *memFS) ReadDir(name string) ([]os.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var entries []os.DirEntry
+	for path, d := range m.files {
+		if filepath.Dir(path) == name {
+			info := memInfo{name: filepath.Base(path), size: int64(len(d.data)), modTime: d.modTime}
+			entries = append(entries, fs.FileInfoToDirEntry(info))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+func (m *memFS) MkdirAll(path string, perm os.FileMode) error {
+	return nil
+}
+
+// names 返回全部文件的名称，已排序
+func (m *memFS) names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var names []string
+	for path := range m.files {
+		names = append(names, filepath.Base(path))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *memFS) content(name string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if d, ok := m.files[name]; ok {
+		return string(d.data)
+	}
+	return ""
+}
+
+type memFile struct {
+	fs     *memFS
+	name   string
+	d      *memData
+	off    int
+	append bool
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.off >= len(f.d.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.d.data[f.off:])
+	f.off += n
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.append {
+		f.off = len(f.d.data)
+	}
+	if end := f.off + len(p); end > len(f.d.data) {
+		f.d.data = append(f.d.data, make([]byte, end-len(f.d.data))...)
+	}
+	copy(f.d.data[f.off:], p)
+	f.off += len(p)
+	f.d.modTime = currentTime()
+	return len(p), nil
+}
+
+func (f *memFile) Close() error { return nil }
+func (f *memFile) Sync() error  { return nil }
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	return memInfo{name: filepath.Base(f.name), size: int64(len(f.d.data)), modTime: f.d.modTime}, nil
+}
+
+func (f *memFile) Truncate(size int64) error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	f.d.data = bytes.Clone(f.d.data[:size])
+	return nil
+}
+
+type memInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) Mode() fs.FileMode  { return 0644 }
+func (i memInfo) ModTime() time.Time { return i.modTime }
+func (i memInfo) IsDir() bool        { return false }
+func (i memInfo) Sys() any           { return nil }
+
+func TestFilesystem(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	defer func() { megabyte = 1024 * 1024 }()
+
+	dir := makeTempDir("TestFilesystem", t)
+	defer os.RemoveAll(dir)
+
+	mem := newMemFS()
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxSize:    10,
+		MaxBackups: 1,
+		Filesystem: mem,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(err, t)
+	equals("boo!\n", mem.content(logFile(dir)), t)
+
+	// 超出 MaxSize 时在内存文件系统中轮转
+	newFakeTime()
+	first := backupFile(dir)
+	_, err = l.Write([]byte("foooooo!\n"))
+	isNil(err, t)
+	equals("boo!\n", mem.content(first), t)
+	equals("foooooo!\n", mem.content(logFile(dir)), t)
+
+	// 按 MaxBackups 删除旧备份
+	newFakeTime()
+	second := backupFile(dir)
+	isNil(l.Rotate(), t)
+	_, err = l.Write([]byte("bar\n"))
+	isNil(err, t)
+	<-time.After(10 * time.Millisecond)
+	equals([]string{filepath.Base(second), filepath.Base(logFile(dir))}, mem.names(), t)
+	equals("foooooo!\n", mem.content(second), t)
+
+	// 本地目录中没有任何文件
+	fileCount(dir, 0, t)
+}
+
+func TestFilesystemAppendsExisting(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestFilesystemAppendsExisting", t)
+	defer os.RemoveAll(dir)
+
+	mem := newMemFS()
+	f, err := mem.OpenFile(logFile(dir), os.O_CREATE|os.O_WRONLY, 0644)
+	isNil(err, t)
+	_, err = f.Write([]byte("foo\n"))
+	isNil(err, t)
+
+	l := &Logger{Filename: logFile(dir), MaxLines: 10, Filesystem: mem}
+	defer l.Close()
+	_, err = l.Write([]byte("bar\n"))
+	isNil(err, t)
+	equals("foo\nbar\n", mem.content(logFile(dir)), t)
+	equals(int64(2), l.lines, t)
+}
+
+func TestFilesystemIncompatibleOptions(t *testing.T) {
+	l := &Logger{Filename: "foo.log", Filesystem: newMemFS(), Compress: true}
+	err := l.Validate()
+	notNil(err, t)
+	assert(strings.Contains(err.Error(), "Filesystem"), t, "unexpected error: %v", err)
+
+	_, err = l.Write([]byte("foo\n"))
+	notNil(err, t)
+
+	// 显式指定本地文件系统时不受限制
+	l = &Logger{Filename: "foo.log", Filesystem: OSFilesystem{}, Compress: true}
+	isNil(l.Validate(), t)
+}
+
+func TestFilesystemFrozenClock(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestFilesystemFrozenClock", t)
+	defer os.RemoveAll(dir)
+
+	mem := newMemFS()
+	l := &Logger{Filename: logFile(dir), Filesystem: mem}
+	defer l.Close()
+
+	// 时间不前进时同名检查同样通过 Filesystem 进行，备份不会互相覆盖
+	for _, s := range []string{"a\n", "b\n", "c\n"} {
+		_, err := l.Write([]byte(s))
+		isNil(err, t)
+		isNil(l.Rotate(), t)
+	}
+	first := backupFile(dir)
+	stem := first[:len(first)-len(".log")]
+	equals("a\n", mem.content(first), t)
+	equals("b\n", mem.content(stem+"-1.log"), t)
+	equals("c\n", mem.content(stem+"-2.log"), t)
+	equals(4, len(mem.names()), t)
+	fileCount(dir, 0, t)
+}
+
+func TestFilesystemHoldAndManifest(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestFilesystemHoldAndManifest", t)
+	defer os.RemoveAll(dir)
+
+	mem := newMemFS()
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxBackups: 1,
+		Manifest:   "manifest.json",
+		Filesystem: mem,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	first := backupFile(dir)
+	isNil(l.Hold(first), t)
+	equals("", mem.content(first+holdSuffix), t)
+
+	// 被保留的备份不计入 MaxBackups
+	newFakeTime()
+	_, err = l.Write([]byte("foo\n"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	isNil(l.WaitIdle(context.Background()), t)
+	equals("boo!\n", mem.content(first), t)
+	equals("foo\n", mem.content(backupFile(dir)), t)
+
+	// 清单写入内存文件系统
+	m, err := parseManifest("manifest.json", []byte(mem.content(filepath.Join(dir, "manifest.json"))))
+	isNil(err, t)
+	equals(2, len(m.Backups), t)
+	assert(m.Backups[1].Held, t, "expected held backup in manifest: %+v", m.Backups)
+
+	isNil(l.Release(first), t)
+	_, err = mem.Stat(first + holdSuffix)
+	assert(os.IsNotExist(err), t, "expected hold marker removed, got %v", err)
+	fileCount(dir, 0, t)
+}
+
+func TestFilesystemRejectsDiskWatermark(t *testing.T) {
+	for _, l := range []*Logger{
+		{Filename: "foo.log", Filesystem: newMemFS(), DiskUsageWatermark: 90},
+		{Filename: "foo.log", Filesystem: newMemFS(), DropOnDiskPressure: true},
+		{Filename: "foo.log", Filesystem: newMemFS(), MinDiskFree: 100},
+	} {
+		err := l.Validate()
+		notNil(err, t)
+		assert(strings.Contains(err.Error(), "Filesystem"), t, "unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package lumberjack
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// followPollInterval 是 Follow 在读到文件末尾后检查新数据和轮转的间隔，
+// 可以在测试中修改
+var followPollInterval = 100 * time.Millisecond
+
+// follower 是 Follow 返回的 reader
+type follower struct {
+	l   *Logger // 每次检查轮转时从中取得当前文件的路径
+	ctx context.Context
+
+	mu     sync.Mutex
+	f      *os.File
+	closed bool
+	done   chan struct{}
+}
+
+// Follow 返回持续读取当前日志文件的 reader，效果类似 tail -F：从调用时的
+// 文件末尾开始，读到末尾后等待新的写入；文件被轮转（重命名或删除后重新
+// 创建）或 Logger 换到新路径（SetFilename、HourlyDirs）时读完旧文件的剩余
+// 内容，再从头读取新文件；文件被截断时从头读取。
+// 只能读到已写入磁盘的数据，设置 BufferSize 时缓冲区中的内容在刷出后才能
+// 读到。在 Windows 上以允许删除的共享模式打开文件，不妨碍轮转时的重命名。
+// ctx 结束后 Read 返回 io.EOF。使用完毕后必须调用 Close。
+func (l *Logger) Follow(ctx context.Context) io.ReadCloser {
+	fl := &follower{l: l, ctx: ctx, done: make(chan struct{})}
+	if f, err := openFileOnce(l.activeName(), os.O_RDONLY, 0); err == nil {
+		f.Seek(0, io.SeekEnd)
+		fl.f = f
+	}
+	return fl
+}
+
+// Read 读取新写入的数据，没有新数据时阻塞
+func (fl *follower) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for {
+		n, err := fl.readOnce(p)
+		if n > 0 || err != nil {
+			return n, err
+		}
+		select {
+		case <-fl.ctx.Done():
+			return 0, io.EOF
+		case <-fl.done:
+			return 0, os.ErrClosed
+		case <-time.After(followPollInterval):
+		}
+	}
+}
+
+// readOnce 从当前文件读取，读到末尾时检查轮转和截断。没有新数据时返回 0 和
+// nil 错误。
+func (fl *follower) readOnce(p []byte) (int, error) {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+	if fl.closed {
+		return 0, os.ErrClosed
+	}
+	if fl.f == nil {
+		// 文件尚未创建，或轮转时旧文件已移走而新文件还没出现
+		f, err := openFileOnce(fl.l.activeName(), os.O_RDONLY, 0)
+		if err != nil {
+			return 0, nil
+		}
+		fl.f = f
+	}
+
+	n, err := fl.f.Read(p)
+	if n > 0 {
+		return n, nil
+	}
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+
+	cur, err := fl.f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	// 每次都重新取得当前文件的路径，SetFilename 或 HourlyDirs 换到新路径时
+	// 同样视为轮转
+	info, err := os.Stat(fl.l.activeName())
+	if err != nil || !os.SameFile(cur, info) {
+		// 已轮转：旧文件的剩余内容已读完，切换到新文件
+		fl.f.Close()
+		fl.f = nil
+		return 0, nil
+	}
+	if off, err := fl.f.Seek(0, io.SeekCurrent); err == nil && info.Size() < off {
+		// 被截断
+		fl.f.Seek(0, io.SeekStart)
+	}
+	return 0, nil
+}
+
+// Close 关闭 reader，正在阻塞的 Read 返回 os.ErrClosed
+func (fl *follower) Close() error {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+	if fl.closed {
+		return errors.New("follower already closed")
+	}
+	fl.closed = true
+	close(fl.done)
+	if fl.f != nil {
+		return fl.f.Close()
+	}
+	return nil
+}
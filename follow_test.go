@@ -0,0 +1,113 @@
+package lumberjack
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// readFollow 从 r 读取，直到得到 n 字节或超时
+func readFollow(r io.Reader, n int, t testing.TB) string {
+	t.Helper()
+	buf := make([]byte, n)
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.ReadFull(r, buf)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		isNil(err, t)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %d bytes", n)
+	}
+	return string(buf)
+}
+
+func TestFollow(t *testing.T) {
+	currentTime = fakeTime
+	defer func(d time.Duration) { followPollInterval = d }(followPollInterval)
+	followPollInterval = time.Millisecond
+
+	dir := makeTempDir("TestFollow", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir)}
+	defer l.Close()
+	_, err := l.Write([]byte("old\n"))
+	isNil(err, t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := l.Follow(ctx)
+	defer r.Close()
+
+	// 从调用时的末尾开始
+	_, err = l.Write([]byte("boo\n"))
+	isNil(err, t)
+	equals("boo\n", readFollow(r, 4, t), t)
+
+	// 跨过轮转继续读取新文件
+	_, err = l.Write([]byte("foo\n"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	_, err = l.Write([]byte("bar\n"))
+	isNil(err, t)
+	equals("foo\nbar\n", readFollow(r, 8, t), t)
+
+	cancel()
+	_, err = r.Read(make([]byte, 1))
+	equals(io.EOF, err, t)
+}
+
+func TestFollowSetFilename(t *testing.T) {
+	currentTime = fakeTime
+	defer func(d time.Duration) { followPollInterval = d }(followPollInterval)
+	followPollInterval = time.Millisecond
+
+	dir := makeTempDir("TestFollowSetFilename", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(filepath.Join(dir, "a"))}
+	defer l.Close()
+	r := l.Follow(context.Background())
+	defer r.Close()
+
+	_, err := l.Write([]byte("boo\n"))
+	isNil(err, t)
+	equals("boo\n", readFollow(r, 4, t), t)
+
+	// 换到新路径后继续读取新文件
+	isNil(l.SetFilename(logFile(filepath.Join(dir, "b"))), t)
+	_, err = l.Write([]byte("foo\n"))
+	isNil(err, t)
+	equals("foo\n", readFollow(r, 4, t), t)
+}
+
+func TestFollowClose(t *testing.T) {
+	dir := makeTempDir("TestFollowClose", t)
+	defer os.RemoveAll(dir)
+
+	// 文件尚不存在时也可以开始跟踪
+	l := &Logger{Filename: logFile(dir)}
+	defer l.Close()
+	r := l.Follow(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := r.Read(make([]byte, 1))
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	isNil(r.Close(), t)
+	select {
+	case err := <-done:
+		equals(os.ErrClosed, err, t)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Read did not return after Close")
+	}
+	notNil(r.Close(), t)
+}
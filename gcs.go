@@ -0,0 +1,108 @@
+package lumberjack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gcsMetadataTokenURL 是 GCE/GKE 元数据服务器上默认服务账号的访问令牌地址
+const gcsMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// GCSUploader 通过 JSON API 的简单上传把备份写入 Google Cloud Storage，
+// 对象名为 Prefix 与备份文件名拼接的结果。不依赖 Cloud SDK。
+type GCSUploader struct {
+	// Bucket 是目标存储桶
+	Bucket string
+
+	// Prefix 是对象名前缀，例如 "logs/app"
+	Prefix string
+
+	// TokenSource 返回 OAuth2 访问令牌。为 nil 时依次使用环境变量
+	// GOOGLE_OAUTH_ACCESS_TOKEN 和元数据服务器上默认服务账号的令牌，
+	// 后者适用于运行在 GCE、GKE、Cloud Run 上的程序。
+	TokenSource func(ctx context.Context) (string, error)
+
+	// Endpoint 是服务地址，默认为 https://storage.googleapis.com，
+	// 可指向模拟器或私有接入点
+	Endpoint string
+
+	// Client 是发送请求使用的 HTTP 客户端，默认为 http.DefaultClient
+	Client *http.Client
+
+	// 元数据服务器令牌的缓存
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// Upload 上传本地文件 path
+func (g *GCSUploader) Upload(ctx context.Context, path string) error {
+	token, err := g.accessToken(ctx)
+	if err != nil {
+		return fmt.Errorf("can't get GCS access token: %s", err)
+	}
+	endpoint := g.Endpoint
+	if endpoint == "" {
+		endpoint = "https://storage.googleapis.com"
+	}
+	q := url.Values{
+		"uploadType": {"media"},
+		"name":       {objectName(g.Prefix, path)},
+	}
+	u := strings.TrimSuffix(endpoint, "/") + "/upload/storage/v1/b/" + url.PathEscape(g.Bucket) + "/o?" + q.Encode()
+	return putFile(ctx, httpClient(g.Client), http.MethodPost, u, path, map[string]string{
+		"Authorization": "Bearer " + token,
+		"Content-Type":  "application/octet-stream",
+	})
+}
+
+// accessToken 返回上传使用的访问令牌
+func (g *GCSUploader) accessToken(ctx context.Context) (string, error) {
+	if g.TokenSource != nil {
+		return g.TokenSource(ctx)
+	}
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.token != "" && time.Now().Before(g.expiry) {
+		return g.token, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcsMetadataTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := httpClient(g.Client).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", responseError(resp)
+	}
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.AccessToken == "" {
+		return "", errors.New("metadata server returned an empty token")
+	}
+	// 提前一分钟过期，避免上传过程中令牌失效
+	g.token = body.AccessToken
+	g.expiry = time.Now().Add(time.Duration(body.ExpiresIn)*time.Second - time.Minute)
+	return g.token, nil
+}
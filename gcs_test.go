@@ -0,0 +1,61 @@
+package lumberjack
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGCSUploader(t *testing.T) {
+	dir := makeTempDir("TestGCSUploader", t)
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "app-2001.log.gz")
+	isNil(os.WriteFile(name, []byte("boo!"), 0600), t)
+
+	var gotPath, gotName, gotAuth string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotName = r.URL.Query().Get("name")
+		gotAuth = r.Header.Get("Authorization")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+
+	g := &GCSUploader{
+		Bucket:   "bucket",
+		Prefix:   "logs",
+		Endpoint: srv.URL,
+		TokenSource: func(ctx context.Context) (string, error) {
+			return "secret", nil
+		},
+	}
+	isNil(g.Upload(context.Background(), name), t)
+	equals("/upload/storage/v1/b/bucket/o", gotPath, t)
+	equals("logs/app-2001.log.gz", gotName, t)
+	equals("Bearer secret", gotAuth, t)
+	equals("boo!", string(gotBody), t)
+}
+
+func TestGCSUploaderError(t *testing.T) {
+	dir := makeTempDir("TestGCSUploaderError", t)
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "app.log")
+	isNil(os.WriteFile(name, []byte("boo!"), 0600), t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "access denied", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "secret")
+	g := &GCSUploader{Bucket: "bucket", Endpoint: srv.URL}
+	err := g.Upload(context.Background(), name)
+	notNil(err, t)
+	equals("403 Forbidden: access denied", err.Error(), t)
+}
@@ -0,0 +1,38 @@
+package lumberjack
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// header 返回新建日志文件的文件头，未配置时返回 nil
+func (l *Logger) header() []byte {
+	if l.Header != nil {
+		return l.Header()
+	}
+	return l.HeaderBytes
+}
+
+// writeHeader 把文件头直接写入刚创建的 l.file（StreamCompress 时写入压缩流），
+// 不经过写入缓冲区。调用方必须持有 l.mu。
+func (l *Logger) writeHeader() error {
+	h := l.header()
+	if len(h) == 0 {
+		return nil
+	}
+	var w io.Writer = l.file
+	if l.gz != nil {
+		w = l.gz
+		l.gzDirty = true
+	}
+	n, err := w.Write(h)
+	l.size += int64(n)
+	if l.MaxLines > 0 {
+		l.lines += int64(bytes.Count(h[:n], []byte{'\n'}))
+	}
+	if err != nil {
+		return fmt.Errorf("can't write header to new logfile: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestHeader(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestHeader", t)
+	defer os.RemoveAll(dir)
+
+	n := 0
+	l := &Logger{
+		Filename: logFile(dir),
+		Header: func() []byte {
+			n++
+			return []byte(fmt.Sprintf("# file %d\n", n))
+		},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(err, t)
+	existsWithContent(logFile(dir), []byte("# file 1\nboo!\n"), t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	_, err = l.Write([]byte("foo\n"))
+	isNil(err, t)
+	existsWithContent(backupFile(dir), []byte("# file 1\nboo!\n"), t)
+	existsWithContent(logFile(dir), []byte("# file 2\nfoo\n"), t)
+
+	// 继续追加已存在的日志文件时不写入文件头
+	isNil(l.Close(), t)
+	l2 := &Logger{Filename: logFile(dir), HeaderBytes: []byte("# static\n")}
+	defer l2.Close()
+	_, err = l2.Write([]byte("bar\n"))
+	isNil(err, t)
+	existsWithContent(logFile(dir), []byte("# file 2\nfoo\nbar\n"), t)
+}
+
+func TestHeaderCountsTowardMaxSize(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	defer func() { megabyte = 1024 * 1024 }()
+
+	dir := makeTempDir("TestHeaderCountsTowardMaxSize", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), MaxSize: 10, HeaderBytes: []byte("#v1\n")}
+	defer l.Close()
+
+	_, err := l.Write([]byte("12345"))
+	isNil(err, t)
+	fileCount(dir, 1, t)
+
+	// 文件头 4 字节加已写入的 5 字节，再写 2 字节超过 MaxSize
+	newFakeTime()
+	_, err = l.Write([]byte("67"))
+	isNil(err, t)
+	fileCount(dir, 2, t)
+	existsWithContent(backupFile(dir), []byte("#v1\n12345"), t)
+	existsWithContent(logFile(dir), []byte("#v1\n67"), t)
+}
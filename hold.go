@@ -0,0 +1,61 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+)
+
+// holdSuffix 是保留标记文件的后缀。备份 name 旁存在 name.hold 时，该备份
+// 不会被清理、压缩、加密或打包。
+const holdSuffix = ".hold"
+
+// Hold 为备份 path 创建 path.hold 标记，使其不受 MaxBackups、MaxAge 等保留
+// 策略影响，也不会再被压缩、加密或打包，直到 Release。适用于事故调查或
+// 法律保全期间需要原样保留的备份。标记是普通文件，进程重启后仍然有效，
+// 也可以由运维人员直接创建或删除。被保留的备份不计入 MaxBackups 和
+// MaxTotalSize。
+func (l *Logger) Hold(path string) error {
+	if _, err := l.stat(path); err != nil {
+		return fmt.Errorf("can't hold backup: %s", err)
+	}
+	f, err := l.fsys().OpenFile(path+holdSuffix, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("can't create hold marker: %s", err)
+	}
+	return f.Close()
+}
+
+// Release 删除备份 path 的保留标记，之后的清理重新按保留策略处理该备份。
+// 备份没有被保留时什么也不做。
+func (l *Logger) Release(path string) error {
+	if err := l.fsys().Remove(path + holdSuffix); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("can't remove hold marker: %s", err)
+	}
+	return nil
+}
+
+// isHeld 判断备份 path 是否被保留
+func (l *Logger) isHeld(path string) bool {
+	_, err := l.stat(path + holdSuffix)
+	return err == nil
+}
+
+// moveHold 在备份从 oldname 重命名为 newname 后移动其保留标记，使保留跟随
+// 备份本身，而不是留在原来的文件名上
+func (l *Logger) moveHold(oldname, newname string) error {
+	if !l.isHeld(oldname) {
+		return nil
+	}
+	return l.rename(oldname+holdSuffix, newname+holdSuffix)
+}
+
+// withoutHeld 从 files 中去掉被保留的备份
+func (l *Logger) withoutHeld(files []logInfo) []logInfo {
+	var remaining []logInfo
+	for _, f := range files {
+		if !l.isHeld(f.path()) {
+			remaining = append(remaining, f)
+		}
+	}
+	return remaining
+}
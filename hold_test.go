@@ -0,0 +1,81 @@
+package lumberjack
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestHold(t *testing.T) {
+	dir := makeTempDir("TestHold", t)
+	defer os.RemoveAll(dir)
+
+	at := func(day int) time.Time {
+		return time.Date(2024, 3, day, 12, 0, 0, 0, time.UTC)
+	}
+	names := makeBackups(dir, t, at(20), at(19), at(18), at(17))
+	held := filepath.Join(dir, names[2])
+
+	l := &Logger{Filename: logFile(dir), MaxBackups: 1, Compress: true}
+	defer l.Close()
+	isNil(l.Hold(held), t)
+	notNil(l.Hold(filepath.Join(dir, "missing.log")), t)
+
+	backups, err := l.Backups()
+	isNil(err, t)
+	for _, b := range backups {
+		equals(b.Path == held, b.Held, t)
+	}
+
+	// 被保留的备份不计入 MaxBackups，也不会被压缩
+	removed, err := l.Purge()
+	isNil(err, t)
+	equals(2, len(removed), t)
+	want := []string{names[0] + compressSuffix, names[2], names[2] + holdSuffix}
+	sort.Strings(want)
+	equals(want, remainingBackups(dir, t), t)
+
+	// 解除保留后按保留策略清理
+	isNil(l.Release(held), t)
+	isNil(l.Release(held), t)
+	removed, err = l.Purge()
+	isNil(err, t)
+	equals([]string{held}, removed, t)
+	equals([]string{names[0] + compressSuffix}, remainingBackups(dir, t), t)
+}
+
+func TestHoldNumberedBackups(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestHoldNumberedBackups", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, NumberedBackups: true, MaxBackups: 1}
+	defer l.Close()
+
+	rotate := func(s string) {
+		_, err := l.Write([]byte(s))
+		isNil(err, t)
+		isNil(l.Rotate(), t)
+		isNil(l.WaitIdle(context.Background()), t)
+	}
+	rotate("a")
+	isNil(l.Hold(filename+".1"), t)
+
+	// 序号后移时保留标记跟随备份
+	rotate("b")
+	existsWithContent(filename+".2", []byte("a"), t)
+	exists(filename+".2"+holdSuffix, t)
+	notExist(filename+".1"+holdSuffix, t)
+
+	rotate("c")
+	existsWithContent(filename+".3", []byte("a"), t)
+	exists(filename+".3"+holdSuffix, t)
+	existsWithContent(filename+".1", []byte("c"), t)
+	// 未被保留的 "b" 按 MaxBackups 被清理
+	notExist(filename+".2", t)
+	notExist(filename+".2"+holdSuffix, t)
+}
@@ -0,0 +1,60 @@
+package lumberjack
+
+import (
+	"path/filepath"
+	"time"
+)
+
+// hourlyLayout 是 HourlyDirs 模式下按小时划分的子目录的时间格式
+const hourlyLayout = "2006-01-02/15"
+
+// hourDirFor 返回时间 t 所属小时的子目录（相对于 Filename 所在目录）
+func (l *Logger) hourDirFor(t time.Time) string {
+	return filepath.FromSlash(t.In(l.location()).Format(hourlyLayout))
+}
+
+// hourDirName 返回当前日志文件所在的小时子目录。尚未打开文件时按当前时间计算。
+func (l *Logger) hourDirName() string {
+	if dir, _ := l.hourDir.Load().(string); dir != "" {
+		return dir
+	}
+	return l.hourDirFor(l.now())
+}
+
+// hourlyFilename 把 name 放入当前小时的子目录中
+func (l *Logger) hourlyFilename(name string) string {
+	return filepath.Join(filepath.Dir(name), l.hourDirName(), filepath.Base(name))
+}
+
+// pinHourDir 把日志文件固定在当前小时的子目录中，直到下一次 switchHour。
+// 调用方必须持有 l.mu。
+func (l *Logger) pinHourDir() {
+	if l.HourlyDirs {
+		l.hourDir.Store(l.hourDirFor(l.now()))
+	}
+}
+
+// hourChanged 判断当前日志文件所在的小时是否已经过去。调用方必须持有 l.mu。
+func (l *Logger) hourChanged() bool {
+	if !l.HourlyDirs {
+		return false
+	}
+	dir, _ := l.hourDir.Load().(string)
+	return dir != "" && dir != l.hourDirFor(l.now())
+}
+
+// nextHour 返回 from 之后的下一个整点，按 location 决定的时区计算
+func (l *Logger) nextHour(from time.Time) time.Time {
+	t := from.In(l.location())
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+}
+
+// switchHour 关闭上一个小时的日志文件，原样保留在其子目录中而不产生备份，
+// 然后在当前小时的子目录中打开日志文件。调用方必须持有 l.mu。
+func (l *Logger) switchHour() error {
+	if err := l.close(); err != nil {
+		return err
+	}
+	l.lastBackup = ""
+	return l.openExistingOrNew(0)
+}
@@ -0,0 +1,63 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHourlyDirs(t *testing.T) {
+	dir := makeTempDir("TestHourlyDirs", t)
+	defer os.RemoveAll(dir)
+
+	clock := &manualClock{now: time.Date(2024, 6, 1, 13, 59, 0, 0, time.UTC)}
+	l := &Logger{
+		Filename:   filepath.Join(dir, "app.log"),
+		HourlyDirs: true,
+		Clock:      clock,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("foo\n"))
+	isNil(err, t)
+	first := filepath.Join(dir, "2024-06-01", "13", "app.log")
+	existsWithContent(first, []byte("foo\n"), t)
+	equals(first, l.CurrentFile(), t)
+
+	// 跨过整点后写入新的小时目录，上一个小时的文件原样保留
+	clock.Advance(2 * time.Minute)
+	_, err = l.Write([]byte("bar\n"))
+	isNil(err, t)
+	second := filepath.Join(dir, "2024-06-01", "14", "app.log")
+	existsWithContent(second, []byte("bar\n"), t)
+	existsWithContent(first, []byte("foo\n"), t)
+	fileCount(filepath.Join(dir, "2024-06-01", "13"), 1, t)
+	equals(int64(0), l.Stats().Rotations, t)
+}
+
+func TestHourlyDirsSizeRotation(t *testing.T) {
+	megabyte = 1
+
+	dir := makeTempDir("TestHourlyDirsSizeRotation", t)
+	defer os.RemoveAll(dir)
+
+	clock := &manualClock{now: time.Date(2024, 6, 1, 13, 0, 0, 0, time.UTC)}
+	l := &Logger{
+		Filename:   filepath.Join(dir, "app.log"),
+		HourlyDirs: true,
+		MaxSize:    5,
+		Clock:      clock,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("foo\n"))
+	isNil(err, t)
+	_, err = l.Write([]byte("bar\n"))
+	isNil(err, t)
+
+	// 一个小时内按大小轮转的备份留在该小时的目录中
+	hour := filepath.Join(dir, "2024-06-01", "13")
+	existsWithContent(filepath.Join(hour, "app.log"), []byte("bar\n"), t)
+	existsWithContent(filepath.Join(hour, "app-2024-06-01T13-00-00.000.log"), []byte("foo\n"), t)
+}
@@ -0,0 +1,120 @@
+package lumberjack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// HTTPUploader 以 POST 请求把备份作为请求体流式发送到 URL，适合不想额外部署
+// 日志采集程序、只需把备份推送到收集服务的轻量场景。请求头 X-Lumberjack-Filename
+// 携带备份文件名。网络错误、5xx、408 和 429 响应按 Retries 以指数退避重试，
+// 其他 4xx 响应视为被拒绝，不再重试。
+type HTTPUploader struct {
+	// URL 是接收备份的地址，通常为 https
+	URL string
+
+	// Authorization 非空时作为请求的 Authorization 头，例如 "Bearer xxx"
+	Authorization string
+
+	// Header 是额外附加的请求头
+	Header http.Header
+
+	// Retries 是失败后的重试次数，默认为 0，即不重试
+	Retries int
+
+	// RetryDelay 是第一次重试前的等待时间，之后每次翻倍，默认为 1 秒
+	RetryDelay time.Duration
+
+	// Client 是发送请求使用的 HTTP 客户端，默认为 http.DefaultClient
+	Client *http.Client
+}
+
+// Upload 上传本地文件 path
+func (h *HTTPUploader) Upload(ctx context.Context, path string) error {
+	header := map[string]string{
+		"Content-Type":          "application/octet-stream",
+		"X-Lumberjack-Filename": filepath.Base(path),
+	}
+	for k, v := range h.Header {
+		if len(v) > 0 {
+			header[k] = v[0]
+		}
+	}
+	if h.Authorization != "" {
+		header["Authorization"] = h.Authorization
+	}
+	return retryBackoff(ctx, systemClock{}, h.Retries, h.RetryDelay, func() error {
+		err := putFile(ctx, httpClient(h.Client), http.MethodPost, h.URL, path, header)
+		var se *httpStatusError
+		if errors.As(err, &se) && se.Code/100 == 4 && se.Code != http.StatusRequestTimeout && se.Code != http.StatusTooManyRequests {
+			return &permanentError{err}
+		}
+		return err
+	})
+}
+
+// httpClient 返回 c，c 为 nil 时返回 http.DefaultClient
+func httpClient(c *http.Client) *http.Client {
+	if c == nil {
+		return http.DefaultClient
+	}
+	return c
+}
+
+// putFile 以 method 把本地文件 path 作为请求体发送到 u，响应不是 2xx 时返回错误
+func putFile(ctx context.Context, c *http.Client, method, u, path string, header map[string]string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return responseError(resp)
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// httpStatusError 是服务器返回的非 2xx 响应
+type httpStatusError struct {
+	Code   int
+	Status string
+	Body   string // 响应体的开头部分
+}
+
+func (e *httpStatusError) Error() string {
+	if e.Body != "" {
+		return fmt.Sprintf("%s: %s", e.Status, e.Body)
+	}
+	return e.Status
+}
+
+// responseError 把失败的 HTTP 响应转换为 *httpStatusError
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return &httpStatusError{Code: resp.StatusCode, Status: resp.Status, Body: strings.TrimSpace(string(body))}
+}
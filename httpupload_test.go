@@ -0,0 +1,88 @@
+package lumberjack
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPUploader(t *testing.T) {
+	dir := makeTempDir("TestHTTPUploader", t)
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "app-2001.log.gz")
+	isNil(os.WriteFile(name, []byte("boo!"), 0600), t)
+
+	var calls atomic.Int32
+	var gotAuth, gotName, gotExtra string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 前两次请求返回 503，之后成功
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		gotAuth = r.Header.Get("Authorization")
+		gotName = r.Header.Get("X-Lumberjack-Filename")
+		gotExtra = r.Header.Get("X-Env")
+		gotBody, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	h := &HTTPUploader{
+		URL:           srv.URL + "/ingest",
+		Authorization: "Bearer secret",
+		Header:        http.Header{"X-Env": {"prod"}},
+		Retries:       2,
+		RetryDelay:    time.Millisecond,
+	}
+	isNil(h.Upload(context.Background(), name), t)
+	equals(int32(3), calls.Load(), t)
+	equals("Bearer secret", gotAuth, t)
+	equals("app-2001.log.gz", gotName, t)
+	equals("prod", gotExtra, t)
+	equals("boo!", string(gotBody), t)
+
+	// 重试次数用完后返回最后一次的错误
+	calls.Store(-10)
+	err := h.Upload(context.Background(), name)
+	notNil(err, t)
+	equals("503 Service Unavailable", err.Error(), t)
+}
+
+func TestHTTPUploaderRejected(t *testing.T) {
+	dir := makeTempDir("TestHTTPUploaderRejected", t)
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "app.log")
+	isNil(os.WriteFile(name, []byte("boo!"), 0600), t)
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "bad token", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	h := &HTTPUploader{URL: srv.URL, Retries: 3, RetryDelay: time.Millisecond}
+	err := h.Upload(context.Background(), name)
+	notNil(err, t)
+	equals("401 Unauthorized: bad token", err.Error(), t)
+	// 4xx 不重试
+	equals(int32(1), calls.Load(), t)
+}
+
+func TestParseHTTPTarget(t *testing.T) {
+	t.Setenv("LUMBERJACK_UPLOAD_AUTHORIZATION", "Bearer secret")
+	u, err := ParseUploadTarget("https://logs.example.com/ingest")
+	isNil(err, t)
+	h, ok := u.(*HTTPUploader)
+	assert(ok, t, "expected *HTTPUploader, got %T", u)
+	equals("https://logs.example.com/ingest", h.URL, t)
+	equals("Bearer secret", h.Authorization, t)
+	equals(3, h.Retries, t)
+}
@@ -0,0 +1,60 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// instanceID 返回注入日志文件名的实例标识，未启用按进程区分文件时返回空串
+func (l *Logger) instanceID() string {
+	id := l.InstanceID
+	if id == "" && l.PerProcessFilename {
+		id = strconv.Itoa(os.Getpid())
+	}
+	// 实例标识只作为文件名的一部分，不能引入子目录
+	return strings.NewReplacer("/", "_", `\`, "_").Replace(id)
+}
+
+// baseFilename 返回未注入实例标识的日志文件路径
+func (l *Logger) baseFilename() string {
+	if name, _ := l.filenameOverride.Load().(string); name != "" {
+		return name
+	}
+	if l.Filename != "" {
+		return l.Filename
+	}
+	name := filepath.Base(os.Args[0]) + "-lumberjack.log"
+	return filepath.Join(os.TempDir(), name)
+}
+
+// familyPrefixAndExt 返回同一配置下所有实例共用的备份文件名前缀与扩展名
+func (l *Logger) familyPrefixAndExt() (prefix, ext string) {
+	filename := filepath.Base(l.baseFilename())
+	ext = filepath.Ext(filename)
+	prefix = filename[:len(filename)-len(ext)] + "-"
+	return prefix, ext
+}
+
+// familyTimeFromName 从任一实例的备份文件名（name-<id>-timestamp.ext）中解析
+// 时间戳。实例标识本身可能含有 "-"，因此依次尝试每个 "-" 之后的部分。
+// 其他实例正在写入的日志文件（name-<id>.ext）不含时间戳，不会被识别为备份。
+func (l *Logger) familyTimeFromName(filename, prefix, ext string) (time.Time, bool) {
+	if !strings.HasPrefix(filename, prefix) || !strings.HasSuffix(filename, ext) {
+		return time.Time{}, false
+	}
+	rest := filename[len(prefix) : len(filename)-len(ext)]
+	// 从 i = -1 开始，使启用该模式之前产生的 name-timestamp.ext 备份也被识别
+	for i := -1; ; {
+		if t, err := parseBackupTime(l.timeFormat(), rest[i+1:], l.location()); err == nil {
+			return t, true
+		}
+		j := strings.Index(rest[i+1:], "-")
+		if j < 0 {
+			return time.Time{}, false
+		}
+		i += j + 1
+	}
+}
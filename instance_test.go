@@ -0,0 +1,79 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPerProcessFilename(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestPerProcessFilename", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:           logFile(dir),
+		PerProcessFilename: true,
+	}
+	defer l.Close()
+
+	filename := filepath.Join(dir, "foobar-"+strconv.Itoa(os.Getpid())+".log")
+	equals(filename, l.CurrentFile(), t)
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	existsWithContent(filename, b, t)
+	notExist(logFile(dir), t)
+
+	isNil(l.Rotate(), t)
+	backup := filepath.Join(dir, "foobar-"+strconv.Itoa(os.Getpid())+"-"+fakeTime().UTC().Format(backupTimeFormat)+".log")
+	existsWithContent(backup, b, t)
+}
+
+func TestInstanceIDFamilyRetention(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestInstanceIDFamilyRetention", t)
+	defer os.RemoveAll(dir)
+
+	// 其他实例（包括标识中含 "-" 的）留下的备份和正在写入的文件
+	ts := func(d time.Duration) string {
+		return fakeTime().Add(-d).UTC().Format(backupTimeFormat)
+	}
+	oldest := filepath.Join(dir, "foobar-"+ts(3*time.Hour)+".log")
+	older := filepath.Join(dir, "foobar-web-1-"+ts(2*time.Hour)+".log")
+	old := filepath.Join(dir, "foobar-web-2-"+ts(time.Hour)+".log.gz")
+	active := filepath.Join(dir, "foobar-web-2.log")
+	for _, name := range []string{oldest, older, old, active} {
+		isNil(os.WriteFile(name, []byte("x"), 0644), t)
+	}
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		InstanceID: "web/3",
+		MaxBackups: 2,
+	}
+	defer l.Close()
+	equals(filepath.Join(dir, "foobar-web_3.log"), l.CurrentFile(), t)
+
+	files, err := l.oldLogFiles()
+	isNil(err, t)
+	equals(3, len(files), t)
+
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+
+	removed, err := l.Purge()
+	isNil(err, t)
+	equals(2, len(removed), t)
+	notExist(oldest, t)
+	notExist(older, t)
+	exists(old, t)
+	exists(filepath.Join(dir, "foobar-web_3-"+fakeTime().UTC().Format(backupTimeFormat)+".log"), t)
+	exists(active, t)
+}
@@ -0,0 +1,64 @@
+package lumberjack
+
+// Operation 是可以被观测的轮转与后台处理操作
+type Operation string
+
+const (
+	// OpRotate 轮转当前日志文件
+	OpRotate Operation = "rotate"
+	// OpCleanup 一次完整的后台处理：清理、打包、压缩和加密旧备份
+	OpCleanup Operation = "cleanup"
+	// OpCompress 压缩单个备份
+	OpCompress Operation = "compress"
+	// OpEncrypt 加密单个备份
+	OpEncrypt Operation = "encrypt"
+	// OpBundle 把某一天的备份打包为归档
+	OpBundle Operation = "bundle"
+	// OpUpload 把单个备份上传到 Uploader 或 UploadTarget
+	OpUpload Operation = "upload"
+	// OpPostRotate 对单个备份执行 PostRotateCommand
+	OpPostRotate Operation = "postrotate"
+	// OpProcess 对单个备份执行 Processors 中的一步
+	OpProcess Operation = "process"
+	// OpWrite 写入日志文件或 Fallback 失败，仅用于 AsyncError
+	OpWrite Operation = "write"
+	// OpMirror 写入 Mirror 失败，仅用于 AsyncError
+	OpMirror Operation = "mirror"
+	// OpSync 后台定期 fsync 失败，仅用于 AsyncError
+	OpSync Operation = "sync"
+	// OpAudit 写入 AuditFile 失败，仅用于 AsyncError
+	OpAudit Operation = "audit"
+)
+
+// Instrumentation 用于观测轮转、压缩、清理等操作，例如把它们上报为
+// OpenTelemetry 的 span 和指标（见 otellumberjack 子包），以便发现卡住的
+// 后台操作。StartOperation 在操作开始时调用，file 为操作的文件（轮转和清理
+// 为日志文件，压缩、加密为备份文件，打包为归档文件）；返回的函数在操作结束
+// 时以操作结果调用。实现必须可以被并发调用。
+type Instrumentation interface {
+	StartOperation(op Operation, file string) (end func(err error))
+}
+
+// startOp 通知 Instrumentation 操作开始，返回结束时需要调用的函数。
+// 未配置 Instrumentation 时返回空操作。
+func (l *Logger) startOp(op Operation, file string) func(err error) {
+	if l.Instrumentation == nil {
+		return func(error) {}
+	}
+	return l.Instrumentation.StartOperation(op, file)
+}
+
+// notifyRemove 在删除备份后调用 OnRemove
+func (l *Logger) notifyRemove(path string) {
+	if l.OnRemove != nil {
+		l.OnRemove(path)
+	}
+}
+
+// notifyCompress 在备份被压缩、加密或打包后记录审计并调用 OnCompress
+func (l *Logger) notifyCompress(src, dst string) {
+	l.audit(AuditCompress, dst, src, l.auditSize(dst))
+	if l.OnCompress != nil {
+		l.OnCompress(src, dst)
+	}
+}
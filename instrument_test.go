@@ -0,0 +1,99 @@
+package lumberjack
+
+import (
+	"os"
+	"sync"
+	"testing"
+)
+
+// recordingInstrumentation 记录所有已结束的操作
+type recordingInstrumentation struct {
+	mu  sync.Mutex
+	ops []Operation
+}
+
+func (r *recordingInstrumentation) StartOperation(op Operation, file string) func(err error) {
+	return func(err error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.ops = append(r.ops, op)
+	}
+}
+
+func TestInstrumentation(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestInstrumentation", t)
+	defer os.RemoveAll(dir)
+
+	inst := &recordingInstrumentation{}
+	l := &Logger{
+		Filename:        logFile(dir),
+		Compress:        true,
+		CompressSync:    true,
+		Instrumentation: inst,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	seen := make(map[Operation]int)
+	for _, op := range inst.ops {
+		seen[op]++
+	}
+	equals(1, seen[OpRotate], t)
+	equals(1, seen[OpCompress], t)
+	// 后台处理还可能在首次打开文件时异步执行
+	assert(seen[OpCleanup] >= 1, t, "expected cleanup operation, got %v", inst.ops)
+}
+
+func TestLifecycleCallbacks(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestLifecycleCallbacks", t)
+	defer os.RemoveAll(dir)
+
+	var (
+		mu         sync.Mutex
+		removed    []string
+		compressed [][2]string
+	)
+	l := &Logger{
+		Filename:     logFile(dir),
+		Compress:     true,
+		CompressSync: true,
+		MaxBackups:   1,
+		OnRemove: func(path string) {
+			mu.Lock()
+			defer mu.Unlock()
+			removed = append(removed, path)
+		},
+		OnCompress: func(src, dst string) {
+			mu.Lock()
+			defer mu.Unlock()
+			compressed = append(compressed, [2]string{src, dst})
+		},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	first := backupFile(dir)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	second := backupFile(dir)
+
+	mu.Lock()
+	defer mu.Unlock()
+	equals([][2]string{
+		{first, first + compressSuffix},
+		{second, second + compressSuffix},
+	}, compressed, t)
+	equals([]string{first + compressSuffix}, removed, t)
+}
@@ -0,0 +1,90 @@
+package lumberjack
+
+import (
+	"fmt"
+	"io"
+)
+
+// LeveledWriter 按级别把日志写入不同的 Logger，例如把错误写入 error.log、
+// 其余写入 info.log，每个 Logger 各自配置轮转与保留策略：
+//
+//	w := &lumberjack.LeveledWriter{
+//		Writers: map[string]*lumberjack.Logger{
+//			"error": {Filename: "/var/log/myapp/error.log", MaxAge: 90},
+//			"debug": {Filename: "/var/log/myapp/debug.log", MaxBackups: 1},
+//		},
+//		Default: &lumberjack.Logger{Filename: "/var/log/myapp/info.log"},
+//		LevelOf: func(p []byte) string { ... },
+//	}
+//
+// 同一个 Logger 可以对应多个级别。LeveledWriter 的字段在开始写入后不应再修改。
+type LeveledWriter struct {
+	// Writers 是级别到 Logger 的映射
+	Writers map[string]*Logger
+
+	// Default 接收 Writers 中没有对应级别的写入，为 nil 时这些写入返回错误
+	Default *Logger
+
+	// LevelOf 从一次写入的内容中提取级别，供 Write 使用。为 nil 时 Write
+	// 全部写入 Default。
+	LevelOf func(p []byte) string
+}
+
+// 编译期检查 LeveledWriter 满足 io.WriteCloser
+var _ io.WriteCloser = (*LeveledWriter)(nil)
+
+// Write 实现 io.Writer，按 LevelOf 返回的级别选择 Logger
+func (w *LeveledWriter) Write(p []byte) (int, error) {
+	level := ""
+	if w.LevelOf != nil {
+		level = w.LevelOf(p)
+	}
+	return w.WriteLevel(level, p)
+}
+
+// WriteLevel 把 p 写入 level 对应的 Logger
+func (w *LeveledWriter) WriteLevel(level string, p []byte) (int, error) {
+	l, ok := w.Writers[level]
+	if !ok || l == nil {
+		l = w.Default
+	}
+	if l == nil {
+		return 0, fmt.Errorf("no writer for level %q", level)
+	}
+	return l.Write(p)
+}
+
+// Rotate 轮转所有 Logger，返回遇到的第一个错误
+func (w *LeveledWriter) Rotate() error {
+	return w.each((*Logger).Rotate)
+}
+
+// Sync 刷新并 fsync 所有 Logger，返回遇到的第一个错误
+func (w *LeveledWriter) Sync() error {
+	return w.each((*Logger).Sync)
+}
+
+// Close 关闭所有 Logger，返回遇到的第一个错误
+func (w *LeveledWriter) Close() error {
+	return w.each((*Logger).Close)
+}
+
+// each 对每个 Logger 调用一次 fn，对应多个级别的 Logger 只调用一次
+func (w *LeveledWriter) each(fn func(*Logger) error) error {
+	seen := make(map[*Logger]bool, len(w.Writers)+1)
+	var firstErr error
+	call := func(l *Logger) {
+		if l == nil || seen[l] {
+			return
+		}
+		seen[l] = true
+		if err := fn(l); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, l := range w.Writers {
+		call(l)
+	}
+	call(w.Default)
+	return firstErr
+}
@@ -0,0 +1,70 @@
+package lumberjack
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLeveledWriter(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestLeveledWriter", t)
+	defer os.RemoveAll(dir)
+
+	errLog := &Logger{Filename: filepath.Join(dir, "error.log")}
+	infoLog := &Logger{Filename: filepath.Join(dir, "info.log")}
+	w := &LeveledWriter{
+		Writers: map[string]*Logger{
+			"error": errLog,
+			"fatal": errLog,
+		},
+		Default: infoLog,
+		LevelOf: func(p []byte) string {
+			if i := bytes.IndexByte(p, ' '); i > 0 {
+				return string(p[:i])
+			}
+			return ""
+		},
+	}
+	defer w.Close()
+
+	for _, line := range []string{"error boom\n", "info ok\n", "fatal bang\n", "debug hm\n"} {
+		n, err := w.Write([]byte(line))
+		isNil(err, t)
+		equals(len(line), n, t)
+	}
+	_, err := w.WriteLevel("error", []byte("direct\n"))
+	isNil(err, t)
+
+	existsWithContent(errLog.Filename, []byte("error boom\nfatal bang\ndirect\n"), t)
+	existsWithContent(infoLog.Filename, []byte("info ok\ndebug hm\n"), t)
+
+	isNil(w.Rotate(), t)
+	equals(int64(1), errLog.Stats().Rotations, t)
+	equals(int64(1), infoLog.Stats().Rotations, t)
+
+	// 对应多个级别的 Logger 只关闭一次，关闭后写入返回错误
+	isNil(w.Close(), t)
+	_, err = w.Write([]byte("error late\n"))
+	notNil(err, t)
+}
+
+func TestLeveledWriterNoDefault(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestLeveledWriterNoDefault", t)
+	defer os.RemoveAll(dir)
+
+	w := &LeveledWriter{
+		Writers: map[string]*Logger{"error": {Filename: filepath.Join(dir, "error.log")}},
+	}
+	defer w.Close()
+
+	// 没有 LevelOf 时全部写入 Default，Default 为 nil 时返回错误
+	_, err := w.Write([]byte("boo!"))
+	notNil(err, t)
+	_, err = w.WriteLevel("info", []byte("boo!"))
+	notNil(err, t)
+	_, err = w.WriteLevel("error", []byte("boo!"))
+	isNil(err, t)
+}
@@ -5,6 +5,7 @@ package lumberjack
 
 import (
 	"os"
+	"path/filepath"
 	"syscall"
 	"testing"
 	"time"
@@ -204,3 +205,176 @@ func (fs *fakeFS) Stat(name string) (os.FileInfo, error) {
 	stat.Gid = 666
 	return info, nil
 }
+
+func TestNotifyReopen(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestNotifyReopen", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+	}
+	defer l.Close()
+
+	stop := NotifyReopen(l, syscall.SIGHUP)
+	defer stop()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	moved := filename + ".1"
+	isNil(os.Rename(filename, moved), t)
+
+	isNil(syscall.Kill(os.Getpid(), syscall.SIGHUP), t)
+	// 等待后台 goroutine 处理信号
+	for i := 0; i < 100; i++ {
+		l.mu.Lock()
+		pending := l.fileMoved()
+		l.mu.Unlock()
+		if !pending {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	_, err = l.Write([]byte("foo"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("foo"), t)
+	existsWithContent(moved, []byte("boo!"), t)
+
+	stop()
+	stop()
+}
+
+func TestPreallocate(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	defer func() { megabyte = 1024 * 1024 }()
+
+	dir := makeTempDir("TestPreallocate", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:    filename,
+		MaxSize:     1024 * 1024,
+		Preallocate: true,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	existsWithContent(filename, b, t)
+
+	allocated := func(name string) int64 {
+		var st syscall.Stat_t
+		isNilUp(syscall.Stat(name, &st), t, 1)
+		return st.Blocks * 512
+	}
+	if allocated(filename) < 1024*1024 {
+		t.Skip("filesystem does not support fallocate")
+	}
+
+	// 轮转后释放备份中未使用的预分配空间
+	isNil(l.Rotate(), t)
+	existsWithContent(backupFile(dir), b, t)
+	assert(allocated(backupFile(dir)) < 1024*1024, t, "expected preallocated space to be released")
+	assert(allocated(filename) >= 1024*1024, t, "expected new file to be preallocated")
+}
+
+func TestIsTransient(t *testing.T) {
+	busy := &os.PathError{Op: "rename", Path: "foo", Err: syscall.EBUSY}
+	assert(IsTransient(busy), t, "expected EBUSY to be transient")
+	assert(IsTransient(&os.LinkError{Op: "rename", Old: "a", New: "b", Err: syscall.EACCES}), t, "expected EACCES to be transient")
+	assert(!IsTransient(&os.PathError{Op: "open", Path: "foo", Err: syscall.ENOENT}), t, "expected ENOENT not to be transient")
+}
+
+func TestFileModeAndDirMode(t *testing.T) {
+	currentTime = fakeTime
+	old := syscall.Umask(0)
+	defer syscall.Umask(old)
+
+	dir := makeTempDir("TestFileModeAndDirMode", t)
+	defer os.RemoveAll(dir)
+
+	// 已存在的日志文件权限较宽，轮转后新文件使用 FileMode
+	sub := filepath.Join(dir, "sub")
+	filename := logFile(sub)
+	l := &Logger{
+		Filename: filename,
+		FileMode: 0640,
+		DirMode:  0750,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	info, err := os.Stat(sub)
+	isNil(err, t)
+	equals(os.ModeDir|0750, info.Mode(), t)
+	info, err = os.Stat(filename)
+	isNil(err, t)
+	equals(os.FileMode(0640), info.Mode(), t)
+
+	isNil(l.Close(), t)
+	isNil(os.Chmod(filename, 0644), t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	info, err = os.Stat(filename)
+	isNil(err, t)
+	equals(os.FileMode(0640), info.Mode(), t)
+	info, err = os.Stat(backupFile(sub))
+	isNil(err, t)
+	equals(os.FileMode(0644), info.Mode(), t)
+}
+
+func TestOwnerAndGroup(t *testing.T) {
+	fakeFS := newFakeFS()
+	osChown = fakeFS.Chown
+	defer func() { osChown = os.Chown }()
+	currentTime = fakeTime
+	dir := makeTempDir("TestOwnerAndGroup", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		Owner:        1001,
+		Group:        1002,
+		Compress:     true,
+		CompressSync: true,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	equals(fakeFile{uid: 1001, gid: 1002}, fakeFS.files[filename], t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	equals(fakeFile{uid: 1001, gid: 1002}, fakeFS.files[filename], t)
+	equals(fakeFile{uid: 1001, gid: 1002}, fakeFS.files[backupFile(dir)+compressSuffix], t)
+}
+
+func TestOwnerOnly(t *testing.T) {
+	fakeFS := newFakeFS()
+	osChown = fakeFS.Chown
+	defer func() { osChown = os.Chown }()
+	currentTime = fakeTime
+	dir := makeTempDir("TestOwnerOnly", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		Owner:    1001,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// 未设置的 Group 保持不变
+	equals(fakeFile{uid: 1001, gid: -1}, fakeFS.files[filename], t)
+}
@@ -0,0 +1,122 @@
+package lumberjack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// lockSuffix 是进程间锁文件的后缀。锁加在独立的文件上，轮转时重命名日志文件
+// 不会影响锁。
+const lockSuffix = ".lock"
+
+// procLocker 是进程间排他锁
+type procLocker interface {
+	lock() error
+	unlock() error
+	close() error
+}
+
+// fileLocker 以日志文件旁的 .lock 文件实现进程间锁
+type fileLocker struct {
+	f *os.File
+}
+
+func (fl *fileLocker) lock() error   { return lockFile(fl.f) }
+func (fl *fileLocker) unlock() error { return unlockFile(fl.f) }
+func (fl *fileLocker) close() error  { return fl.f.Close() }
+
+// interProcess 判断是否启用了进程间协调
+func (l *Logger) interProcess() bool {
+	return l.InterProcessLock || l.NamedMutex
+}
+
+// newLocker 按配置创建进程间锁：NamedMutex 在 Windows 上使用命名互斥量，
+// 其余情况使用锁文件
+func (l *Logger) newLocker() (procLocker, error) {
+	if l.NamedMutex {
+		m, err := newNamedMutex(mutexName(l.filename()))
+		if err != nil {
+			return nil, fmt.Errorf("can't create named mutex: %s", err)
+		}
+		if m != nil {
+			return m, nil
+		}
+	}
+	if err := os.MkdirAll(l.dir(), l.dirMode()); err != nil {
+		return nil, fmt.Errorf("can't make directories for new logfile: %s", err)
+	}
+	f, err := os.OpenFile(l.filename()+lockSuffix, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("can't open lock file: %s", err)
+	}
+	return &fileLocker{f: f}, nil
+}
+
+// mutexName 由日志文件的绝对路径派生命名互斥量的名称。Windows 路径不区分
+// 大小写，因此先统一为小写，使以不同写法配置同一文件的进程得到相同的名称。
+func mutexName(filename string) string {
+	if abs, err := filepath.Abs(filename); err == nil {
+		filename = abs
+	}
+	sum := sha256.Sum256([]byte(strings.ToLower(filepath.Clean(filename))))
+	return `Global\lumberjack-` + hex.EncodeToString(sum[:16])
+}
+
+// lockShared 在启用 InterProcessLock 或 NamedMutex 时获取进程间排他锁，并与
+// 其他进程同步当前文件的状态：若其他进程已经轮转（打开的文件已被移走），则
+// 重新打开日志文件；否则以磁盘上的实际大小更新 l.size。返回释放锁的函数，
+// 必须在同一个 goroutine 中调用。调用方必须持有 l.mu。
+func (l *Logger) lockShared() (unlock func(), err error) {
+	if !l.interProcess() {
+		return func() {}, nil
+	}
+	if l.locker == nil {
+		if l.locker, err = l.newLocker(); err != nil {
+			return nil, err
+		}
+	}
+	if err := l.locker.lock(); err != nil {
+		return nil, fmt.Errorf("can't acquire inter-process lock: %s", err)
+	}
+	unlock = func() {
+		if err := l.locker.unlock(); err != nil {
+			l.logDebug("释放进程间锁失败: %v，文件: %s", err, l.filename())
+		}
+	}
+
+	if err := l.syncShared(); err != nil {
+		unlock()
+		return nil, err
+	}
+	return unlock, nil
+}
+
+// syncShared 使当前文件的状态与磁盘一致，见 lockShared
+func (l *Logger) syncShared() error {
+	if l.file == nil {
+		return nil
+	}
+	if l.fileMoved() {
+		return l.reopen()
+	}
+	info, err := l.file.Stat()
+	if err != nil {
+		return fmt.Errorf("error getting log file info: %s", err)
+	}
+	l.size = info.Size()
+	return nil
+}
+
+// closeLock 关闭进程间锁
+func (l *Logger) closeLock() error {
+	if l.locker == nil {
+		return nil
+	}
+	err := l.locker.close()
+	l.locker = nil
+	return err
+}
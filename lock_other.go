@@ -0,0 +1,16 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly && !windows
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly,!windows
+
+package lumberjack
+
+import "os"
+
+// lockFile 在不支持文件锁的平台上不做任何事，InterProcessLock 不生效
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// unlockFile 在不支持文件锁的平台上不做任何事
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,126 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInterProcessLock(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestInterProcessLock", t)
+	defer os.RemoveAll(dir)
+
+	// 两个 Logger 各自打开同一文件，模拟两个进程
+	filename := logFile(dir)
+	l1 := &Logger{Filename: filename, MaxSize: 10, InterProcessLock: true}
+	defer l1.Close()
+	l2 := &Logger{Filename: filename, MaxSize: 10, InterProcessLock: true}
+	defer l2.Close()
+
+	_, err := l1.Write([]byte("boo!"))
+	isNil(err, t)
+	_, err = l2.Write([]byte("foooo"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("boo!foooo"), t)
+	exists(filename+lockSuffix, t)
+
+	// l1 按磁盘上的实际大小判断需要轮转
+	_, err = l1.Write([]byte("bar"))
+	isNil(err, t)
+	existsWithContent(backupFile(dir), []byte("boo!foooo"), t)
+
+	// l2 发现文件已被轮转，重新打开而不是写入备份
+	_, err = l2.Write([]byte("baz"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("barbaz"), t)
+	existsWithContent(backupFile(dir), []byte("boo!foooo"), t)
+	fileCount(dir, 3, t)
+}
+
+func TestInterProcessLockConcurrent(t *testing.T) {
+	currentTime = time.Now
+	defer func() { currentTime = fakeTime }()
+	megabyte = 1
+
+	dir := makeTempDir("TestInterProcessLockConcurrent", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	line := "0123456789\n"
+	const writers, writes = 4, 50
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		l := &Logger{Filename: filename, MaxSize: 100, InterProcessLock: true}
+		defer l.Close()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < writes; j++ {
+				_, err := l.Write([]byte(line))
+				isNil(err, t)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 所有写入都保留在日志文件或备份中，且没有文件超过 MaxSize
+	files, err := os.ReadDir(dir)
+	isNil(err, t)
+	var total int
+	for _, f := range files {
+		if strings.HasSuffix(f.Name(), lockSuffix) {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, f.Name()))
+		isNil(err, t)
+		assert(len(b) <= 100, t, "file %s has %d bytes", f.Name(), len(b))
+		total += len(b)
+	}
+	equals(writers*writes*len(line), total, t)
+}
+
+func TestMutexName(t *testing.T) {
+	dir := makeTempDir("TestMutexName", t)
+	defer os.RemoveAll(dir)
+
+	name := mutexName(logFile(dir))
+	assert(strings.HasPrefix(name, `Global\lumberjack-`), t, "unexpected mutex name %s", name)
+	assert(!strings.ContainsAny(name[len(`Global\`):], `\/`), t, "mutex name %s contains separators", name)
+
+	// 同一文件的不同写法得到相同的名称
+	equals(name, mutexName(strings.ToUpper(logFile(dir))), t)
+	equals(name, mutexName(filepath.Join(dir, ".", "foobar.log")), t)
+	assert(name != mutexName(logFile(dir)+".other"), t, "expected different names for different files")
+}
+
+func TestNamedMutex(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestNamedMutex", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l1 := &Logger{Filename: filename, MaxSize: 10, NamedMutex: true}
+	defer l1.Close()
+	l2 := &Logger{Filename: filename, MaxSize: 10, NamedMutex: true}
+	defer l2.Close()
+
+	_, err := l1.Write([]byte("boo!"))
+	isNil(err, t)
+	_, err = l2.Write([]byte("foooo"))
+	isNil(err, t)
+	_, err = l1.Write([]byte("bar"))
+	isNil(err, t)
+	_, err = l2.Write([]byte("baz"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("barbaz"), t)
+	existsWithContent(backupFile(dir), []byte("boo!foooo"), t)
+}
@@ -0,0 +1,22 @@
+//go:build windows
+// +build windows
+
+package lumberjack
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile 以 LockFileEx 获取 f 上的排他锁，其他进程持有锁时阻塞等待
+func lockFile(f *os.File) error {
+	ol := new(windows.Overlapped)
+	return windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, ol)
+}
+
+// unlockFile 释放 f 上的锁
+func unlockFile(f *os.File) error {
+	ol := new(windows.Overlapped)
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, ol)
+}
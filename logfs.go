@@ -0,0 +1,219 @@
+package lumberjack
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FS 返回当前日志文件和全部备份的只读 fs.FS 视图，可以直接交给 fs.WalkDir、
+// http.FileServer(http.FS(...)) 等标准工具使用。所有文件位于根目录下，不区分
+// BackupDir 和按日期分区的子目录；压缩和加密的备份以去掉相应后缀后的名称
+// 出现（按天打包的归档为 .tar），打开时解开为原始内容。
+//
+// 打开压缩或加密的备份会把解开后的内容整个读入内存，以便支持 Seek；目录
+// 项的 Info 需要完整解开一遍才能得到这类文件的大小。
+func (l *Logger) FS() fs.FS {
+	return logFS{l}
+}
+
+// logFS 是 FS 返回的文件系统
+type logFS struct {
+	l *Logger
+}
+
+// fsEntry 是 logFS 根目录下的一个文件
+type fsEntry struct {
+	name string // 规范化后的文件名
+	path string // 磁盘上的路径
+	info fs.FileInfo
+}
+
+// encoded 判断文件在磁盘上是否经过压缩或加密，需要解开后才能读取
+func (e fsEntry) encoded() bool {
+	return e.name != e.info.Name()
+}
+
+// entries 返回根目录下的全部文件，按名称排序。规范化后同名的文件（例如正在
+// 压缩的备份与其压缩结果）只保留未压缩的那个。
+func (fsys logFS) entries() ([]fsEntry, error) {
+	l := fsys.l
+	l.backupMu.Lock()
+	files, err := l.oldLogFiles()
+	l.backupMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]fsEntry)
+	add := func(e fsEntry) {
+		if old, ok := byName[e.name]; ok && !old.encoded() {
+			return
+		}
+		byName[e.name] = e
+	}
+	if info, err := l.stat(l.activeName()); err == nil {
+		add(fsEntry{name: filepath.Base(l.filename()), path: l.activeName(), info: info})
+	}
+	for _, f := range files {
+		add(fsEntry{name: trimCompressSuffix(f.Name()), path: f.path(), info: f.FileInfo})
+	}
+
+	entries := make([]fsEntry, 0, len(byName))
+	for _, e := range byName {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+	return entries, nil
+}
+
+// Open 实现 fs.FS
+func (fsys logFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	entries, err := fsys.entries()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if name == "." {
+		return &fsDir{fsys: fsys, entries: entries}, nil
+	}
+	for _, e := range entries {
+		if e.name != name {
+			continue
+		}
+		if !e.encoded() {
+			f, err := fsys.l.fsys().OpenFile(e.path, os.O_RDONLY, 0)
+			if err != nil {
+				return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+			}
+			return f, nil
+		}
+		var r io.ReadCloser
+		if e.path == fsys.l.activeName() {
+			// StreamCompress 模式下正在写入的压缩文件
+			r, err = fsys.l.openActive()
+		} else {
+			r, err = fsys.l.openBackupReader(e.path)
+		}
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+		}
+		return &fsFile{
+			Reader: bytes.NewReader(data),
+			info:   fsInfo{FileInfo: e.info, name: e.name, size: int64(len(data))},
+		}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir 实现 fs.ReadDirFS
+func (fsys logFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name != "." {
+		if !fs.ValidPath(name) {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+		}
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	entries, err := fsys.entries()
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	return (&fsDir{fsys: fsys, entries: entries}).ReadDir(-1)
+}
+
+// fsInfo 以规范化后的名称描述文件，size 为解开后的大小
+type fsInfo struct {
+	fs.FileInfo
+	name string
+	size int64
+}
+
+func (i fsInfo) Name() string { return i.name }
+func (i fsInfo) Size() int64  { return i.size }
+
+// fsFile 是解开到内存中的压缩或加密备份
+type fsFile struct {
+	*bytes.Reader
+	info fsInfo
+}
+
+func (f *fsFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *fsFile) Close() error               { return nil }
+
+// fsDir 是 logFS 的根目录
+type fsDir struct {
+	fsys    logFS
+	entries []fsEntry
+	offset  int
+}
+
+func (d *fsDir) Stat() (fs.FileInfo, error) { return rootInfo{}, nil }
+func (d *fsDir) Close() error               { return nil }
+
+func (d *fsDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: ".", Err: fs.ErrInvalid}
+}
+
+// ReadDir 实现 fs.ReadDirFile
+func (d *fsDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n > 0 && len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > 0 && n < len(rest) {
+		rest = rest[:n]
+	}
+	d.offset += len(rest)
+	list := make([]fs.DirEntry, 0, len(rest))
+	for _, e := range rest {
+		list = append(list, fsDirEntry{d.fsys, e})
+	}
+	return list, nil
+}
+
+// fsDirEntry 是根目录下的目录项
+type fsDirEntry struct {
+	fsys logFS
+	e    fsEntry
+}
+
+func (d fsDirEntry) Name() string      { return d.e.name }
+func (d fsDirEntry) IsDir() bool       { return false }
+func (d fsDirEntry) Type() fs.FileMode { return d.e.info.Mode().Type() }
+
+// Info 返回文件信息，压缩或加密的备份需要解开一遍以得到原始大小
+func (d fsDirEntry) Info() (fs.FileInfo, error) {
+	size := d.e.info.Size()
+	if d.e.encoded() {
+		r, err := d.fsys.l.openBackupReader(d.e.path)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		if size, err = io.Copy(io.Discard, r); err != nil {
+			return nil, err
+		}
+	}
+	return fsInfo{FileInfo: d.e.info, name: d.e.name, size: size}, nil
+}
+
+// rootInfo 描述 logFS 的根目录
+type rootInfo struct{}
+
+func (rootInfo) Name() string       { return "." }
+func (rootInfo) Size() int64        { return 0 }
+func (rootInfo) Mode() fs.FileMode  { return fs.ModeDir | 0555 }
+func (rootInfo) ModTime() time.Time { return time.Time{} }
+func (rootInfo) IsDir() bool        { return true }
+func (rootInfo) Sys() any           { return nil }
@@ -0,0 +1,43 @@
+package lumberjack
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFS(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestFS", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), Compress: true, CompressSync: true, PartitionByDate: true}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	backup := backupFile(dir)
+	_, err = l.Write([]byte("foo\n"))
+	isNil(err, t)
+
+	fsys := l.FS()
+	name := filepath.Base(backup)
+	isNil(fstest.TestFS(fsys, filepath.Base(logFile(dir)), name), t)
+
+	b, err := fs.ReadFile(fsys, name)
+	isNil(err, t)
+	equals("boo!\n", string(b), t)
+	info, err := fs.Stat(fsys, name)
+	isNil(err, t)
+	equals(int64(5), info.Size(), t)
+
+	b, err = fs.ReadFile(fsys, filepath.Base(logFile(dir)))
+	isNil(err, t)
+	equals("foo\n", string(b), t)
+
+	_, err = fsys.Open(name + compressSuffix)
+	assert(os.IsNotExist(err), t, "expected not exist, got %v", err)
+}
@@ -22,17 +22,21 @@
 package lumberjack
 
 import (
+	"bufio"
+	"bytes"
 	"compress/gzip"
+	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -79,14 +83,128 @@ var _ io.WriteCloser = (*Logger)(nil)
 // If MaxBackups and MaxAge are both 0, no old log files will be deleted.
 type Logger struct {
 	// Filename is the file to write logs to.  Backup log files will be retained
-	// in the same directory.  It uses <processname>-lumberjack.log in
+	// in the same directory, unless BackupDir is set.  It uses <processname>-lumberjack.log in
 	// os.TempDir() if empty.
 	Filename string `json:"filename" yaml:"filename"`
 
+	// BackupDir 是存放轮转后备份文件的目录，不存在时会自动创建。相对路径相对于
+	// Filename 所在目录。清理与压缩都只扫描该目录。轮转通过重命名完成，因此
+	// BackupDir 应与日志文件位于同一文件系统。默认为空，即与日志文件同目录。
+	BackupDir string `json:"backupdir" yaml:"backupdir"`
+
+	// PartitionByDate 为 true 时按轮转时间把备份放入备份目录下的 YYYY/MM/DD/
+	// 子目录（时区由 LocalTime 决定），避免单个目录中积累成千上万个文件。
+	// 清理时会扫描这些子目录，并删除清理后变空的子目录。
+	PartitionByDate bool `json:"partitionbydate" yaml:"partitionbydate"`
+
+	// HourlyDirs 为 true 时日志文件按小时写入 Filename 所在目录下的
+	// YYYY-MM-DD/HH/ 子目录，例如 logs/app.log 在 13 点写入
+	// logs/2024-06-01/13/app.log，便于 Spark 等按小时分区读取。每到整点（时区
+	// 由 Location、TimeZone 或 LocalTime 决定）关闭上一个小时的文件并在新的
+	// 子目录中打开，上一个小时的文件原样保留，不会被重命名为备份。一个小时内
+	// 按 MaxSize 等条件轮转产生的备份放在该小时的子目录中（BackupDir 为相对
+	// 路径时相对于该子目录），清理和压缩只处理当前小时的备份，已过去的小时
+	// 目录需要由下游或其他工具清理。默认为 false。
+	HourlyDirs bool `json:"hourlydirs" yaml:"hourlydirs"`
+
+	// FilenamePattern 是备份文件名模板，支持以下占位符：
+	//
+	//	{name}      日志文件名去掉扩展名的部分
+	//	{ext}       日志文件的扩展名（含 "."）
+	//	{timestamp} 轮转时间
+	//	{pid}       当前进程号
+	//	{hostname}  主机名
+	//	{seq}       递增序号，在已有备份的最大序号上加一
+	//
+	// 模板必须包含 {timestamp} 或 {seq}，且不能包含路径分隔符。清理旧备份时
+	// 按同一模板识别备份文件；模板不含 {timestamp} 时按文件修改时间排序。
+	// 默认为空，等价于 "{name}-{timestamp}{ext}"。
+	FilenamePattern string `json:"filenamepattern" yaml:"filenamepattern"`
+
+	// NumberedBackups 为 true 时使用与 logrotate 兼容的序号命名：最新的备份为
+	// app.log.1，每次轮转时已有备份的序号依次加一（app.log.1 -> app.log.2，
+	// 压缩后的 app.log.1.gz 同理）。该模式下按修改时间计算 MaxAge，
+	// PartitionByDate 不生效，且不能与 FilenamePattern 同时使用。
+	NumberedBackups bool `json:"numberedbackups" yaml:"numberedbackups"`
+
+	// BackupTimeFormat 是备份文件名中时间戳使用的 time.Time 格式，例如
+	// "2006-01-02" 或 "20060102-150405.000"。清理旧备份时按同一格式解析文件名。
+	// 格式中不能包含路径分隔符。默认为 "2006-01-02T15-04-05.000"。
+	BackupTimeFormat string `json:"backuptimeformat" yaml:"backuptimeformat"`
+
+	// SymlinkName 是始终指向当前日志文件的符号链接路径，相对路径相对于日志文件
+	// 所在目录。每次打开或轮转文件后都会更新，tail 等工具可以一直跟踪该路径。
+	// Windows 上无权创建符号链接时改用硬链接。默认为空，即不创建链接。
+	SymlinkName string `json:"symlinkname" yaml:"symlinkname"`
+
+	// FileMode 是新建日志文件的权限，设置后轮转时不再沿用旧文件的权限。与
+	// os.OpenFile 一样，实际权限还会受进程 umask 影响。默认为 0，即首次创建
+	// 时使用 0600，轮转时沿用旧文件的权限。
+	FileMode os.FileMode `json:"filemode" yaml:"filemode"`
+
+	// DirMode 是自动创建日志目录和备份目录时使用的权限，同样受 umask 影响。
+	// 已存在的目录不会被修改。默认为 0，即使用 0755。
+	DirMode os.FileMode `json:"dirmode" yaml:"dirmode"`
+
+	// Owner 与 Group 是新建日志文件及其备份（包括压缩、加密后的文件）的属主
+	// uid 和 gid，使以 root 启动、随后降权运行的进程仍能让降权后的用户读取和
+	// 轮转日志。修改属主通常需要特权。仅在 Unix 上生效。默认为 0，即不修改；
+	// 只设置其中一项时另一项保持不变。
+	Owner int `json:"owner" yaml:"owner"`
+	Group int `json:"group" yaml:"group"`
+
+	// CopyACL 决定 Windows 上新建日志文件的 DACL 从何处复制："directory"
+	// 重新继承日志目录上可继承的权限，"previous" 复制轮转前日志文件的权限，
+	// 使权限受限的服务账号在轮转后仍能读取日志。其他平台上不生效。默认为空，
+	// 即使用系统默认的权限。
+	CopyACL ACLSource `json:"copyacl" yaml:"copyacl"`
+
 	// MaxSize is the maximum size in megabytes of the log file before it gets
 	// rotated. It defaults to 100 megabytes.
 	MaxSize int `json:"maxsize" yaml:"maxsize"`
 
+	// MaxSizeStr 以带单位的字符串表示 MaxSize，例如 "500MB"、"1.5GiB"，不带
+	// 单位时按字节计算，单位均按 1024 进制换算。设置后优先于 MaxSize，适合
+	// 配置中不便说明 MaxSize 以 MB 为单位的场景。
+	MaxSizeStr string `json:"maxsizestr" yaml:"maxsizestr"`
+
+	// MaxLines 是日志文件在轮转前最多容纳的行数（以换行符计）。写入会使行数
+	// 超过该值时先轮转；单次写入本身超过 MaxLines 行时不会被拆分。默认为 0，
+	// 即不按行数轮转。
+	MaxLines int `json:"maxlines" yaml:"maxlines"`
+
+	// MaxLineBytes 是单次写入（一条日志记录）去掉末尾换行后的最大字节数。
+	// 超过时只保留前 MaxLineBytes 字节，并追加 "...[truncated N bytes]" 标记
+	// 和原有的末尾换行，N 为被丢弃的字节数，避免几 MB 的超长行破坏下游解析
+	// 或单次写入超过 MaxSize。截断不会拆开 UTF-8 字符。ReadFrom 写入的数据
+	// 不是单条记录，不受此限制（AppendNewline 同理）。默认为 0，即不截断。
+	MaxLineBytes int `json:"maxlinebytes" yaml:"maxlinebytes"`
+
+	// Transforms 在每次写入的数据写入日志文件前依次对其进行变换，例如
+	// StripANSI 去除颜色码、按正则表达式脱敏或在行首添加时间戳。MaxLineBytes、
+	// AppendNewline、MaxSize 与 MaxLines 都按变换后的内容计算，Mirror 也收到
+	// 变换后的内容；Write 成功时仍返回 len(p)。ReadFrom 按每次读取的数据块
+	// 分别变换，跨数据块的内容可能无法匹配。默认为空。
+	Transforms []Transform `json:"-" yaml:"-"`
+
+	// RedactPatterns 是脱敏使用的正则表达式（RE2 语法），匹配到的内容在写入
+	// 日志文件前被替换为 RedactReplacement，使令牌、邮箱等敏感信息不会落盘，
+	// 常用的表达式见 RedactEmail 等常量。RedactKeys 是 JSON 行中需要脱敏的键名
+	// （不区分大小写），其字符串、数字等标量值被整体替换，见 RedactJSONKeys。
+	// 脱敏在 Transforms 之后、MaxLineBytes 截断之前进行，Mirror 同样只收到
+	// 脱敏后的内容。表达式无法编译时 Write 返回错误，不写入任何内容。默认为空。
+	RedactPatterns []string `json:"redactpatterns" yaml:"redactpatterns"`
+	RedactKeys     []string `json:"redactkeys" yaml:"redactkeys"`
+
+	// RedactReplacement 是替换敏感内容的文本，默认为 "[REDACTED]"
+	RedactReplacement string `json:"redactreplacement" yaml:"redactreplacement"`
+
+	// AppendNewline 为 true 时，不以换行结尾的写入会在末尾补上 "\n"，使每次
+	// 写入都是完整的一行，轮转不会把一条记录拆到两个文件中，按行采集的日志
+	// 收集器也能正确切分。补上的换行计入 MaxSize 和 MaxLines，但不计入 Write
+	// 的返回值。空写入不补换行。默认为 false。
+	AppendNewline bool `json:"appendnewline" yaml:"appendnewline"`
+
 	// MaxAge is the maximum number of days to retain old log files based on the
 	// timestamp encoded in their filename.  Note that a day is defined as 24
 	// hours and may not exactly correspond to calendar days due to daylight
@@ -94,30 +212,498 @@ type Logger struct {
 	// based on age.
 	MaxAge int `json:"maxage" yaml:"maxage"`
 
+	// MaxAgeDuration 以 time.Duration 表示的旧日志保留时长，例如 72h 或 30m，
+	// 适合需要比天更细粒度的场景。设置后优先于 MaxAge。
+	MaxAgeDuration time.Duration `json:"maxageduration" yaml:"maxageduration"`
+
+	// MaxAgeStr 以字符串表示旧日志的保留时长，除 time.ParseDuration 接受的
+	// 写法外还可以使用 "d"（天）和 "w"（周），例如 "30d"、"1w"、"1d12h"。
+	// 设置后优先于 MaxAgeDuration 与 MaxAge。
+	MaxAgeStr string `json:"maxagestr" yaml:"maxagestr"`
+
 	// MaxBackups is the maximum number of old log files to retain.  The default
 	// is to retain all old log files (though MaxAge may still cause them to get
 	// deleted.)
 	MaxBackups int `json:"maxbackups" yaml:"maxbackups"`
 
+	// TrashDir 设置后，按保留策略（MaxBackups、MaxAge、MaxTotalSize 等）过期的
+	// 备份不再直接删除，而是移入该目录，给运维人员留出找回误删日志的时间。
+	// 相对路径相对于日志文件所在目录。为释放空间（MinDiskFree）而删除的备份
+	// 仍然直接删除。默认为空，即直接删除。
+	TrashDir string `json:"trashdir" yaml:"trashdir"`
+
+	// TrashTTL 是备份在 TrashDir 中保留的时长，从移入时开始计算，到期后在
+	// 下一次清理时删除。默认为 0，即不自动清理回收目录。
+	TrashTTL time.Duration `json:"trashttl" yaml:"trashttl"`
+
+	// AuditFile 设置后，每次轮转、压缩（含加密、打包和 Processors）、上传、
+	// 移入 TrashDir 和删除文件时向该文件追加一行 JSON 审计记录（见
+	// AuditRecord），用于向审计方证明日志何时被销毁。相对路径相对于日志文件
+	// 所在目录。审计文件不会被轮转，也不参与备份清理。默认为空，即不记录。
+	AuditFile string `json:"auditfile" yaml:"auditfile"`
+
+	// Manifest 设置后，每次轮转后的后台处理和 Purge 结束时把全部备份的清单
+	// （见 Manifest 类型）以 JSON 写入该文件，包括大小、SHA-256、压缩算法和
+	// 上传状态，供工具读取而无需解析文件名。文件通过重命名原子替换。相对路径
+	// 相对于日志文件所在目录；多个 Logger 不能共用同一个清单文件。默认为空，
+	// 即不维护清单。
+	Manifest string `json:"manifest" yaml:"manifest"`
+
+	// KeepDaily、KeepWeekly 和 KeepMonthly 启用祖父-父-子（GFS）保留策略：
+	// 分别保留最近 N 天、M 周（ISO 周）、K 个月中每个周期最新的一个备份，
+	// 不属于任何一层的备份被删除。例如 7、4、12 表示保留最近一周的每日备份、
+	// 最近一个月的每周备份和最近一年的每月备份。日期按 LocalTime 决定的时区
+	// 计算。可以与 MaxBackups、MaxAge 等同时使用，备份被任一规则删除即删除。
+	// 都为 0 时不启用。
+	KeepDaily   int `json:"keepdaily" yaml:"keepdaily"`
+	KeepWeekly  int `json:"keepweekly" yaml:"keepweekly"`
+	KeepMonthly int `json:"keepmonthly" yaml:"keepmonthly"`
+
+	// ThinAfter 启用按天稀疏化：时间戳早于该时长的备份每个自然日只保留一个，
+	// 其余删除，适合轮转频繁、又需要保留每日记录的服务。例如 48 * time.Hour
+	// 表示最近两天的备份全部保留，更早的每天只留一个。默认为 0，即不稀疏化。
+	ThinAfter time.Duration `json:"thinafter" yaml:"thinafter"`
+
+	// ThinKeep 指定稀疏化时每天保留的备份："first"（默认）保留当天最早的，
+	// "last" 保留当天最新的
+	ThinKeep string `json:"thinkeep" yaml:"thinkeep"`
+
+	// MaxTotalSize 是当前日志文件与所有备份文件合计占用的最大空间（单位字节）。
+	// 超出时从最旧的备份开始删除，直到总量回到预算以内。默认为 0，即不限制。
+	MaxTotalSize int64 `json:"maxtotalsize" yaml:"maxtotalsize"`
+
+	// MinDiskFree 是日志所在卷需要保留的最小剩余空间（单位 MB）。写入前会
+	// 定期检查剩余空间，低于该值时从最旧的备份开始删除；删除全部备份后仍不足
+	// 则拒绝写入并返回错误（设置 DropOnDiskPressure 时丢弃写入），直到空间
+	// 恢复。默认为 0，即不检查。
+	MinDiskFree int `json:"mindiskfree" yaml:"mindiskfree"`
+
+	// DiskUsageWatermark 是日志所在卷的使用率水位（百分比，例如 90 表示 90%，
+	// 按当前用户可用的剩余空间计算）。使用率超过水位时从最旧的备份开始删除，
+	// 直到回到水位以下或没有备份可删。仍超过水位时默认继续写入，设置
+	// DropOnDiskPressure 时丢弃写入。默认为 0，即不检查。
+	DiskUsageWatermark float64 `json:"diskusagewatermark" yaml:"diskusagewatermark"`
+
+	// DropOnDiskPressure 为 true 时，删除全部备份后剩余空间仍低于 MinDiskFree
+	// 或使用率仍超过 DiskUsageWatermark 的期间丢弃写入（Write 返回成功），而
+	// 不是返回错误，被丢弃的写入数计入 Stats 的 DiskDropped，空间恢复后
+	// 自动继续写入。默认为 false。
+	DropOnDiskPressure bool `json:"dropondiskpressure" yaml:"dropondiskpressure"`
+
+	// DiskCheckInterval 是检查 MinDiskFree 与 DiskUsageWatermark 的间隔：写入时
+	// 距上次检查超过该间隔才重新检查。设置后还会由后台 goroutine 按该间隔检查，
+	// 写入停止时也能及时清理备份、解除暂停。默认为 0，即只在写入时检查，且
+	// 每秒最多一次。
+	DiskCheckInterval time.Duration `json:"diskcheckinterval" yaml:"diskcheckinterval"`
+
+	// PerProcessFilename 为 true 时在日志文件名中注入进程号，例如 app.log 变为
+	// app-1234.log，使多个进程可以共用同一份配置而不争用同一个文件。备份按
+	// 实例文件名生成（app-1234-<timestamp>.log），清理时按所有实例的备份合并
+	// 计算 MaxBackups、MaxAge 与 MaxTotalSize，因此即使进程重启后进程号变化，
+	// 旧实例的备份也会被清理。合并清理仅适用于默认的备份命名，设置
+	// FilenamePattern 或 NumberedBackups 时每个实例只管理自己的备份。
+	PerProcessFilename bool `json:"perprocessfilename" yaml:"perprocessfilename"`
+
+	// InstanceID 是注入日志文件名的实例标识，用于替代进程号（例如容器名或
+	// 副本序号），设置后即启用 PerProcessFilename 的行为。路径分隔符会被替换为 "_"。
+	InstanceID string `json:"instanceid" yaml:"instanceid"`
+
+	// ReopenCheckInterval 是检查日志文件是否被外部重命名或删除（如 logrotate、
+	// 容器中的日志收集 sidecar）的间隔。写入时若距上次检查已超过该间隔，比较
+	// 打开的文件与日志文件路径是否仍为同一文件，不是则在原路径重新打开，避免
+	// 一直写入已被移走或删除的文件。默认为 0，即不检查。
+	ReopenCheckInterval time.Duration `json:"reopencheckinterval" yaml:"reopencheckinterval"`
+
+	// InterProcessLock 为 true 时，写入与轮转期间持有日志文件旁 .lock 文件上
+	// 的进程间排他锁（Unix 上为 flock，Windows 上为 LockFileEx），并在持锁后
+	// 按磁盘上的实际大小判断是否需要轮转、发现其他进程已轮转时重新打开文件，
+	// 使多个进程追加同一日志文件时不会重复轮转或覆盖彼此的备份。每次写入都要
+	// 加锁并 stat 文件，有一定开销。MaxLines 仍只统计本进程写入的行数。
+	// 不支持文件锁的平台上不生效。默认为 false。
+	InterProcessLock bool `json:"interprocesslock" yaml:"interprocesslock"`
+
+	// NamedMutex 为 true 时，在 Windows 上改用由日志文件绝对路径派生的命名
+	// 互斥量（Global\lumberjack-<hash>）协调多个进程，语义与 InterProcessLock
+	// 相同，不需要在日志目录中创建 .lock 文件，适合多个服务写同一日志文件的
+	// 场景。其他平台上等同于 InterProcessLock。默认为 false。
+	NamedMutex bool `json:"namedmutex" yaml:"namedmutex"`
+
 	// LocalTime determines if the time used for formatting the timestamps in
 	// backup files is the computer's local time.  The default is to use UTC
-	// time.
+	// time. 设置 Location 或 TimeZone 时忽略 LocalTime。
 	LocalTime bool `json:"localtime" yaml:"localtime"`
 
+	// Location 指定备份文件名中的时间戳、按日期分区与打包、GFS 保留以及
+	// RotateDaily、RotateSchedule 使用的时区，例如服务器运行在 UTC 而保留策略
+	// 按业务所在地时间定义的场景。优先于 TimeZone 和 LocalTime。
+	Location *time.Location `json:"-" yaml:"-"`
+
+	// TimeZone 以 IANA 时区名（例如 "America/New_York"）指定与 Location 相同
+	// 的时区，便于在配置文件中设置。名称无法加载时使用 UTC，Validate 会报告
+	// 该错误。
+	TimeZone string `json:"timezone" yaml:"timezone"`
+
+	// Clock 是 Logger 使用的时钟，决定备份时间戳、MaxAge 清理以及定时轮转、
+	// 定期同步、压缩流刷新、磁盘检查和后台重试的计时器，便于在测试中模拟跨天
+	// 和文件老化而无需等待。为 nil 时使用系统时钟。
+	Clock Clock `json:"-" yaml:"-"`
+
+	// StreamCompress 为 true 时当前日志文件本身以 gzip 格式写入，磁盘上的文件
+	// 名为日志文件名加 ".gz"（例如 app.log.gz），轮转后直接成为压缩的备份
+	// （app-<timestamp>.log.gz），适合日志量极大的服务降低当前文件占用的空间。
+	// 压缩流每隔 StreamFlushInterval 以及 Flush、Sync 时刷新一次，刷新后的内容
+	// 即使文件尚未写完也能用 zcat 等工具读取。MaxSize 与 MaxLines 按解压后的
+	// 内容计算；重新打开已有的文件时需要完整解压一遍以得到其大小，文件不完整
+	// （例如进程崩溃）时先将其轮转。不能与 InterProcessLock、NamedMutex 和
+	// Seal 同时使用。CurrentFile、Follow 和清单使用带 ".gz" 的文件名，Follow
+	// 读到的是压缩后的数据。默认为 false。
+	StreamCompress bool `json:"streamcompress" yaml:"streamcompress"`
+
+	// StreamFlushInterval 是 StreamCompress 模式下两次刷新压缩流的最长间隔，
+	// 间隔越短越能及时读到新内容，但压缩率越低。默认为 1 秒。
+	StreamFlushInterval time.Duration `json:"streamflushinterval" yaml:"streamflushinterval"`
+
+	// BufferSize 是写入缓冲区的大小（字节）。设置后 Write 先写入内存缓冲区，
+	// 缓冲区满、调用 Flush、轮转或 Close 时才写入文件，以减少每秒大量短小
+	// 日志行带来的系统调用开销。进程崩溃时缓冲区中的数据会丢失。按缓冲前的
+	// 字节数判断 MaxSize。启用 InterProcessLock 或 NamedMutex 时每次写入后都会
+	// 刷新。默认为 0，即不缓冲。
+	BufferSize int `json:"buffersize" yaml:"buffersize"`
+
+	// AsyncQueue 是异步写入队列的长度。设置后 Write 把数据复制到队列后立即
+	// 返回，由后台 goroutine 写入文件，写入错误通过 Errors 上报，Flush 与
+	// Close 会等待队列中的数据写完。默认为 0，即同步写入。
+	AsyncQueue int `json:"asyncqueue" yaml:"asyncqueue"`
+
+	// DropPolicy 决定异步队列已满时的处理方式：DropBlock（默认）阻塞 Write，
+	// DropNewest 丢弃本次写入，DropOldest 丢弃队列中最早的写入。对延迟敏感的
+	// 场景（如游戏服务器的逻辑帧）宁可丢弃日志也不能阻塞。被丢弃的写入数记录
+	// 在 Stats 的 DroppedWrites 中。
+	DropPolicy DropPolicy `json:"droppolicy" yaml:"droppolicy"`
+
+	// MaxBytesPerSecond 是写入日志文件的最大速率（字节/秒），以容量为一秒
+	// 额度的令牌桶实现，避免失控的调试循环占满与数据库等延迟敏感服务共用的
+	// 磁盘 IOPS。超出额度时按 RateLimitMode 处理。启用 AsyncQueue 时限制的是
+	// 后台写入文件的速率，队列写满后再按 DropPolicy 处理。默认为 0，即不限速。
+	MaxBytesPerSecond int64 `json:"maxbytespersecond" yaml:"maxbytespersecond"`
+
+	// RateLimitMode 决定写入超过 MaxBytesPerSecond 时的处理方式：
+	// RateLimitBlock（默认）阻塞 Write 直到有足够额度；RateLimitDrop 丢弃本次
+	// 写入并返回成功，被丢弃的写入数计入 Stats 的 DroppedWrites。
+	RateLimitMode RateLimitMode `json:"ratelimitmode" yaml:"ratelimitmode"`
+
+	// Fallback 是写入日志文件失败（磁盘已满、失去写权限等）时的备用输出，
+	// 例如 os.Stderr。失败的写入改写入 Fallback，之后每秒重试一次日志文件，
+	// 成功后自动切回，期间的日志不会丢失。切换到 Fallback 时通过 Errors 上报
+	// 文件写入错误。默认为 nil，即直接返回写入错误。
+	Fallback io.Writer `json:"-" yaml:"-"`
+
+	// Mirror 是额外的输出，每次写入的数据在写入日志文件后也写入 Mirror，例如
+	// 容器环境中的 os.Stdout 或一个 net.Conn。写入 Mirror 失败不影响 Write 的
+	// 返回值，错误通过 Errors 上报。Flush 时若 Mirror 实现了 Flush() error 也会
+	// 调用。Close 不会关闭 Mirror。默认为 nil。
+	Mirror io.Writer `json:"-" yaml:"-"`
+
+	// SyncPolicy 决定何时 fsync 日志文件：SyncNever（默认）交给操作系统；
+	// SyncAlways 每次写入后 fsync；SyncPeriodic 在距上次 fsync 超过 SyncEvery
+	// 或累计写入达到 SyncBytes 字节时 fsync（写入停止后后台 goroutine 仍会按
+	// SyncEvery 落盘）。审计日志等需要持久性保证的场景可使用后两者。设置为
+	// SyncAlways 或 SyncPeriodic 时，轮转和 Close 前也会 fsync。
+	SyncPolicy SyncPolicy `json:"syncpolicy" yaml:"syncpolicy"`
+
+	// SyncEvery 是 SyncPeriodic 策略下两次 fsync 的最长间隔
+	SyncEvery time.Duration `json:"syncevery" yaml:"syncevery"`
+
+	// SyncBytes 是 SyncPeriodic 策略下触发 fsync 的累计写入字节数
+	SyncBytes int `json:"syncbytes" yaml:"syncbytes"`
+
+	// Filesystem 是操作日志文件和备份使用的文件系统，默认为本地文件系统
+	// （OSFilesystem）。自定义的文件系统用于日志文件的创建、写入和轮转，以及
+	// 备份的扫描和按保留策略删除，不能与压缩、加密、上传等直接操作本地文件的
+	// 选项同时使用（见 Validate）；ReopenCheckInterval 和 Search、Follow、FS
+	// 等读取接口也只适用于本地文件系统。
+	Filesystem Filesystem `json:"-" yaml:"-"`
+
+	// Preallocate 为 true 时，新建日志文件后为其预留 MaxSize 字节的磁盘空间
+	// （Linux 上为 fallocate，macOS 上为 F_PREALLOCATE，Windows 上设置文件的
+	// 分配大小），文件大小不变。这样可以减少碎片，并在创建文件时而非写到一半
+	// 时发现磁盘空间不足。关闭或轮转文件时释放未用完的空间。文件系统不支持时
+	// 不生效。默认为 false。
+	Preallocate bool `json:"preallocate" yaml:"preallocate"`
+
+	// Header 返回写在每个新建日志文件（包括轮转后的新文件）开头的内容，例如
+	// 格式版本、主机名、构建信息，使每个备份都能被下游解析程序独立识别。在
+	// 持有 Logger 内部锁时调用，不能再写入该 Logger。返回的内容原样写入，通常
+	// 应以换行结尾，并计入 MaxSize 和 MaxLines。打开已存在的日志文件继续追加时
+	// 不写入。
+	Header func() []byte `json:"-" yaml:"-"`
+
+	// HeaderBytes 是固定的文件头，Header 为 nil 时使用，语义与 Header 相同
+	HeaderBytes []byte `json:"-" yaml:"-"`
+
+	// Seal 为 true 时，在日志文件被轮转前向其末尾追加一行 JSON 封存记录（见
+	// SealRecord），包含记录数、字节数、SHA-256 和封存时间，下游可以用
+	// VerifySeal 发现被截断或不完整的备份。Close 时不封存，文件重新打开后仍会
+	// 继续追加。默认为 false。
+	Seal bool `json:"seal" yaml:"seal"`
+
+	// WriteThroughRename 为 true 时，Windows 上轮转改用
+	// MoveFileEx(MOVEFILE_REPLACE_EXISTING|MOVEFILE_WRITE_THROUGH) 重命名日志
+	// 文件和备份，重命名写入磁盘后才继续打开新文件，避免掉电后丢失重命名。
+	// 其他平台上不生效。默认为 false。
+	WriteThroughRename bool `json:"writethroughrename" yaml:"writethroughrename"`
+
+	// RetryPolicy 决定打开日志文件、轮转时重命名以及清理时删除备份失败后
+	// 是否重试，例如在 NFS、SMB 共享上使用 Backoff 应对暂时的 EBUSY/EACCES。
+	// 默认为 nil：Windows 上对文件被短暂占用的错误做少量重试，其他平台不重试。
+	RetryPolicy RetryPolicy `json:"-" yaml:"-"`
+
 	// Compress determines if the rotated log files should be compressed
 	// using gzip. The default is not to perform compression.
 	Compress bool `json:"compress" yaml:"compress"`
 
-	size int64
-	file *os.File
-	mu   sync.Mutex
+	// Compression 指定备份使用的压缩算法，可选 "gzip"（.gz）、"zstd"（.zst）、
+	// "zip"（.zip，Windows 资源管理器可直接打开）或用 RegisterCompressor 注册
+	// 的名称。设置后即启用压缩，无需再设置 Compress。默认为空，由 Compress
+	// 决定是否使用 gzip。
+	Compression string `json:"compression" yaml:"compression"`
+
+	// CompressionLevel 是压缩级别，含义与所选算法一致（gzip 和 zip 为 1-9，
+	// zstd 为 1-22）。默认为 0，即使用算法的默认级别。
+	CompressionLevel int `json:"compressionlevel" yaml:"compressionlevel"`
+
+	// Compressor 是自定义的压缩算法，设置后即启用压缩，并优先于 Compression
+	// 和 CompressionLevel。默认为 nil。
+	Compressor Compressor `json:"-" yaml:"-"`
+
+	// CompressWorkers 是该 Logger 同时压缩的备份文件数上限。所有 Logger 的
+	// 压缩任务还共享一个进程级的名额池，其大小由 SetCompressWorkers 设置。
+	// 默认为 1，即逐个压缩。
+	CompressWorkers int `json:"compressworkers" yaml:"compressworkers"`
+
+	// CompressSync 为 true 时在轮转过程中同步完成压缩与清理，Write 或 Rotate
+	// 返回时备份已经压缩完毕，适合写完日志后立即退出的批处理任务。代价是触发
+	// 轮转的那次调用会阻塞到压缩结束。默认为 false，即由后台 goroutine 处理。
+	CompressSync bool `json:"compresssync" yaml:"compresssync"`
+
+	// BundleDaily 为 true 时在轮转后的后台处理中，把今天之前的备份按日历日
+	// 打包为一个 name-YYYY-MM-DD.tar.gz（日期时区由 LocalTime 决定）并删除原
+	// 文件，减少归档到对象存储时的文件数量。归档在清理时视为一个备份，按其日期
+	// 计算 MaxAge；已打包的备份不再单独压缩。NumberedBackups 模式下不生效。
+	// 需要与其他后处理步骤排定先后时，改用 Processors 中的 BundleProcessor。
+	BundleDaily bool `json:"bundledaily" yaml:"bundledaily"`
+
+	// Checksum 为 true 时，压缩或打包后的备份在删除原文件前会从磁盘读回并与
+	// 写入时计算的 SHA-256 比对，不一致则保留原文件并返回错误；校验通过后在
+	// 备份旁写入 sha256sum 格式的 .sha256 文件，可用 VerifyChecksum 或
+	// sha256sum -c 在上传、审计前再次校验。旁路文件随备份一起删除。
+	Checksum bool `json:"checksum" yaml:"checksum"`
+
+	// Encrypt 为 true 时在轮转后的后台处理中用 AES-256-GCM 加密备份（在压缩
+	// 之后进行），生成 .enc 文件并删除明文。密钥由 EncryptionKey 或
+	// EncryptionKeyFile 提供，可用 DecryptFile 或 NewDecryptReader 解密。
+	Encrypt bool `json:"encrypt" yaml:"encrypt"`
+
+	// EncryptionKey 是 32 字节的加密密钥。为避免密钥随配置文件泄露，
+	// 该字段不参与 JSON/YAML 序列化。
+	EncryptionKey []byte `json:"-" yaml:"-"`
+
+	// EncryptionKeyFile 是密钥文件路径，文件内容为 32 字节原始密钥或 64 个
+	// 十六进制字符。仅在 EncryptionKey 为空时使用。
+	EncryptionKeyFile string `json:"encryptionkeyfile" yaml:"encryptionkeyfile"`
+
+	// AgeRecipients 是 age 公钥列表（age1... 或 ssh-ed25519/ssh-rsa 公钥）。
+	// 设置后在轮转后的后台处理中把备份加密给这些接收者（在压缩之后进行），
+	// 生成 .age 文件并删除明文，只有持有对应私钥的人才能用 age 命令或
+	// DecryptAgeFile 解密，适合无法在生产主机上保存对称密钥的场景。
+	// 不能与 Encrypt 同时使用。
+	AgeRecipients []string `json:"agerecipients" yaml:"agerecipients"`
+
+	// Instrumentation 在轮转、清理、压缩、加密和打包前后被调用，用于把这些
+	// 操作上报为追踪 span 或指标。默认为 nil，即不上报。
+	Instrumentation Instrumentation `json:"-" yaml:"-"`
+
+	// OnRemove 在清理（MaxBackups、MaxAge、MaxTotalSize、MinDiskFree 等）删除
+	// 一个备份后调用，path 为被删除的文件。
+	OnRemove func(path string) `json:"-" yaml:"-"`
+
+	// OnCompress 在备份 src 被压缩、加密或打包为 dst 且 src 已被删除后调用。
+	// 打包时每个被归档的备份各调用一次，dst 为归档文件。
+	//
+	// OnRemove 和 OnCompress 在后台 goroutine（设置 CompressSync 时在轮转过程中）
+	// 调用，可能并发执行，不能调用该 Logger 的方法，也不应长时间阻塞。
+	OnCompress func(src, dst string) `json:"-" yaml:"-"`
+
+	// Processors 是轮转后处理流水线，每次轮转产生的备份在后台依次经过其中
+	// 的处理器，例如：
+	//
+	//	[]Processor{CompressProcessor("zstd", 0), EncryptProcessor(key),
+	//		UploadProcessor(u), DeleteProcessor()}
+	//
+	// 用于替代内置的压缩和加密，不能与 Compress、Encrypt、AgeRecipients 同时
+	// 使用。清理仍按 MaxBackups 等配置进行。默认为空。
+	Processors []Processor `json:"-" yaml:"-"`
+
+	// ProcessorRetries 是 Processors 中每一步失败后的重试次数，默认为 0，
+	// 即不重试。重试用完后该备份的后续步骤被放弃，错误通过 Errors 通道上报。
+	ProcessorRetries int `json:"processorretries" yaml:"processorretries"`
+
+	// ProcessorRetryDelay 是第一次重试前的等待时间，之后每次翻倍，默认为 1 秒
+	ProcessorRetryDelay time.Duration `json:"processorretrydelay" yaml:"processorretrydelay"`
+
+	// Uploader 在每次轮转产生的备份完成压缩和加密后把它上传到远端存储。上传
+	// 失败通过 Errors 通道上报，备份保留在本地并在下次后台处理时重试。
+	// 默认为 nil，即不上传。
+	Uploader Uploader `json:"-" yaml:"-"`
+
+	// UploadTarget 以 URL 指定内置的上传目标，例如 "gs://bucket/logs" 或
+	// "azblob://account/container/logs"，格式见 ParseUploadTarget。仅在
+	// Uploader 为 nil 时使用。
+	UploadTarget string `json:"uploadtarget" yaml:"uploadtarget"`
+
+	// UploadTimeout 是单个备份上传的最长时间，超时后放弃本次上传，备份保留在
+	// 本地并在下次后台处理时重试。默认为 5 分钟。Close 时正在进行的上传会被
+	// 立即取消（CloseContext 会等待它完成）。
+	UploadTimeout time.Duration `json:"uploadtimeout" yaml:"uploadtimeout"`
+
+	// PostRotateCommand 是每次轮转后执行的外部命令及其参数，相当于 logrotate
+	// 的 postrotate，例如 []string{"/usr/local/bin/ship-log", "{backup}"}。
+	// 参数中的 {backup} 会被替换为备份路径，命令还可以从环境变量
+	// LUMBERJACK_BACKUP 和 LUMBERJACK_LOGFILE 读取备份路径和日志文件路径。
+	// 命令在备份完成压缩、加密和上传后在后台执行（设置 CompressSync 时在轮转
+	// 过程中执行），退出码非零时连同输出通过 Errors 通道上报。默认为空。
+	PostRotateCommand []string `json:"postrotatecommand" yaml:"postrotatecommand"`
+
+	// EventLogSource 是 Windows 事件日志的来源名称。设置后，后台轮转、压缩、
+	// 清理等失败时除了投递到 Errors，还会以错误事件写入 Windows 事件日志，
+	// 便于运维人员发现没有控制台的 Windows 服务中的问题。来源应事先注册（例如
+	// 使用 golang.org/x/sys/windows/svc/eventlog.InstallAsEventCreate），否则
+	// 事件查看器中只能看到原始消息。其他平台上不生效。默认为空。
+	EventLogSource string `json:"eventlogsource" yaml:"eventlogsource"`
+
+	// DebugLogger 接收该 Logger 的调试日志（后台 goroutine 的启停、清理与压缩
+	// 失败等），多个 Logger 可以分别输出到不同目标。默认为 nil，此时只有通过
+	// 已废弃的 EnableDebugLog 全局启用后才输出到标准库的默认 logger。
+	DebugLogger *log.Logger `json:"-" yaml:"-"`
+
+	// RotationInterval 按时间轮转的周期，例如 24 * time.Hour 或 time.Hour。
+	// 轮转发生在时钟跨过该周期的整数倍时（以 UTC 零点为基准对齐），与文件大小
+	// 无关。默认为 0，即不按时间轮转。
+	RotationInterval time.Duration `json:"rotationinterval" yaml:"rotationinterval"`
+
+	// RotateSchedule 以 5 字段 cron 表达式（分 时 日 月 周，例如 "0 0 * * *"）
+	// 指定精确的轮转时间点，也支持 @daily、@hourly 等缩写。时间按 LocalTime
+	// 决定的时区计算。到点时后台 goroutine 会主动唤醒并轮转，无需等待下一次写入。
+	RotateSchedule string `json:"rotateschedule" yaml:"rotateschedule"`
+
+	// RotateDaily 为 true 时在每天零点轮转，零点按 LocalTime 决定的时区计算。
+	// 若进程停机期间跨过了零点，启动后的首次写入会先补做一次轮转。
+	RotateDaily bool `json:"rotatedaily" yaml:"rotatedaily"`
+
+	size  int64
+	lines int64 // 当前文件的行数，仅在 MaxLines > 0 时维护
+	file  File
+	buf   *bufio.Writer // 配置了 BufferSize 时包装 file 的缓冲区
+	mu    sync.Mutex
+
+	// StreamCompress 的压缩流相关字段
+	gz        *gzip.Writer // 写入 file 的 gzip 流
+	gzDirty   bool         // 上次刷新后是否写入过数据
+	gzFlushed time.Time    // 上次刷新压缩流的时间
+
+	// locker 启用 InterProcessLock 或 NamedMutex 时使用的进程间锁
+	locker procLocker
+
+	// rotateAt 当前文件的下一次定时轮转时间点，零值表示未配置定时轮转
+	rotateAt time.Time
+
+	// hourDir HourlyDirs 模式下当前日志文件所在的小时子目录（string），
+	// 后台 goroutine 会不持锁读取
+	hourDir atomic.Value
+
+	// filenameOverride SetFilename 设置的日志文件路径（string），优先于
+	// Filename，后台 goroutine 会不持锁读取
+	filenameOverride atomic.Value
+
+	// lastBackup 最近一次轮转产生的备份路径，没有产生备份时为空
+	lastBackup string
+
+	// 磁盘剩余空间检查相关字段
+	lastDiskCheck time.Time // 上一次检查剩余空间的时间
+	diskLow       bool      // 上一次检查时剩余空间是否低于 MinDiskFree
+	diskOver      bool      // 上一次检查时使用率是否超过 DiskUsageWatermark
+
+	// fsync 相关字段
+	unsynced int64     // 上次 fsync 后写入的字节数
+	lastSync time.Time // 上次 fsync 的时间
+
+	// 备用输出相关字段
+	fallbackActive bool      // 当前是否正在写入 Fallback
+	fallbackRetry  time.Time // 下一次重试日志文件的时间
+
+	// lastMoveCheck 上一次检查日志文件是否被外部移走的时间
+	lastMoveCheck time.Time
 
 	// 日志轮转后台处理相关字段
-	millCh    chan bool      // 后台处理任务通道
-	startMill sync.Once      // 确保后台 goroutine 只启动一次
-	done      chan struct{}  // 关闭信号通道，用于通知后台 goroutine 退出
-	millWg    sync.WaitGroup // 等待后台 goroutine 完全退出
-	closed    bool           // 标记 Logger 是否已关闭，防止重复关闭
+	millCh    chan bool          // 后台处理任务通道
+	idleCh    chan chan struct{} // WaitIdle 的请求通道，处理完已排队的任务后关闭收到的通道
+	startMill sync.Once          // 确保后台 goroutine 只启动一次
+	recovered sync.Once          // 确保只在首次打开文件时清理上次崩溃的残留文件
+	done      chan struct{}      // 关闭信号通道，用于通知后台 goroutine 退出
+	millCtx   context.Context    // 后台上传和 Processors 使用的 context，关闭时被取消
+	millStop  atomic.Value       // 取消 millCtx 的 context.CancelFunc，可以不持锁调用
+	millWg    sync.WaitGroup     // 等待后台 goroutine 完全退出
+	drain     atomic.Bool        // 关闭时是否先完成尚未执行的后台处理任务
+	closed    bool               // 标记 Logger 是否已关闭，防止重复关闭
+
+	// backupMu 串行化后台的压缩/清理与轮转时对备份的重命名，
+	// 避免序号命名模式下后台正在压缩的文件被轮转挪走
+	backupMu sync.Mutex
+
+	// 异步写入相关字段
+	asyncQ      chan asyncItem // 异步写入队列，首次异步写入时创建
+	asyncOnce   sync.Once      // 确保写入 goroutine 只启动一次
+	asyncMu     sync.RWMutex   // 保护 asyncClosed，防止向已关闭的队列发送
+	asyncClosed bool           // 异步队列是否已关闭
+	asyncWg     sync.WaitGroup // 等待写入 goroutine 退出
+
+	// ctxSlot 串行化 WriteContext 在后台 goroutine 中执行的写入，首次调用时创建
+	ctxSlot     chan struct{}
+	ctxSlotOnce sync.Once
+
+	// rate MaxBytesPerSecond 的令牌桶
+	rate rateLimiter
+
+	// 脱敏相关字段，由 RedactPatterns 和 RedactKeys 在首次使用时生成
+	redactOnce sync.Once
+	redact     Transform
+	redactErr  error
+
+	// metrics 运行期间的累计指标
+	metrics loggerMetrics
+
+	// 事件日志相关字段，由 eventMu 保护
+	eventMu     sync.Mutex
+	eventOpened bool      // 是否已尝试打开事件日志
+	events      eventSink // 已打开的事件日志
+
+	// 后台错误通道，首次调用 Errors 或上报错误时创建
+	errCh   chan error
+	errOnce sync.Once
+
+	// 上传相关字段
+	uploads        backupQueue // 等待上传的备份（轮转时的路径）
+	uploadMu       sync.Mutex  // 保护 targetUploader
+	targetUploader Uploader    // 由 UploadTarget 创建的上传目标，创建成功后不再重建
+
+	// postRotate 等待执行 PostRotateCommand 的备份
+	postRotate backupQueue
+
+	// processing 等待经过 Processors 的备份
+	processing backupQueue
+
+	// uploaded 已上传成功、尚未写入清单的备份
+	uploaded backupQueue
 }
 
 var (
@@ -136,8 +722,18 @@ var (
 	debugLog = false
 )
 
-// logDebug 输出调试日志，仅在 debugLog 为 true 时生效
-func logDebug(format string, args ...interface{}) {
+// now 返回当前时间，轮转时间戳、MaxAge 清理和定时轮转都以此为准
+func (l *Logger) now() time.Time {
+	return l.clock().Now()
+}
+
+// logDebug 输出调试日志。设置了 DebugLogger 时写入 DebugLogger，否则仅在
+// 通过 EnableDebugLog 全局启用时写入标准库的默认 logger。
+func (l *Logger) logDebug(format string, args ...interface{}) {
+	if l.DebugLogger != nil {
+		l.DebugLogger.Printf("[lumberjack-debug] "+format, args...)
+		return
+	}
 	if debugLog {
 		log.Printf("[lumberjack-debug] "+format, args...)
 	}
@@ -145,6 +741,9 @@ func logDebug(format string, args ...interface{}) {
 
 // EnableDebugLog 启用调试日志输出，用于排查 goroutine 泄露等问题
 // 在生产环境中应谨慎使用，因为会产生额外的日志输出
+//
+// Deprecated: 全局开关会同时影响进程内所有 Logger，请改为设置
+// Logger.DebugLogger，按实例输出和过滤调试日志。
 func EnableDebugLog(enable bool) {
 	debugLog = enable
 	if enable {
@@ -156,13 +755,55 @@ func EnableDebugLog(enable bool) {
 // than MaxSize, the file is closed, renamed to include a timestamp of the
 // current time, and a new log file is created using the original log file name.
 // If the length of the write is greater than MaxSize, an error is returned.
+//
+// 设置了 AsyncQueue 时，Write 只把数据放入队列后立即返回，写入文件时的错误
+// 通过 Errors 上报。写入的数据先经过 Transforms 和脱敏，超过 MaxLineBytes 时
+// 被截断，设置 AppendNewline 时补上末尾换行，成功时仍返回 len(p)。
 func (l *Logger) Write(p []byte) (n int, err error) {
+	q, err := l.prepareRecord(p)
+	if err != nil {
+		l.metrics.writeErrors.Add(1)
+		return 0, err
+	}
+	n, err = l.writeRaw(q)
+	return recordN(p, q, n, err), err
+}
+
+// writeRaw 按是否启用 AsyncQueue 同步或异步写入 p，不做记录级的处理
+func (l *Logger) writeRaw(p []byte) (n int, err error) {
+	if l.AsyncQueue > 0 {
+		return l.writeAsync(context.Background(), p)
+	}
+	return l.write(p)
+}
+
+// write 同步写入当前文件，必要时先轮转
+func (l *Logger) write(p []byte) (n int, err error) {
+	return l.writeClaimed(p, nil)
+}
+
+// writeClaimed 与 write 相同，claim 非 nil 时在取得 l.mu 后调用它，返回 false
+// 表示调用方已放弃这次写入，此时不写入并返回 errAbandoned
+func (l *Logger) writeClaimed(p []byte, claim func() bool) (n int, err error) {
+	// 在获取 l.mu 之前限速，等待额度时不阻塞 Flush、Close 等操作
+	if !l.throttle(len(p)) {
+		return len(p), nil
+	}
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	if claim != nil && !claim() {
+		return 0, errAbandoned
+	}
+	defer func() {
+		if err != nil {
+			l.metrics.writeErrors.Add(1)
+		}
+	}()
 
 	// 检查 Logger 是否已关闭
 	if l.closed {
-		return 0, fmt.Errorf("logger is closed")
+		return 0, errClosed
 	}
 
 	writeLen := int64(len(p))
@@ -172,20 +813,73 @@ func (l *Logger) Write(p []byte) (n int, err error) {
 		)
 	}
 
+	if l.Fallback != nil {
+		n, err = l.writeOrFallback(p)
+	} else {
+		n, err = l.writeFile(p)
+	}
+	l.writeMirror(p)
+	return n, err
+}
+
+// writeFile 把 p 写入当前文件，必要时先打开或轮转。调用方必须持有 l.mu。
+func (l *Logger) writeFile(p []byte) (n int, err error) {
+	writeLen := int64(len(p))
+	drop, err := l.checkDiskFree()
+	if err != nil {
+		return 0, err
+	}
+	if drop {
+		l.metrics.diskDropped.Add(1)
+		return len(p), nil
+	}
+
+	unlock, err := l.lockShared()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
 	if l.file == nil {
 		if err = l.openExistingOrNew(len(p)); err != nil {
 			return 0, err
 		}
 	}
 
-	if l.size+writeLen > l.max() {
+	if err := l.checkMoved(); err != nil {
+		return 0, err
+	}
+
+	var writeLines int64
+	if l.MaxLines > 0 {
+		writeLines = int64(bytes.Count(p, []byte{'\n'}))
+	}
+
+	if l.size+writeLen > l.max() || l.rotationDue() || l.linesExceeded(writeLines) || l.hourChanged() {
 		if err := l.rotate(); err != nil {
 			return 0, err
 		}
 	}
 
-	n, err = l.file.Write(p)
+	n, err = l.output().Write(p)
+	if err == nil && l.interProcess() {
+		// 其他进程按磁盘上的大小判断轮转，释放锁前必须写入文件
+		err = l.flush()
+	}
 	l.size += int64(n)
+	l.metrics.bytesWritten.Add(int64(n))
+	if l.MaxLines > 0 {
+		l.lines += int64(bytes.Count(p[:n], []byte{'\n'}))
+	}
+	if l.gz != nil && n > 0 {
+		l.gzDirty = true
+		if err == nil {
+			err = l.flushStreamIfDue()
+		}
+	}
+	if err == nil {
+		err = l.syncAfterWrite(int64(n))
+	}
 
 	return n, err
 }
@@ -193,11 +887,14 @@ func (l *Logger) Write(p []byte) (n int, err error) {
 // Close implements io.Closer, and closes the current logfile.
 // 同时优雅关闭后台 goroutine，防止 goroutine 泄露
 func (l *Logger) Close() error {
+	// 先写完异步队列中的数据，后台写入需要获取 l.mu
+	l.closeAsync()
+
 	l.mu.Lock()
-	defer l.mu.Unlock()
 
 	// 防止重复关闭
 	if l.closed {
+		l.mu.Unlock()
 		return nil
 	}
 
@@ -206,10 +903,19 @@ func (l *Logger) Close() error {
 
 	// 关闭文件
 	err := l.close()
+	if lerr := l.closeLock(); err == nil {
+		err = lerr
+	}
+	l.mu.Unlock()
 
-	// 关闭后台 goroutine
+	// 关闭后台 goroutine。必须在释放锁之后等待，因为后台 goroutine
+	// 执行定时轮转时需要获取 l.mu
 	l.shutdownMill()
 
+	if eerr := l.closeEventLog(); err == nil {
+		err = eerr
+	}
+
 	return err
 }
 
@@ -218,29 +924,75 @@ func (l *Logger) close() error {
 	if l.file == nil {
 		return nil
 	}
+	// 先把缓冲区写入即将关闭的文件，缓冲区随文件一起丢弃
+	ferr := l.flush()
+	if serr := l.closeStream(); ferr == nil {
+		ferr = serr
+	}
+	if ferr == nil {
+		ferr = l.syncBeforeClose()
+	}
+	if ferr == nil && l.Preallocate {
+		ferr = l.releasePreallocated()
+	}
+	l.buf = nil
 	err := l.file.Close()
 	l.file = nil
+	if ferr != nil {
+		return ferr
+	}
 	return err
 }
 
+// releasePreallocated 把文件截断到实际大小，释放预分配但未使用的空间，
+// 避免备份文件占用 MaxSize 的磁盘空间
+func (l *Logger) releasePreallocated() error {
+	info, err := l.file.Stat()
+	if err != nil {
+		return fmt.Errorf("error getting log file info: %s", err)
+	}
+	if err := l.file.Truncate(info.Size()); err != nil {
+		return fmt.Errorf("can't release preallocated space: %s", err)
+	}
+	return nil
+}
+
+// drainMill 执行关闭前尚未处理的后台任务，仅由后台 goroutine 调用
+func (l *Logger) drainMill() {
+	select {
+	case <-l.millCh:
+		l.logDebug("关闭前执行剩余的清理任务，文件: %s", l.filename())
+		if err := l.millRunOnce(); err != nil {
+			l.reportError(OpCleanup, err)
+			l.logDebug("后台压缩与清理失败: %v，文件: %s", err, l.filename())
+		}
+	default:
+	}
+}
+
 // shutdownMill 优雅关闭后台处理 goroutine
 func (l *Logger) shutdownMill() {
 	// 如果 done channel 已经初始化，则关闭它来通知 goroutine 退出
 	if l.done != nil {
-		logDebug("开始关闭后台处理 goroutine，文件: %s", l.filename())
+		l.logDebug("开始关闭后台处理 goroutine，文件: %s", l.filename())
 
 		select {
 		case <-l.done:
 			// 已经关闭，无需重复操作
-			logDebug("后台处理 goroutine 已经关闭，文件: %s", l.filename())
+			l.logDebug("后台处理 goroutine 已经关闭，文件: %s", l.filename())
 		default:
 			close(l.done)
-			logDebug("发送关闭信号给后台处理 goroutine，文件: %s", l.filename())
+			l.logDebug("发送关闭信号给后台处理 goroutine，文件: %s", l.filename())
+		}
+		if !l.drain.Load() {
+			// 取消正在进行的上传，避免卡住的网络请求使 Close 无法返回
+			l.cancelMill()
 		}
 
 		// 等待后台 goroutine 完全退出
 		l.millWg.Wait()
-		logDebug("后台处理 goroutine 已完全退出，文件: %s", l.filename())
+		l.cancelMill()
+		l.logDebug("后台处理 goroutine 已完全退出，文件: %s", l.filename())
 	}
 }
 
@@ -252,86 +1004,306 @@ func (l *Logger) shutdownMill() {
 func (l *Logger) Rotate() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	unlock, err := l.lockShared()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	return l.rotate()
 }
 
+// RotateTo 把当前日志文件移动到 backupPath 并立即打开新的日志文件，适合按需
+// 把日志快照到指定目录。相对路径相对于日志文件所在目录，所在目录不存在时会
+// 自动创建；backupPath 已存在时返回错误。跨文件系统时改为复制后删除原文件。
+// 备份不在备份目录中或不符合命名规则时，不参与后续的压缩和清理。
+func (l *Logger) RotateTo(backupPath string) error {
+	if backupPath == "" {
+		return errors.New("empty backup path")
+	}
+	if !filepath.IsAbs(backupPath) {
+		backupPath = filepath.Join(l.dir(), backupPath)
+	}
+	if filepath.Clean(backupPath) == filepath.Clean(l.activeName()) {
+		return errors.New("backup path is the log file itself")
+	}
+	if _, err := l.stat(backupPath); err == nil {
+		return fmt.Errorf("backup path %s already exists", backupPath)
+	}
+	if err := l.fsys().MkdirAll(filepath.Dir(backupPath), l.dirMode()); err != nil {
+		return fmt.Errorf("can't make directories for backup: %s", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	unlock, err := l.lockShared()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return l.rotateTo(backupPath)
+}
+
+// RotateWithResult 与 Rotate 相同，并返回本次轮转产生的备份路径，便于立即交给
+// 上传等后续流程。设置了 CompressSync 时返回的是压缩、加密后的文件路径；否则
+// 返回轮转时的原始路径，后台压缩完成后该文件会被替换为带压缩后缀的文件。
+// 轮转前日志文件不存在（没有产生备份）时返回空串。
+func (l *Logger) RotateWithResult() (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	unlock, err := l.lockShared()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	if err := l.rotate(); err != nil {
+		return "", err
+	}
+	if l.lastBackup != "" && l.CompressSync {
+		return l.processedName(l.lastBackup), nil
+	}
+	return l.lastBackup, nil
+}
+
 // rotate closes the current file, moves it aside with a timestamp in the name,
 // (if it exists), opens a new file with the original filename, and then runs
 // post-rotation processing and removal.
 func (l *Logger) rotate() error {
+	return l.rotateTo("")
+}
+
+// rotateTo 轮转当前文件，target 非空时把当前文件移动到 target，
+// 否则按配置的命名规则生成备份路径
+func (l *Logger) rotateTo(target string) (err error) {
+	if target == "" && l.hourChanged() {
+		// 进入新的小时，换到新的子目录而不是轮转
+		return l.switchHour()
+	}
+	l.recovered.Do(l.recoverPartial)
+	end := l.startOp(OpRotate, l.filename())
+	defer func() { end(err) }()
 	if err := l.close(); err != nil {
 		return err
 	}
-	if err := l.openNew(); err != nil {
+	if l.Seal {
+		if err := l.seal(); err != nil {
+			return err
+		}
+	}
+	if err := l.openNewTo(target); err != nil {
 		return err
 	}
+	l.rotated()
+	if l.CompressSync {
+		// 同步执行压缩与清理，保证 Write/Rotate 返回时备份已处理完毕。
+		// 此时新文件已就绪，处理失败不影响本次写入，错误通过 Errors 通道上报。
+		if err := l.millRunOnce(); err != nil {
+			l.reportError(OpCleanup, err)
+			l.logDebug("同步压缩与清理失败: %v，文件: %s", err, l.filename())
+		}
+	}
 	l.mill()
 	return nil
 }
 
+// rotated 记录一次轮转，并把刚产生的备份 l.lastBackup 登记给后台处理
+func (l *Logger) rotated() {
+	l.metrics.rotations.Add(1)
+	l.metrics.lastRotation.Store(l.now().UnixNano())
+	if l.lastBackup != "" {
+		l.audit(AuditRotate, l.lastBackup, l.filename(), l.auditSize(l.lastBackup))
+		l.queueProcess(l.lastBackup)
+		l.queueUpload(l.lastBackup)
+		l.queuePostRotate(l.lastBackup)
+	}
+}
+
 // openNew opens a new log file for writing, moving any old log file out of the
 // way.  This methods assumes the file has already been closed.
 func (l *Logger) openNew() error {
-	err := os.MkdirAll(l.dir(), 0755)
+	return l.openNewTo("")
+}
+
+// moveToBackup 把当前文件 name 移动到 target，target 为空时按命名规则生成
+// 备份路径。返回备份的路径。
+func (l *Logger) moveToBackup(name, target string) (string, error) {
+	if target != "" {
+		if err := l.move(name, target); err != nil {
+			return "", fmt.Errorf("can't rename log file: %s", err)
+		}
+		return target, nil
+	}
+	dir := l.newBackupDir()
+	if err := l.fsys().MkdirAll(dir, l.dirMode()); err != nil {
+		return "", fmt.Errorf("can't make directories for backup: %s", err)
+	}
+	newname, err := l.newBackupName(dir, l.filename())
+	if err != nil {
+		return "", err
+	}
+	if l.StreamCompress {
+		// 压缩流已经结束，备份就是完整的 gzip 文件
+		newname += compressSuffix
+	}
+	if err := l.rename(name, newname); err != nil {
+		return "", fmt.Errorf("can't rename log file: %s", err)
+	}
+	return newname, nil
+}
+
+// openNewTo 与 openNew 相同，target 非空时把已有文件移动到 target
+func (l *Logger) openNewTo(target string) error {
+	err := l.fsys().MkdirAll(l.dir(), l.dirMode())
 	if err != nil {
 		return fmt.Errorf("can't make directories for new logfile: %s", err)
 	}
 
-	name := l.filename()
+	// 先校验定时轮转与权限配置，避免旧文件已被移走却无法打开新文件
+	rotateAt, err := l.nextRotation(l.now())
+	if err != nil {
+		return err
+	}
+	if err := l.checkACL(); err != nil {
+		return err
+	}
+	if err := l.checkLimits(); err != nil {
+		return err
+	}
+	if err := l.checkFilesystem(); err != nil {
+		return err
+	}
+	if err := l.checkStream(); err != nil {
+		return err
+	}
+
+	name := l.activeName()
 	mode := os.FileMode(0600)
-	info, err := osStat(name)
+	if l.FileMode != 0 {
+		mode = l.FileMode
+	}
+	l.lastBackup = ""
+	info, err := l.stat(name)
 	if err == nil {
 		// Copy the mode off the old logfile.
-		mode = info.Mode()
+		if l.FileMode == 0 {
+			mode = info.Mode()
+		}
 		// move the existing file
-		newname := backupName(name, l.LocalTime)
-		if err := renameFile(name, newname); err != nil {
-			return fmt.Errorf("can't rename log file: %s", err)
+		if l.lastBackup, err = l.moveToBackup(name, target); err != nil {
+			return err
 		}
 
 		// this is a no-op anywhere but linux
-		if err := chown(name, info); err != nil {
-			return err
+		if !l.customFS() {
+			if err := chown(name, mode, info); err != nil {
+				return err
+			}
 		}
 	}
 
 	// we use truncate here because this should only get called when we've moved
 	// the file ourselves. if someone else creates the file in the meantime,
 	// just wipe out the contents.
-	f, err := openFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if l.interProcess() {
+		// 其他进程会以追加方式打开同一文件，这里也必须追加写入，
+		// 否则按自己的文件偏移写入会覆盖其他进程写入的内容
+		flag |= os.O_APPEND
+	}
+	f, err := l.openLogFile(name, flag, mode)
 	if err != nil {
 		return fmt.Errorf("can't open new logfile: %s", err)
 	}
+	if err := l.applyOwner(name); err != nil {
+		f.Close()
+		return fmt.Errorf("can't chown new logfile: %s", err)
+	}
+	if err := l.applyACL(name, l.lastBackup); err != nil {
+		f.Close()
+		return fmt.Errorf("can't set ACL of new logfile: %s", err)
+	}
 	l.file = f
+	l.startStream()
+	if l.Preallocate {
+		// 预分配失败（例如文件系统不支持）不影响写入
+		if osf, ok := f.(*os.File); ok {
+			if err := preallocate(osf, l.max()); err != nil {
+				l.logDebug("预分配日志文件空间失败: %v，文件: %s", err, name)
+			}
+		}
+	}
 	l.size = 0
+	l.lines = 0
+	if err := l.writeHeader(); err != nil {
+		return err
+	}
+	l.rotateAt = rotateAt
+	l.updateSymlink()
 	return nil
 }
 
-// backupName creates a new filename from the given name, inserting a timestamp
-// between the filename and the extension, using the local time if requested
-// (otherwise UTC).
-func backupName(name string, local bool) string {
-	dir := filepath.Dir(name)
+// backupName creates a new filename in dir from the given name, inserting the
+// given timestamp between the filename and the extension.
+func backupName(dir, name, timestamp string) string {
 	filename := filepath.Base(name)
 	ext := filepath.Ext(filename)
 	prefix := filename[:len(filename)-len(ext)]
-	t := currentTime()
-	if !local {
-		t = t.UTC()
+	return filepath.Join(dir, fmt.Sprintf("%s-%s%s", prefix, timestamp, ext))
+}
+
+// moveFile 把 src 移动到 dst。重命名失败（例如跨文件系统）时改为复制后删除 src，
+// 复制过程中不会覆盖已存在的 dst。
+func moveFile(p RetryPolicy, src, dst string) error {
+	errRename := renameFileRetry(p, src, dst)
+	if errRename == nil {
+		return nil
 	}
 
-	timestamp := t.Format(backupTimeFormat)
-	return filepath.Join(dir, fmt.Sprintf("%s-%s%s", prefix, timestamp, ext))
+	in, err := os.Open(src)
+	if err != nil {
+		return errRename
+	}
+	defer in.Close()
+	fi, err := in.Stat()
+	if err != nil {
+		return errRename
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, fi.Mode())
+	if err != nil {
+		return errRename
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
 }
 
 // openExistingOrNew opens the logfile if it exists and if the current write
 // would not put it over MaxSize.  If there is no such file or the write would
 // put it over the MaxSize, a new file is created.
 func (l *Logger) openExistingOrNew(writeLen int) error {
+	l.pinHourDir()
+	l.recovered.Do(l.recoverPartial)
 	l.mill()
 
-	filename := l.filename()
-	info, err := osStat(filename)
+	if err := l.checkLimits(); err != nil {
+		return err
+	}
+	if err := l.checkFilesystem(); err != nil {
+		return err
+	}
+	if err := l.checkStream(); err != nil {
+		return err
+	}
+
+	filename := l.activeName()
+	info, err := l.stat(filename)
 	if os.IsNotExist(err) {
 		return l.openNew()
 	}
@@ -339,28 +1311,90 @@ func (l *Logger) openExistingOrNew(writeLen int) error {
 		return fmt.Errorf("error getting log file info: %s", err)
 	}
 
-	if info.Size()+int64(writeLen) >= l.max() {
+	size := info.Size()
+	var lines int64
+	if l.StreamCompress {
+		if size, lines, err = l.scanStream(filename); err != nil {
+			// 不完整的压缩流之后追加的内容无法解压，轮转后重新开始
+			l.logDebug("压缩日志文件不完整: %v，文件: %s", err, filename)
+			return l.rotate()
+		}
+	}
+
+	if size+int64(writeLen) >= l.max() {
 		return l.rotate()
 	}
 
-	file, err := openFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
+	// 以文件最后修改时间推算其所属的轮转周期，若进程停机期间已跨过轮转时间点，
+	// 则在启动时补做一次轮转
+	rotateAt, err := l.nextRotation(info.ModTime())
+	if err != nil {
+		return err
+	}
+	if !rotateAt.IsZero() && !l.now().Before(rotateAt) {
+		return l.rotate()
+	}
+
+	if l.MaxLines > 0 && !l.StreamCompress {
+		if lines, err = l.countLines(filename); err != nil {
+			return fmt.Errorf("error counting lines of log file: %s", err)
+		}
+	}
+
+	file, err := l.openLogFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
 		// if we fail to open the old log file for some reason, just ignore
 		// it and open a new log file.
 		return l.openNew()
 	}
 	l.file = file
-	l.size = info.Size()
+	l.startStream()
+	l.size = size
+	l.lines = lines
+	l.rotateAt = rotateAt
+	l.updateSymlink()
 	return nil
 }
 
+// linesExceeded 判断再写入 writeLines 行是否会使当前文件超过 MaxLines。
+// 空文件总是允许写入，避免单次写入超过 MaxLines 行时无限轮转。
+func (l *Logger) linesExceeded(writeLines int64) bool {
+	return l.MaxLines > 0 && l.lines > 0 && l.lines+writeLines > int64(l.MaxLines)
+}
+
+// countLines 统计已有日志文件中的换行符个数
+func (l *Logger) countLines(name string) (int64, error) {
+	f, err := l.fsys().OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var lines int64
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := f.Read(buf)
+		lines += int64(bytes.Count(buf[:n], []byte{'\n'}))
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
 // filename generates the name of the logfile from the current time.
 func (l *Logger) filename() string {
-	if l.Filename != "" {
-		return l.Filename
+	name := l.baseFilename()
+	if l.HourlyDirs {
+		name = l.hourlyFilename(name)
+	}
+	if id := l.instanceID(); id != "" {
+		ext := filepath.Ext(name)
+		name = name[:len(name)-len(ext)] + "-" + id + ext
 	}
-	name := filepath.Base(os.Args[0]) + "-lumberjack.log"
-	return filepath.Join(os.TempDir(), name)
+	return name
 }
 
 // millRunOnce performs compression and removal of stale log files.
@@ -368,16 +1402,102 @@ func (l *Logger) filename() string {
 // files are removed, keeping at most l.MaxBackups files, as long as
 // none of them are older than MaxAge.
 func (l *Logger) millRunOnce() error {
-	if l.MaxBackups == 0 && l.MaxAge == 0 && !l.Compress {
-		return nil
+	_, err := l.purge(time.Time{})
+	l.runProcessors()
+	l.uploadPending()
+	l.runPostRotate()
+	if errManifest := l.updateManifest(); err == nil {
+		err = errManifest
+	}
+	return err
+}
+
+// purge 按配置清理、打包、压缩和加密旧备份，before 非零时还会删除时间戳早于
+// before 的备份。返回被删除的备份路径。
+func (l *Logger) purge(before time.Time) (removed []string, err error) {
+	codec := l.compression()
+	bundle := l.BundleDaily && !l.NumberedBackups
+	if l.MaxBackups == 0 && l.maxAge() == 0 && l.MaxTotalSize == 0 && !l.hasGFS() && l.ThinAfter == 0 && l.TrashTTL == 0 && codec == "" && !bundle && !l.Encrypt && len(l.AgeRecipients) == 0 && before.IsZero() {
+		return nil, nil
 	}
 
+	end := l.startOp(OpCleanup, l.filename())
+	defer func() { end(err) }()
+
+	l.backupMu.Lock()
+	defer l.backupMu.Unlock()
+
 	files, err := l.oldLogFiles()
 	if err != nil {
-		return err
+		return nil, err
 	}
+	// 被保留的备份不参与清理、打包、压缩和加密
+	files = l.withoutHeld(files)
 
 	var compress, remove []logInfo
+	files, remove = l.planRemoval(files, before)
+
+	for _, f := range remove {
+		errRemove := l.expire(f.path())
+		if err == nil && errRemove != nil {
+			err = errRemove
+		}
+		if errRemove == nil {
+			l.metrics.removals.Add(1)
+			l.notifyRemove(f.path())
+			removed = append(removed, f.path())
+		}
+		l.pruneBackupDir(f.dir)
+	}
+	if errSweep := l.sweepTrash(); err == nil && errSweep != nil {
+		err = errSweep
+	}
+	if bundle {
+		var errBundle error
+		files, errBundle = l.bundleDaily(files)
+		if err == nil && errBundle != nil {
+			err = errBundle
+		}
+	}
+
+	comp, errComp := l.compressor()
+	if errComp != nil {
+		return removed, errComp
+	}
+	enc, errEnc := l.encrypter()
+	if errEnc != nil {
+		return removed, errEnc
+	}
+	for _, f := range files {
+		if (comp != nil && !isCompressed(f.Name())) || (enc != nil && !isEncrypted(f.Name())) {
+			compress = append(compress, f)
+		}
+	}
+
+	if len(compress) > 0 {
+		errCompress := l.processBackups(compress, comp, enc)
+		if err == nil && errCompress != nil {
+			err = errCompress
+		}
+	}
+
+	return removed, err
+}
+
+// planRemoval 按保留策略把 files（按时间从新到旧排列）划分为保留和删除两部分，
+// before 非零时还会删除时间戳早于 before 的备份。不修改任何文件。
+func (l *Logger) planRemoval(files []logInfo, before time.Time) (kept, remove []logInfo) {
+	if !before.IsZero() {
+		var remaining []logInfo
+		for _, f := range files {
+			if f.timestamp.Before(before) {
+				remove = append(remove, f)
+			} else {
+				remaining = append(remaining, f)
+			}
+		}
+		files = remaining
+	}
 
 	if l.MaxBackups > 0 && l.MaxBackups < len(files) {
 		preserved := make(map[string]bool)
@@ -386,7 +1506,7 @@ func (l *Logger) millRunOnce() error {
 			// Only count the uncompressed log file or the
 			// compressed log file, not both.
 			fn := f.Name()
-			fn = strings.TrimSuffix(fn, compressSuffix)
+			fn = trimCompressSuffix(fn)
 			preserved[fn] = true
 
 			if len(preserved) > l.MaxBackups {
@@ -397,9 +1517,8 @@ func (l *Logger) millRunOnce() error {
 		}
 		files = remaining
 	}
-	if l.MaxAge > 0 {
-		diff := time.Duration(int64(24*time.Hour) * int64(l.MaxAge))
-		cutoff := currentTime().Add(-1 * diff)
+	if maxAge := l.maxAge(); maxAge > 0 {
+		cutoff := l.now().Add(-1 * maxAge)
 
 		var remaining []logInfo
 		for _, f := range files {
@@ -411,30 +1530,61 @@ func (l *Logger) millRunOnce() error {
 		}
 		files = remaining
 	}
+	if l.ThinAfter > 0 {
+		var thinned []logInfo
+		files, thinned = l.applyThinning(files)
+		remove = append(remove, thinned...)
+	}
+	if l.hasGFS() {
+		var gfsRemove []logInfo
+		files, gfsRemove = l.applyGFS(files)
+		remove = append(remove, gfsRemove...)
+	}
+	if l.MaxTotalSize > 0 {
+		// 当前文件也计入预算，备份按从新到旧累加，超出预算的旧备份全部删除
+		budget := l.MaxTotalSize
+		var total int64
+		if info, err := l.stat(l.activeName()); err == nil {
+			total = info.Size()
+		}
 
-	if l.Compress {
+		var remaining []logInfo
 		for _, f := range files {
-			if !strings.HasSuffix(f.Name(), compressSuffix) {
-				compress = append(compress, f)
+			total += f.Size()
+			if total > budget {
+				remove = append(remove, f)
+			} else {
+				remaining = append(remaining, f)
 			}
 		}
+		files = remaining
 	}
+	return files, remove
+}
 
-	for _, f := range remove {
-		errRemove := os.Remove(filepath.Join(l.dir(), f.Name()))
-		if err == nil && errRemove != nil {
-			err = errRemove
-		}
-	}
-	for _, f := range compress {
-		fn := filepath.Join(l.dir(), f.Name())
-		errCompress := compressLogFile(fn, fn+compressSuffix)
-		if err == nil && errCompress != nil {
-			err = errCompress
-		}
+// Purge 立即按配置的保留策略（MaxBackups、MaxAge、MaxTotalSize 等）清理旧备份，
+// 并同步完成打包、压缩和加密，返回被删除的备份路径。清理通常只在轮转后进行，
+// 长时间没有轮转的服务可以定期调用 Purge 回收空间。
+func (l *Logger) Purge() ([]string, error) {
+	return l.purgeAndRecord(time.Time{})
+}
+
+// PurgeOlderThan 与 Purge 相同，并额外删除时间戳早于 t 的所有备份，
+// 不受 MaxBackups 等配置影响
+func (l *Logger) PurgeOlderThan(t time.Time) ([]string, error) {
+	if t.IsZero() {
+		return nil, errors.New("zero purge cutoff")
 	}
+	return l.purgeAndRecord(t)
+}
 
-	return err
+// purgeAndRecord 执行清理并更新清单文件
+func (l *Logger) purgeAndRecord(before time.Time) ([]string, error) {
+	removed, err := l.purge(before)
+	if errManifest := l.updateManifest(); err == nil {
+		err = errManifest
+	}
+	return removed, err
 }
 
 // millRun runs in a goroutine to manage post-rotation compression and removal
@@ -446,18 +1596,61 @@ func (l *Logger) millRun() {
 	// millWg.Add(1) 已在 mill() 中调用，这里只需确保退出时调用 Done()
 	defer l.millWg.Done() // 确保在退出时通知等待者
 
-	logDebug("后台处理 goroutine 启动，文件: %s", l.filename())
-	defer logDebug("后台处理 goroutine 退出，文件: %s", l.filename())
+	l.logDebug("后台处理 goroutine 启动，文件: %s", l.filename())
+	defer l.logDebug("后台处理 goroutine 退出，文件: %s", l.filename())
+
+	// 定时轮转计时器，每次处理完任务后按当前文件的轮转时间点重新设置
+	timer := l.newTimer(time.Hour)
+	defer timer.Stop()
+	l.scheduleRotation(timer)
+
+	// 按时间间隔 fsync 的计时器，未配置时不会被选中
+	syncT := l.syncTicker()
+	defer syncT.stop()
+
+	// StreamCompress 定期刷新压缩流的计时器，未配置时不会被选中
+	streamT := l.streamTicker()
+	defer streamT.stop()
+
+	// 定期检查磁盘空间的计时器，未配置时不会被选中
+	diskT := l.diskTicker()
+	defer diskT.stop()
 
 	for {
 		select {
 		case <-l.millCh:
 			// 收到处理任务信号，执行日志文件清理
-			logDebug("执行日志文件清理任务，文件: %s", l.filename())
-			_ = l.millRunOnce()
+			l.logDebug("执行日志文件清理任务，文件: %s", l.filename())
+			if err := l.millRunOnce(); err != nil {
+				l.reportError(OpCleanup, err)
+				l.logDebug("后台压缩与清理失败: %v，文件: %s", err, l.filename())
+			}
+			l.scheduleRotation(timer)
+		case <-timer.C():
+			// 到达定时轮转时间点
+			l.logDebug("到达定时轮转时间点，文件: %s", l.filename())
+			l.rotateIfDue()
+			l.scheduleRotation(timer)
+		case <-syncT.C():
+			syncT.next()
+			l.syncIfDirty()
+		case <-streamT.C():
+			streamT.next()
+			l.flushStreamIdle()
+		case <-diskT.C():
+			diskT.next()
+			l.watchDisk()
+		case reply := <-l.idleCh:
+			// 先完成已排队的任务再答复 WaitIdle
+			l.drainMill()
+			l.scheduleRotation(timer)
+			close(reply)
 		case <-l.done:
 			// 收到关闭信号，优雅退出 goroutine
-			logDebug("收到关闭信号，后台处理 goroutine 准备退出，文件: %s", l.filename())
+			l.logDebug("收到关闭信号，后台处理 goroutine 准备退出，文件: %s", l.filename())
+			if l.drain.Load() {
+				l.drainMill()
+			}
 			return
 		}
 	}
@@ -471,9 +1664,13 @@ func (l *Logger) mill() {
 	l.startMill.Do(func() {
 		// 初始化通道
 		l.millCh = make(chan bool, 1)
+		l.idleCh = make(chan chan struct{})
 		l.done = make(chan struct{})
+		ctx, stop := context.WithCancel(context.Background())
+		l.millCtx = ctx
+		l.millStop.Store(stop)
 
-		logDebug("初始化后台处理通道，准备启动 goroutine，文件: %s", l.filename())
+		l.logDebug("初始化后台处理通道，准备启动 goroutine，文件: %s", l.filename())
 		// 【修复数据竞态】在启动 goroutine 之前增加 WaitGroup 计数器
 		// 这样可以确保 shutdownMill() 中的 Wait() 不会在 goroutine 启动前返回
 		l.millWg.Add(1)
@@ -484,48 +1681,99 @@ func (l *Logger) mill() {
 
 	// 检查是否已关闭，避免向已关闭的 Logger 发送任务
 	if l.closed {
-		logDebug("Logger 已关闭，跳过后台处理任务，文件: %s", l.filename())
+		l.logDebug("Logger 已关闭，跳过后台处理任务，文件: %s", l.filename())
 		return
 	}
 
 	select {
 	case l.millCh <- true:
 		// 成功发送处理任务信号
-		logDebug("成功发送后台处理任务信号，文件: %s", l.filename())
+		l.logDebug("成功发送后台处理任务信号，文件: %s", l.filename())
 	default:
 		// 通道已满，跳过本次处理（避免阻塞）
-		logDebug("后台处理通道已满，跳过本次任务，文件: %s", l.filename())
+		l.logDebug("后台处理通道已满，跳过本次任务，文件: %s", l.filename())
 	}
 }
 
-// oldLogFiles returns the list of backup log files stored in the same
-// directory as the current log file, sorted by ModTime
+// oldLogFiles returns the list of backup log files stored in the backup
+// directory (by default the same directory as the current log file), sorted by
+// ModTime
 func (l *Logger) oldLogFiles() ([]logInfo, error) {
-	files, err := ioutil.ReadDir(l.dir())
+	if l.Compressor != nil && l.Compressor.Ext() != "" {
+		// 确保尚未压缩过任何备份时也能识别自定义后缀的备份
+		addCompressSuffix(l.Compressor.Ext())
+	}
+	dirs, err := l.backupDirs()
+	if err != nil {
+		return nil, err
+	}
+	pattern, err := l.filenamePattern()
 	if err != nil {
-		return nil, fmt.Errorf("can't read log file directory: %s", err)
+		return nil, err
 	}
 	logFiles := []logInfo{}
 
 	prefix, ext := l.prefixAndExt()
+	base := filepath.Base(l.filename())
+	family := l.instanceID() != ""
+	familyPrefix, _ := l.familyPrefixAndExt()
 
-	for _, f := range files {
-		if f.IsDir() {
-			continue
-		}
-		if t, err := l.timeFromName(f.Name(), prefix, ext); err == nil {
-			logFiles = append(logFiles, logInfo{t, f})
-			continue
+	for _, dir := range dirs {
+		entries, err := l.fsys().ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("can't read log file directory: %s", err)
 		}
-		if t, err := l.timeFromName(f.Name(), prefix, ext+compressSuffix); err == nil {
-			logFiles = append(logFiles, logInfo{t, f})
-			continue
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			f, err := e.Info()
+			if err != nil {
+				// 列出目录后被删除
+				continue
+			}
+			if l.NumberedBackups {
+				if n, ok := numberFromName(f.Name(), base); ok {
+					logFiles = append(logFiles, logInfo{f.ModTime(), n, dir, f})
+				}
+				continue
+			}
+			if t, ok := l.bundleTime(f.Name()); ok {
+				logFiles = append(logFiles, logInfo{t, 0, dir, f})
+				continue
+			}
+			if pattern != nil {
+				if t, seq, ok := pattern.parse(f.Name()); ok {
+					if t.IsZero() {
+						t = f.ModTime()
+					}
+					logFiles = append(logFiles, logInfo{t, seq, dir, f})
+				}
+				continue
+			}
+			if family {
+				if t, ok := l.familyTimeFromName(trimCompressSuffix(f.Name()), familyPrefix, ext); ok {
+					logFiles = append(logFiles, logInfo{t, 0, dir, f})
+				}
+				continue
+			}
+			if t, err := l.timeFromName(trimCompressSuffix(f.Name()), prefix, ext); err == nil {
+				logFiles = append(logFiles, logInfo{t, 0, dir, f})
+				continue
+			}
+			// error parsing means that the suffix at the end was not generated
+			// by lumberjack, and therefore it's not a backup file.
 		}
-		// error parsing means that the suffix at the end was not generated
-		// by lumberjack, and therefore it's not a backup file.
 	}
 
-	sort.Sort(byFormatTime(logFiles))
+	if l.NumberedBackups {
+		// 序号越小越新
+		sort.SliceStable(logFiles, func(i, j int) bool {
+			return logFiles[i].seq < logFiles[j].seq
+		})
+	} else {
+		sort.Sort(byFormatTime(logFiles))
+	}
 
 	return logFiles, nil
 }
@@ -541,22 +1789,62 @@ func (l *Logger) timeFromName(filename, prefix, ext string) (time.Time, error) {
 		return time.Time{}, errors.New("mismatched extension")
 	}
 	ts := filename[len(prefix) : len(filename)-len(ext)]
-	return time.Parse(backupTimeFormat, ts)
+	return parseBackupTime(l.timeFormat(), ts, l.location())
 }
 
 // max returns the maximum size in bytes of log files before rolling.
 func (l *Logger) max() int64 {
+	if l.MaxSizeStr != "" {
+		// 无法解析时由 checkLimits 报告错误
+		if n, err := parseSize(l.MaxSizeStr); err == nil && n > 0 {
+			return n
+		}
+	}
 	if l.MaxSize == 0 {
 		return int64(defaultMaxSize * megabyte)
 	}
 	return int64(l.MaxSize) * int64(megabyte)
 }
 
+// maxAge 返回旧日志的保留时长，MaxAgeDuration 优先于 MaxAge，
+// 返回 0 表示不按时间清理。
+func (l *Logger) maxAge() time.Duration {
+	if l.MaxAgeStr != "" {
+		// 无法解析时由 checkLimits 报告错误
+		if d, err := parseDuration(l.MaxAgeStr); err == nil {
+			return d
+		}
+	}
+	if l.MaxAgeDuration > 0 {
+		return l.MaxAgeDuration
+	}
+	return time.Duration(int64(24*time.Hour) * int64(l.MaxAge))
+}
+
+// dirMode 返回自动创建目录时使用的权限
+func (l *Logger) dirMode() os.FileMode {
+	if l.DirMode == 0 {
+		return 0755
+	}
+	return l.DirMode
+}
+
 // dir returns the directory for the current filename.
 func (l *Logger) dir() string {
 	return filepath.Dir(l.filename())
 }
 
+// backupDir 返回存放备份文件的目录
+func (l *Logger) backupDir() string {
+	if l.BackupDir == "" {
+		return l.dir()
+	}
+	if filepath.IsAbs(l.BackupDir) {
+		return l.BackupDir
+	}
+	return filepath.Join(l.dir(), l.BackupDir)
+}
+
 // prefixAndExt returns the filename part and extension part from the Logger's
 // filename.
 func (l *Logger) prefixAndExt() (prefix, ext string) {
@@ -566,9 +1854,50 @@ func (l *Logger) prefixAndExt() (prefix, ext string) {
 	return prefix, ext
 }
 
-// compressLogFile compresses the given log file, removing the
+// compressLogFile compresses the given log file with c, removing the
 // uncompressed log file if successful.
-func compressLogFile(src, dst string) (err error) {
+func compressLogFile(src, dst string, c Compressor, checksum bool) (err error) {
+	switch b := c.(type) {
+	case GzipCompressor:
+		return compressCodecFile(src, dst, compressionGzip, b.Level, checksum)
+	case codecCompressor:
+		return compressCodecFile(src, dst, b.codec, b.level, checksum)
+	}
+
+	fi, err := osStat(src)
+	if err != nil {
+		return fmt.Errorf("failed to stat log file: %v", err)
+	}
+	if err := chown(dst, fi.Mode(), fi); err != nil {
+		return fmt.Errorf("failed to chown compressed log file: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(dst)
+			err = fmt.Errorf("failed to compress log file: %v", err)
+		}
+	}()
+	// 自定义算法自行读写文件，无法在写入时计算校验和，只能在写完后读回计算
+	if err := c.Compress(src, dst); err != nil {
+		return err
+	}
+	if checksum {
+		sum, err := fileChecksum(dst)
+		if err != nil {
+			return err
+		}
+		if err := writeChecksum(dst, sum); err != nil {
+			return err
+		}
+	}
+	if err := os.Chtimes(dst, fi.ModTime(), fi.ModTime()); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// compressCodecFile 以内置算法 codec 压缩 src，成功后删除 src
+func compressCodecFile(src, dst, codec string, level int, checksum bool) (err error) {
 	f, err := os.Open(src)
 	if err != nil {
 		return fmt.Errorf("failed to open log file: %v", err)
@@ -580,7 +1909,7 @@ func compressLogFile(src, dst string) (err error) {
 		return fmt.Errorf("failed to stat log file: %v", err)
 	}
 
-	if err := chown(dst, fi); err != nil {
+	if err := chown(dst, fi.Mode(), fi); err != nil {
 		return fmt.Errorf("failed to chown compressed log file: %v", err)
 	}
 
@@ -592,8 +1921,6 @@ func compressLogFile(src, dst string) (err error) {
 	}
 	defer gzf.Close()
 
-	gz := gzip.NewWriter(gzf)
-
 	defer func() {
 		if err != nil {
 			os.Remove(dst)
@@ -601,6 +1928,12 @@ func compressLogFile(src, dst string) (err error) {
 		}
 	}()
 
+	// 启用校验时同时计算写入内容的 SHA-256，写完后与磁盘上的内容比对
+	h := sha256.New()
+	gz, err := newCompressWriter(io.MultiWriter(gzf, h), codec, level, fi)
+	if err != nil {
+		return err
+	}
 	if _, err := io.Copy(gz, f); err != nil {
 		return err
 	}
@@ -610,10 +1943,23 @@ func compressLogFile(src, dst string) (err error) {
 	if err := gzf.Close(); err != nil {
 		return err
 	}
+	if checksum {
+		sum := h.Sum(nil)
+		if err := verifyWritten(dst, sum); err != nil {
+			return err
+		}
+		if err := writeChecksum(dst, sum); err != nil {
+			return err
+		}
+	}
 
 	if err := f.Close(); err != nil {
 		return err
 	}
+	// 保留原文件的修改时间，按修改时间排序的备份在压缩后顺序不变
+	if err := os.Chtimes(dst, fi.ModTime(), fi.ModTime()); err != nil {
+		return err
+	}
 	if err := os.Remove(src); err != nil {
 		return err
 	}
@@ -625,13 +1971,27 @@ func compressLogFile(src, dst string) (err error) {
 // timestamp.
 type logInfo struct {
 	timestamp time.Time
+	seq       int    // FilenamePattern 中 {seq} 的值，没有时为 0
+	dir       string // 备份文件所在目录
 	os.FileInfo
 }
 
+// path 返回备份文件的完整路径
+func (f logInfo) path() string {
+	return filepath.Join(f.dir, f.Name())
+}
+
 // byFormatTime sorts by newest time formatted in the name.
 type byFormatTime []logInfo
 
 func (b byFormatTime) Less(i, j int) bool {
+	if b[i].timestamp.Equal(b[j].timestamp) {
+		// 时间戳相同（例如时间格式精度不足）时，依次按序号和修改时间区分新旧
+		if b[i].seq != b[j].seq {
+			return b[i].seq > b[j].seq
+		}
+		return b[i].ModTime().After(b[j].ModTime())
+	}
 	return b[i].timestamp.After(b[j].timestamp)
 }
 
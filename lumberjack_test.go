@@ -6,8 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -200,6 +202,43 @@ func TestFirstWriteRotate(t *testing.T) {
 	fileCount(dir, 2, t)
 }
 
+func TestMaxLines(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestMaxLines", t)
+	defer os.RemoveAll(dir)
+
+	// 启动时已有一行
+	filename := logFile(dir)
+	start := []byte("one\n")
+	isNil(ioutil.WriteFile(filename, start, 0644), t)
+
+	l := &Logger{
+		Filename: filename,
+		MaxSize:  100,
+		MaxLines: 3,
+	}
+	defer l.Close()
+	b := []byte("two\nthree\n")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+	existsWithContent(filename, append(start, b...), t)
+	fileCount(dir, 1, t)
+
+	newFakeTime()
+
+	// 第四行会超过 MaxLines，先轮转再写入
+	b2 := []byte("four\n")
+	n, err = l.Write(b2)
+	isNil(err, t)
+	equals(len(b2), n, t)
+	existsWithContent(filename, b2, t)
+	existsWithContent(backupFile(dir), append(start, b...), t)
+	fileCount(dir, 2, t)
+}
+
 func TestMaxBackups(t *testing.T) {
 	currentTime = fakeTime
 	megabyte = 1
@@ -286,19 +325,20 @@ func TestMaxBackups(t *testing.T) {
 	// this will use the new fake time
 	fourthFilename := backupFile(dir)
 
-	// Create a log file that is/was being compressed - this should
-	// not be counted since both the compressed and the uncompressed
-	// log files still exist.
-	compLogFile := fourthFilename + compressSuffix
-	err = ioutil.WriteFile(compLogFile, []byte("compress"), 0644)
-	isNil(err, t)
-
 	// this will make us rotate again
 	b4 := []byte("baaaaaaz!")
 	n, err = l.Write(b4)
 	isNil(err, t)
 	equals(len(b4), n, t)
 
+	// Create a log file that is/was being compressed - this should
+	// not be counted since both the compressed and the uncompressed
+	// log files still exist. It is created after the rotation, since an
+	// existing compressed file makes the rotation pick another name.
+	compLogFile := fourthFilename + compressSuffix
+	err = ioutil.WriteFile(compLogFile, []byte("compress"), 0644)
+	isNil(err, t)
+
 	existsWithContent(fourthFilename, b3, t)
 	existsWithContent(fourthFilename+compressSuffix, []byte("compress"), t)
 
@@ -448,6 +488,80 @@ func TestMaxAge(t *testing.T) {
 	existsWithContent(backupFile(dir), b2, t)
 }
 
+func TestMaxAgeDuration(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestMaxAgeDuration", t)
+	defer os.RemoveAll(dir)
+
+	// 一个 2 小时前的备份和一个 30 分钟前的备份
+	data := []byte("data")
+	stale := filepath.Join(dir, "foobar-"+fakeTime().Add(-2*time.Hour).UTC().Format(backupTimeFormat)+".log")
+	fresh := filepath.Join(dir, "foobar-"+fakeTime().Add(-30*time.Minute).UTC().Format(backupTimeFormat)+".log")
+	isNil(ioutil.WriteFile(stale, data, 0644), t)
+	isNil(ioutil.WriteFile(fresh, data, 0644), t)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:       filename,
+		MaxAge:         30, // MaxAgeDuration 优先
+		MaxAgeDuration: time.Hour,
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+
+	// we need to wait a little bit since the files get deleted on a different
+	// goroutine.
+	<-time.After(10 * time.Millisecond)
+
+	notExist(stale, t)
+	exists(fresh, t)
+	fileCount(dir, 2, t)
+}
+
+func TestMaxTotalSize(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestMaxTotalSize", t)
+	defer os.RemoveAll(dir)
+
+	// 三个各 4 字节的备份，从旧到新
+	data := []byte("data")
+	var backups []string
+	for i := 0; i < 3; i++ {
+		backup := backupFile(dir)
+		isNil(ioutil.WriteFile(backup, data, 0644), t)
+		backups = append(backups, backup)
+		newFakeTime()
+	}
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		MaxTotalSize: 10,
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+
+	// we need to wait a little bit since the files get deleted on a different
+	// goroutine.
+	<-time.After(10 * time.Millisecond)
+
+	// 当前文件 4 字节 + 最新的一个备份 4 字节在 10 字节预算内，其余被删除
+	notExist(backups[0], t)
+	notExist(backups[1], t)
+	exists(backups[2], t)
+	fileCount(dir, 2, t)
+}
+
 func TestOldLogFiles(t *testing.T) {
 	currentTime = fakeTime
 	megabyte = 1
@@ -643,6 +757,84 @@ func TestCompressOnRotate(t *testing.T) {
 	fileCount(dir, 2, t)
 }
 
+func TestBackupDir(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestBackupDir", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	backupDir := filepath.Join(dir, "archive")
+	l := &Logger{
+		Filename:   filename,
+		BackupDir:  "archive",
+		MaxBackups: 1,
+		Compress:   true,
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	first := backupFile(backupDir)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// we need to wait a little bit since the files get compressed on a different
+	// goroutine.
+	<-time.After(300 * time.Millisecond)
+
+	// 备份文件位于 BackupDir 中，清理和压缩都在该目录进行
+	notExist(first+compressSuffix, t)
+	exists(backupFile(backupDir)+compressSuffix, t)
+	fileCount(backupDir, 1, t)
+	// 日志目录中只有当前文件和备份目录
+	fileCount(dir, 2, t)
+}
+
+func TestCompressSync(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestCompressSync", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Compress:     true,
+		CompressSync: true,
+		Filename:     filename,
+		MaxSize:      10,
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+
+	newFakeTime()
+
+	// 不需要等待后台 goroutine，Rotate 返回时压缩已经完成
+	err = l.Rotate()
+	isNil(err, t)
+
+	bc := new(bytes.Buffer)
+	gz := gzip.NewWriter(bc)
+	_, err = gz.Write(b)
+	isNil(err, t)
+	err = gz.Close()
+	isNil(err, t)
+	existsWithContent(backupFile(dir)+compressSuffix, bc.Bytes(), t)
+	notExist(backupFile(dir), t)
+
+	fileCount(dir, 2, t)
+}
+
 func TestCompressOnResume(t *testing.T) {
 	currentTime = fakeTime
 	megabyte = 1
@@ -780,3 +972,156 @@ func exists(path string, t testing.TB) {
 	_, err := os.Stat(path)
 	assertUp(err == nil, t, 1, "expected file to exist, but got error from os.Stat: %v", err)
 }
+
+func TestDebugLogger(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestDebugLogger", t)
+	defer os.RemoveAll(dir)
+
+	var buf bytes.Buffer
+	l := &Logger{
+		Filename:    logFile(dir),
+		DebugLogger: log.New(&buf, "", 0),
+	}
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.Close(), t)
+
+	// 调试日志只写入该 Logger 的 DebugLogger
+	assert(strings.Contains(buf.String(), "[lumberjack-debug]"), t, "expected debug output, got %q", buf.String())
+	assert(strings.Contains(buf.String(), logFile(dir)), t, "expected filename in debug output, got %q", buf.String())
+}
+
+func TestRotateWithResult(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestRotateWithResult", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+	}
+	defer l.Close()
+
+	// 日志文件还不存在，不产生备份
+	name, err := l.RotateWithResult()
+	isNil(err, t)
+	equals("", name, t)
+
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	name, err = l.RotateWithResult()
+	isNil(err, t)
+	equals(backupFile(dir), name, t)
+	existsWithContent(name, []byte("boo!"), t)
+
+	// 同步压缩时返回压缩后的路径
+	isNil(l.Close(), t)
+	l2 := &Logger{
+		Filename:     logFile(dir),
+		Compress:     true,
+		CompressSync: true,
+	}
+	defer l2.Close()
+	_, err = l2.Write([]byte("foo"))
+	isNil(err, t)
+	newFakeTime()
+	name, err = l2.RotateWithResult()
+	isNil(err, t)
+	equals(backupFile(dir)+compressSuffix, name, t)
+	exists(name, t)
+}
+
+func TestRotateTo(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestRotateTo", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// 相对路径相对于日志文件所在目录，目录不存在时自动创建
+	isNil(l.RotateTo(filepath.Join("incident-42", "snapshot.log")), t)
+	snapshot := filepath.Join(dir, "incident-42", "snapshot.log")
+	existsWithContent(snapshot, []byte("boo!"), t)
+	existsWithContent(logFile(dir), []byte{}, t)
+
+	_, err = l.Write([]byte("foo"))
+	isNil(err, t)
+	existsWithContent(logFile(dir), []byte("foo"), t)
+
+	// 不覆盖已有文件
+	notNil(l.RotateTo(snapshot), t)
+	notNil(l.RotateTo(logFile(dir)), t)
+	notNil(l.RotateTo(""), t)
+	existsWithContent(snapshot, []byte("boo!"), t)
+	existsWithContent(logFile(dir), []byte("foo"), t)
+}
+
+func TestPurge(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestPurge", t)
+	defer os.RemoveAll(dir)
+
+	var backups []string
+	for i := 0; i < 3; i++ {
+		name := backupFile(dir)
+		isNil(ioutil.WriteFile(name, []byte("old"), 0644), t)
+		backups = append(backups, name)
+		newFakeTime()
+	}
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxBackups: 1,
+	}
+	defer l.Close()
+
+	// 不需要轮转即可按 MaxBackups 清理
+	removed, err := l.Purge()
+	isNil(err, t)
+	equals([]string{backups[1], backups[0]}, removed, t)
+	exists(backups[2], t)
+
+	removed, err = l.Purge()
+	isNil(err, t)
+	equals(0, len(removed), t)
+}
+
+func TestPurgeOlderThan(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestPurgeOlderThan", t)
+	defer os.RemoveAll(dir)
+
+	var backups []string
+	for i := 0; i < 3; i++ {
+		name := backupFile(dir)
+		isNil(ioutil.WriteFile(name, []byte("old"), 0644), t)
+		backups = append(backups, name)
+		newFakeTime()
+	}
+
+	l := &Logger{
+		Filename: logFile(dir),
+	}
+	defer l.Close()
+
+	// 截止时间早于最新备份一小时，只保留最新备份
+	removed, err := l.PurgeOlderThan(fakeTime().Add(-2*24*time.Hour - time.Hour))
+	isNil(err, t)
+	equals([]string{backups[1], backups[0]}, removed, t)
+	exists(backups[2], t)
+
+	_, err = l.PurgeOlderThan(time.Time{})
+	notNil(err, t)
+}
@@ -0,0 +1,105 @@
+package lumberjacktest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ai-mmo/lumberjack"
+)
+
+// FakeClock 是只在调用 Advance 或 Set 时前进的 lumberjack.Clock。计时器在
+// 时钟越过其到期时间时触发，可以模拟跨天的定时轮转和 MaxAge 清理而无需等待。
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFakeClock 返回停在 now 的 FakeClock
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now 实现 lumberjack.Clock
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer 实现 lumberjack.Clock
+func (c *FakeClock) NewTimer(d time.Duration) lumberjack.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{c: c, ch: make(chan time.Time, 1), at: c.now.Add(d), active: true}
+	c.timers = append(c.timers, t)
+	c.fire()
+	return t
+}
+
+// Advance 使时钟前进 d，并触发到期的计时器
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.fire()
+}
+
+// Set 把时钟设置为 t，并触发到期的计时器。t 早于当前时间时时钟倒退。
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+	c.fire()
+}
+
+// fire 触发到期的计时器，调用方必须持有 c.mu
+func (c *FakeClock) fire() {
+	live := c.timers[:0]
+	for _, t := range c.timers {
+		if !t.active {
+			continue
+		}
+		if t.at.After(c.now) {
+			live = append(live, t)
+			continue
+		}
+		t.active = false
+		select {
+		case t.ch <- c.now:
+		default:
+		}
+	}
+	c.timers = live
+}
+
+// fakeTimer 是 FakeClock 创建的计时器
+type fakeTimer struct {
+	c      *FakeClock
+	ch     chan time.Time
+	at     time.Time
+	active bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Stop() bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	was := t.active
+	t.active = false
+	return was
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	was := t.active
+	t.at = t.c.now.Add(d)
+	if !was {
+		t.active = true
+		t.c.timers = append(t.c.timers, t)
+	}
+	t.c.fire()
+	return was
+}
@@ -0,0 +1,147 @@
+// Package lumberjacktest 提供测试 lumberjack.Logger 使用方代码的辅助函数：
+// 在临时目录中创建 Logger、强制轮转并等待后台处理完成、断言备份的数量和
+// 内容，以及可以手动推进的 FakeClock，测试中无需 glob 扫描目录或 sleep。
+//
+//	func TestRotation(t *testing.T) {
+//		clock := lumberjacktest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+//		l := lumberjacktest.New(t, func(l *lumberjack.Logger) {
+//			l.MaxBackups = 2
+//			l.Clock = clock
+//		})
+//		lumberjacktest.Write(t, l, "hello\n")
+//		clock.Advance(time.Second)
+//		lumberjacktest.Rotate(t, l)
+//		lumberjacktest.AssertBackups(t, l, "hello\n")
+//	}
+package lumberjacktest
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ai-mmo/lumberjack"
+)
+
+// Timeout 是辅助函数等待后台压缩、清理等处理完成的最长时间
+var Timeout = 10 * time.Second
+
+// New 在 t.TempDir() 中创建写入 test.log 的 Logger，依次应用 opts 后校验配置
+// 并打开日志文件，测试结束时自动关闭，临时目录随后被删除。
+func New(t testing.TB, opts ...lumberjack.Option) *lumberjack.Logger {
+	t.Helper()
+	l, err := lumberjack.New(filepath.Join(t.TempDir(), "test.log"), opts...)
+	if err != nil {
+		t.Fatalf("lumberjacktest: can't create logger: %s", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+// Dir 返回 l 的日志文件所在的目录
+func Dir(l *lumberjack.Logger) string {
+	return filepath.Dir(l.Filename)
+}
+
+// Write 把 s 写入 l，出错时使测试失败
+func Write(t testing.TB, l *lumberjack.Logger, s string) {
+	t.Helper()
+	if _, err := io.WriteString(l, s); err != nil {
+		t.Fatalf("lumberjacktest: write failed: %s", err)
+	}
+}
+
+// Rotate 强制轮转 l，并等待压缩、清理等后台处理完成。使用 FakeClock 时，
+// 连续两次轮转之间应推进时钟，使备份的时间戳不同。
+func Rotate(t testing.TB, l *lumberjack.Logger) {
+	t.Helper()
+	if err := l.Rotate(); err != nil {
+		t.Fatalf("lumberjacktest: rotate failed: %s", err)
+	}
+	WaitIdle(t, l)
+}
+
+// WaitIdle 等待 l 的后台处理完成，超过 Timeout 时使测试失败
+func WaitIdle(t testing.TB, l *lumberjack.Logger) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	if err := l.WaitIdle(ctx); err != nil {
+		t.Fatalf("lumberjacktest: waiting for background work: %s", err)
+	}
+}
+
+// Backups 等待后台处理完成后返回 l 的全部备份，按从新到旧排序
+func Backups(t testing.TB, l *lumberjack.Logger) []lumberjack.BackupInfo {
+	t.Helper()
+	WaitIdle(t, l)
+	backups, err := l.Backups()
+	if err != nil {
+		t.Fatalf("lumberjacktest: can't list backups: %s", err)
+	}
+	return backups
+}
+
+// AssertBackupCount 断言 l 的备份数量为 n
+func AssertBackupCount(t testing.TB, l *lumberjack.Logger, n int) {
+	t.Helper()
+	if backups := Backups(t, l); len(backups) != n {
+		t.Fatalf("lumberjacktest: expected %d backups, got %d: %v", n, len(backups), names(backups))
+	}
+}
+
+// AssertBackups 断言 l 的备份按从新到旧的内容依次为 want。压缩和加密（使用
+// EncryptionKey 或 EncryptionKeyFile）的备份按原始内容比较。
+func AssertBackups(t testing.TB, l *lumberjack.Logger, want ...string) {
+	t.Helper()
+	backups := Backups(t, l)
+	if len(backups) != len(want) {
+		t.Fatalf("lumberjacktest: expected %d backups, got %d: %v", len(want), len(backups), names(backups))
+	}
+	for i, b := range backups {
+		got := BackupContent(t, l, b)
+		if got != want[i] {
+			t.Errorf("lumberjacktest: backup %s: expected %q, got %q", b.Name, want[i], got)
+		}
+	}
+}
+
+// BackupContent 返回备份 b 的原始内容
+func BackupContent(t testing.TB, l *lumberjack.Logger, b lumberjack.BackupInfo) string {
+	t.Helper()
+	r, err := l.OpenBackup(b)
+	if err != nil {
+		t.Fatalf("lumberjacktest: %s", err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("lumberjacktest: can't read backup %s: %s", b.Name, err)
+	}
+	return string(data)
+}
+
+// AssertContent 断言 l 当前日志文件的内容为 want。设置 BufferSize 时先调用
+// Flush。
+func AssertContent(t testing.TB, l *lumberjack.Logger, want string) {
+	t.Helper()
+	data, err := os.ReadFile(l.Filename)
+	if err != nil {
+		t.Fatalf("lumberjacktest: can't read log file: %s", err)
+	}
+	if string(data) != want {
+		t.Errorf("lumberjacktest: log file: expected %q, got %q", want, data)
+	}
+}
+
+// names 返回备份的文件名
+func names(backups []lumberjack.BackupInfo) []string {
+	list := make([]string, 0, len(backups))
+	for _, b := range backups {
+		list = append(list, b.Name)
+	}
+	return list
+}
@@ -0,0 +1,96 @@
+package lumberjacktest
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ai-mmo/lumberjack"
+)
+
+func TestRotateAndAssert(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	l := New(t, func(l *lumberjack.Logger) {
+		l.MaxBackups = 2
+		l.Compress = true
+		l.Clock = clock
+	})
+
+	for _, s := range []string{"one\n", "two\n", "three\n"} {
+		Write(t, l, s)
+		clock.Advance(time.Second)
+		Rotate(t, l)
+	}
+	Write(t, l, "four\n")
+
+	AssertBackupCount(t, l, 2)
+	AssertBackups(t, l, "three\n", "two\n")
+	AssertContent(t, l, "four\n")
+	for _, b := range Backups(t, l) {
+		if !b.Compressed {
+			t.Errorf("backup %s is not compressed", b.Name)
+		}
+	}
+}
+
+func TestFakeClockDailyRotation(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 23, 59, 0, 0, time.UTC))
+	l := New(t, func(l *lumberjack.Logger) {
+		l.RotateDaily = true
+		l.Clock = clock
+	})
+	Write(t, l, "boo!\n")
+	WaitIdle(t, l)
+
+	clock.Advance(time.Minute)
+	deadline := time.Now().Add(Timeout)
+	for len(Backups(t, l)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("daily rotation did not happen")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	AssertBackups(t, l, "boo!\n")
+	AssertContent(t, l, "")
+}
+
+func TestFakeClockTimers(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	timer := clock.NewTimer(time.Hour)
+
+	clock.Advance(59 * time.Minute)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+	clock.Advance(time.Minute)
+	select {
+	case now := <-timer.C():
+		if !now.Equal(time.Unix(3600, 0)) {
+			t.Fatalf("unexpected fire time %v", now)
+		}
+	default:
+		t.Fatal("timer did not fire")
+	}
+
+	if timer.Reset(time.Minute) {
+		t.Fatal("Reset of expired timer returned true")
+	}
+	if !timer.Stop() {
+		t.Fatal("Stop of active timer returned false")
+	}
+	clock.Advance(time.Hour)
+	select {
+	case <-timer.C():
+		t.Fatal("stopped timer fired")
+	default:
+	}
+}
+
+func TestDir(t *testing.T) {
+	l := New(t)
+	if _, err := os.Stat(Dir(l)); err != nil {
+		t.Fatal(err)
+	}
+}
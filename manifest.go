@@ -0,0 +1,169 @@
+package lumberjack
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Manifest 是 Logger 维护的备份清单文件的内容，见 Logger.Manifest
+type Manifest struct {
+	Filename string          `json:"filename"` // 当前日志文件路径，同 CurrentFile
+	Updated  time.Time       `json:"updated"`  // 清单最后一次更新的时间
+	Backups  []ManifestEntry `json:"backups"`  // 全部备份，顺序与 Backups 一致
+}
+
+// ManifestEntry 描述清单中的一个备份
+type ManifestEntry struct {
+	BackupInfo
+	SHA256      string `json:"sha256"`                // 文件内容的 SHA-256（十六进制）
+	Compression string `json:"compression,omitempty"` // 压缩算法或自定义压缩的后缀，未压缩时为空
+	Uploaded    bool   `json:"uploaded"`              // 是否已上传到 Uploader 或 UploadTarget
+}
+
+// manifestFile 返回清单文件的路径，相对路径相对于日志文件所在目录
+func (l *Logger) manifestFile() string {
+	if filepath.IsAbs(l.Manifest) {
+		return l.Manifest
+	}
+	return filepath.Join(l.dir(), l.Manifest)
+}
+
+// ReadManifest 读取清单文件 path
+func ReadManifest(path string) (*Manifest, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseManifest(path, b)
+}
+
+// readManifest 通过 Filesystem 读取清单文件 path
+func (l *Logger) readManifest(path string) (*Manifest, error) {
+	b, err := l.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseManifest(path, b)
+}
+
+// parseManifest 解析从 path 读取的清单内容 b
+func parseManifest(path string, b []byte) (*Manifest, error) {
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("malformed manifest %s: %s", path, err)
+	}
+	return &m, nil
+}
+
+// markUploaded 记录备份 path 已上传成功，下次更新清单时写入
+func (l *Logger) markUploaded(path string) {
+	if l.Manifest != "" {
+		l.uploaded.push(path)
+	}
+}
+
+// updateManifest 按当前的备份重写清单文件，未设置 Manifest 时什么也不做。
+// 与上一版清单中路径、大小和时间戳都相同的备份沿用其校验和与上传状态，其余
+// 备份优先读取校验和旁路文件，没有时重新计算。序号命名模式下路径会随轮转
+// 复用和移动，只按大小和时间戳（即修改时间）匹配。先写临时文件再重命名，
+// 读取方不会看到写了一半的清单。
+func (l *Logger) updateManifest() error {
+	if l.Manifest == "" {
+		return nil
+	}
+	l.backupMu.Lock()
+	defer l.backupMu.Unlock()
+
+	name := l.manifestFile()
+	previous := make(map[manifestKey]ManifestEntry)
+	if old, err := l.readManifest(name); err == nil {
+		for _, e := range old.Backups {
+			previous[l.manifestKey(e.BackupInfo)] = e
+		}
+	}
+	uploaded := make(map[string]bool)
+	for _, p := range l.uploaded.take() {
+		uploaded[p] = true
+	}
+
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return err
+	}
+	m := Manifest{Filename: l.activeName(), Updated: l.now(), Backups: []ManifestEntry{}}
+	for _, f := range files {
+		e := ManifestEntry{BackupInfo: l.backupInfo(f), Compression: compressionOf(f.Name())}
+		if old, ok := previous[l.manifestKey(e.BackupInfo)]; ok && old.SHA256 != "" {
+			e.SHA256 = old.SHA256
+			e.Uploaded = old.Uploaded
+		} else if e.SHA256, err = l.backupChecksum(e.Path); err != nil {
+			return fmt.Errorf("can't checksum backup for manifest: %s", err)
+		}
+		e.Uploaded = e.Uploaded || uploaded[e.Path]
+		m.Backups = append(m.Backups, e)
+	}
+
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := l.fsys().MkdirAll(filepath.Dir(name), l.dirMode()); err != nil {
+		return fmt.Errorf("can't make directories for manifest: %s", err)
+	}
+	if err := l.replaceFile(name, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("can't write manifest: %s", err)
+	}
+	return nil
+}
+
+// manifestKey 标识清单中的一个备份，用于判断能否沿用上一版的记录
+type manifestKey struct {
+	path      string
+	size      int64
+	timestamp int64
+}
+
+// manifestKey 返回备份 b 的清单键，序号命名模式下不含路径
+func (l *Logger) manifestKey(b BackupInfo) manifestKey {
+	k := manifestKey{path: b.Path, size: b.Size, timestamp: b.Timestamp.UnixNano()}
+	if l.NumberedBackups {
+		k.path = ""
+	}
+	return k
+}
+
+// backupChecksum 返回备份 name 的 SHA-256，存在校验和旁路文件时直接读取
+func (l *Logger) backupChecksum(name string) (string, error) {
+	if b, err := l.readFile(name + checksumSuffix); err == nil {
+		if fields := strings.Fields(string(b)); len(fields) > 0 {
+			return fields[0], nil
+		}
+	}
+	sum, err := fsChecksum(l.fsys(), name)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum), nil
+}
+
+// compressionOf 按文件名后缀返回备份的压缩算法，未压缩时返回空字符串
+func compressionOf(name string) string {
+	name = trimEncryptSuffix(name)
+	suffix := name[len(trimCompressSuffix(name)):]
+	switch suffix {
+	case "":
+		return ""
+	case compressSuffix:
+		return compressionGzip
+	case zstdSuffix:
+		return compressionZstd
+	case zipSuffix:
+		return compressionZip
+	}
+	return strings.TrimPrefix(suffix, ".")
+}
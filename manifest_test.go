@@ -0,0 +1,106 @@
+package lumberjack
+
+import (
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestManifest(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestManifest", t)
+	defer os.RemoveAll(dir)
+
+	var uploads []string
+	l := &Logger{
+		Filename:     logFile(dir),
+		Manifest:     "manifest.json",
+		Compress:     true,
+		CompressSync: true,
+		MaxBackups:   2,
+		Uploader: UploaderFunc(func(ctx context.Context, path string) error {
+			uploads = append(uploads, path)
+			return nil
+		}),
+	}
+	defer l.Close()
+
+	for i := 0; i < 3; i++ {
+		newFakeTime()
+		_, err := l.Write([]byte("boo!"))
+		isNil(err, t)
+		isNil(l.Rotate(), t)
+	}
+	equals(3, len(uploads), t)
+
+	m, err := ReadManifest(filepath.Join(dir, "manifest.json"))
+	isNil(err, t)
+	equals(logFile(dir), m.Filename, t)
+	equals(2, len(m.Backups), t)
+	newest := m.Backups[0]
+	equals(backupFile(dir)+compressSuffix, newest.Path, t)
+	equals(compressionGzip, newest.Compression, t)
+	assert(newest.Uploaded, t, "expected uploaded backup: %+v", newest)
+	sum, err := fileChecksum(newest.Path)
+	isNil(err, t)
+	equals(hex.EncodeToString(sum), newest.SHA256, t)
+
+	// 上传状态在之后的更新中保留，已删除的备份从清单中移除
+	_, err = l.Purge()
+	isNil(err, t)
+	m, err = ReadManifest(filepath.Join(dir, "manifest.json"))
+	isNil(err, t)
+	equals(2, len(m.Backups), t)
+	assert(m.Backups[0].Uploaded && m.Backups[1].Uploaded, t, "upload status lost: %+v", m.Backups)
+	notExist(filepath.Join(dir, "manifest.json"+tmpSuffix), t)
+}
+
+func TestManifestNumbered(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestManifestNumbered", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:        logFile(dir),
+		Manifest:        "manifest.json",
+		NumberedBackups: true,
+		CompressSync:    true,
+	}
+	defer l.Close()
+
+	// 序号命名复用路径，内容不同但大小相同的新备份不能沿用旧的校验和
+	mtime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, s := range []string{"aaaa", "bbbb", "cccc"} {
+		_, err := l.Write([]byte(s))
+		isNil(err, t)
+		mtime = mtime.Add(time.Minute)
+		isNil(os.Chtimes(logFile(dir), mtime, mtime), t)
+		isNil(l.Rotate(), t)
+	}
+
+	m, err := ReadManifest(filepath.Join(dir, "manifest.json"))
+	isNil(err, t)
+	equals(3, len(m.Backups), t)
+	for _, e := range m.Backups {
+		sum, err := fileChecksum(e.Path)
+		isNil(err, t)
+		equals(hex.EncodeToString(sum), e.SHA256, t)
+	}
+	equals(logFile(dir)+".1", m.Backups[0].Path, t)
+}
+
+func TestCompressionOf(t *testing.T) {
+	for name, want := range map[string]string{
+		"foo.log":         "",
+		"foo.log.gz":      compressionGzip,
+		"foo.log.zst.enc": compressionZstd,
+		"foo.log.zip":     compressionZip,
+	} {
+		equals(want, compressionOf(name), t)
+	}
+}
@@ -0,0 +1,108 @@
+package lumberjack
+
+import (
+	"expvar"
+	"sync/atomic"
+	"time"
+)
+
+// loggerMetrics 记录 Logger 运行期间的累计指标，所有字段都可以并发读写
+type loggerMetrics struct {
+	bytesWritten   atomic.Int64 // 成功写入的字节数
+	writeErrors    atomic.Int64 // 返回错误的 Write 调用次数
+	rotations      atomic.Int64 // 轮转次数
+	removals       atomic.Int64 // 清理时删除的备份数
+	uploads        atomic.Int64 // 成功上传的备份数
+	millErrors     atomic.Int64 // 后台压缩、清理失败的次数
+	droppedErrs    atomic.Int64 // Errors 通道已满而被丢弃的错误数
+	droppedWrites  atomic.Int64 // 异步队列已满或超过限速而被丢弃的写入数
+	fallbackWrites atomic.Int64 // 写入 Fallback 的次数
+	diskDropped    atomic.Int64 // 磁盘空间不足而被丢弃的写入数
+	lastRotation   atomic.Int64 // 最近一次轮转的时间（UnixNano），0 表示尚未轮转
+
+	// 压缩耗时，compressions 为完成的压缩次数，compressNanos 为累计耗时
+	compressions  atomic.Int64
+	compressNanos atomic.Int64
+}
+
+// observeCompression 记录一次压缩的耗时
+func (m *loggerMetrics) observeCompression(d time.Duration) {
+	m.compressions.Add(1)
+	m.compressNanos.Add(int64(d))
+}
+
+// CurrentFile 返回当前正在写入的日志文件路径（StreamCompress 时带 ".gz"
+// 后缀），可以被并发调用
+func (l *Logger) CurrentFile() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.activeName()
+}
+
+// CurrentSize 返回当前日志文件已写入的字节数，可以被并发调用。与 MaxSize 比较
+// 即可知道距离下一次按大小轮转还有多远。尚未打开文件时返回 0。
+func (l *Logger) CurrentSize() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.size
+}
+
+// Stats 是 Logger 运行状态的快照，PublishExpvar 和 promlumberjack 包都基于它
+// 导出指标
+type Stats struct {
+	BytesWritten     int64     `json:"bytes_written"`     // 成功写入的字节数
+	Rotations        int64     `json:"rotations"`         // 轮转次数
+	LastRotation     time.Time `json:"last_rotation"`     // 最近一次轮转的时间，尚未轮转时为零值
+	CurrentSize      int64     `json:"current_size"`      // 当前日志文件的大小
+	Backups          int       `json:"backups"`           // 磁盘上的备份数
+	BackupBytes      int64     `json:"backup_bytes"`      // 磁盘上备份的总大小
+	Removals         int64     `json:"removals"`          // 清理时删除的备份数
+	Uploads          int64     `json:"uploads"`           // 成功上传的备份数
+	WriteErrors      int64     `json:"write_errors"`      // 返回错误的写入次数
+	BackgroundErrors int64     `json:"background_errors"` // 后台压缩、清理、定时轮转失败的次数
+	DroppedErrors    int64     `json:"dropped_errors"`    // Errors 通道已满而被丢弃的错误数
+	DroppedWrites    int64     `json:"dropped_writes"`    // 异步队列已满或超过限速而被丢弃的写入数
+	FallbackWrites   int64     `json:"fallback_writes"`   // 因写入日志文件失败而写入 Fallback 的次数
+	DiskDropped      int64     `json:"disk_dropped"`      // 设置 DropOnDiskPressure 时因磁盘空间不足而被丢弃的写入数
+
+	Compressions    int64         `json:"compressions"`     // 完成的备份压缩次数
+	CompressionTime time.Duration `json:"compression_time"` // 备份压缩的累计耗时
+}
+
+// Stats 返回 Logger 当前的运行状态。备份数和大小在调用时扫描备份目录得到。
+func (l *Logger) Stats() Stats {
+	m := &l.metrics
+	s := Stats{
+		BytesWritten:     m.bytesWritten.Load(),
+		Rotations:        m.rotations.Load(),
+		CurrentSize:      l.CurrentSize(),
+		Removals:         m.removals.Load(),
+		Uploads:          m.uploads.Load(),
+		WriteErrors:      m.writeErrors.Load(),
+		BackgroundErrors: m.millErrors.Load(),
+		DroppedErrors:    m.droppedErrs.Load(),
+		DroppedWrites:    m.droppedWrites.Load(),
+		FallbackWrites:   m.fallbackWrites.Load(),
+		DiskDropped:      m.diskDropped.Load(),
+		Compressions:     m.compressions.Load(),
+		CompressionTime:  time.Duration(m.compressNanos.Load()),
+	}
+	if ns := m.lastRotation.Load(); ns != 0 {
+		s.LastRotation = time.Unix(0, ns)
+	}
+	if files, err := l.oldLogFiles(); err == nil {
+		s.Backups = len(files)
+		for _, f := range files {
+			s.BackupBytes += f.Size()
+		}
+	}
+	return s
+}
+
+// PublishExpvar 以 name 把 Stats 发布到 expvar，可通过 /debug/vars 查看。
+// 与 expvar.Publish 一样，name 重复时会 panic。
+func (l *Logger) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return l.Stats()
+	}))
+}
@@ -0,0 +1,90 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestStats", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+	}
+	defer l.Close()
+
+	s := l.Stats()
+	equals(int64(0), s.Rotations, t)
+	assert(s.LastRotation.IsZero(), t, "expected zero LastRotation, got %v", s.LastRotation)
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	_, err = l.Write([]byte("foo"))
+	isNil(err, t)
+
+	s = l.Stats()
+	equals(int64(7), s.BytesWritten, t)
+	equals(int64(1), s.Rotations, t)
+	equals(fakeTime().UnixNano(), s.LastRotation.UnixNano(), t)
+	equals(int64(3), s.CurrentSize, t)
+	equals(1, s.Backups, t)
+	equals(int64(4), s.BackupBytes, t)
+	equals(int64(0), s.WriteErrors, t)
+
+	isNil(l.Close(), t)
+	_, err = l.Write([]byte("closed"))
+	notNil(err, t)
+	equals(int64(1), l.Stats().WriteErrors, t)
+}
+
+func TestPublishExpvar(t *testing.T) {
+	dir := makeTempDir("TestPublishExpvar", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// expvar 不允许重复发布同名变量，使用与 Logger 相关的名称以便测试重复运行
+	name := fmt.Sprintf("TestPublishExpvar-%p", l)
+	l.PublishExpvar(name)
+	v := expvar.Get(name)
+	notNil(v, t)
+
+	var s Stats
+	isNil(json.Unmarshal([]byte(v.String()), &s), t)
+	equals(int64(4), s.BytesWritten, t)
+}
+
+func TestCurrentFileAndSize(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestCurrentFileAndSize", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+	}
+	defer l.Close()
+
+	equals(logFile(dir), l.CurrentFile(), t)
+	equals(int64(0), l.CurrentSize(), t)
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	equals(int64(4), l.CurrentSize(), t)
+
+	isNil(l.Rotate(), t)
+	equals(int64(0), l.CurrentSize(), t)
+	equals(logFile(dir), l.CurrentFile(), t)
+}
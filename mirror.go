@@ -0,0 +1,25 @@
+package lumberjack
+
+// flusher 是带有缓冲区、可以显式刷新的 writer，例如 *bufio.Writer
+type flusher interface {
+	Flush() error
+}
+
+// writeMirror 把 p 写入 Mirror，失败时通过 Errors 上报。调用方必须持有 l.mu。
+func (l *Logger) writeMirror(p []byte) {
+	if l.Mirror == nil {
+		return
+	}
+	if _, err := l.Mirror.Write(p); err != nil {
+		l.reportError(OpMirror, err)
+		l.logDebug("写入 Mirror 失败: %v，文件: %s", err, l.filename())
+	}
+}
+
+// flushMirror 在 Mirror 实现了 Flush 时刷新它。调用方必须持有 l.mu。
+func (l *Logger) flushMirror() error {
+	if f, ok := l.Mirror.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package lumberjack
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMirror(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestMirror", t)
+	defer os.RemoveAll(dir)
+
+	var mirror bytes.Buffer
+	buffered := bufio.NewWriter(&mirror)
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		Mirror:   buffered,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	_, err = l.Write([]byte("foo"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("foo"), t)
+
+	// Flush 同时刷新 Mirror
+	equals(0, mirror.Len(), t)
+	isNil(l.Flush(), t)
+	equals("boo!foo", mirror.String(), t)
+}
+
+type failWriter struct{}
+
+func (failWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("mirror down")
+}
+
+func TestMirrorError(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestMirrorError", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		Mirror:   failWriter{},
+	}
+	defer l.Close()
+	errs := l.Errors()
+
+	// Mirror 失败不影响写入日志文件
+	n, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	equals(4, n, t)
+	existsWithContent(filename, []byte("boo!"), t)
+
+	select {
+	case err := <-errs:
+		var ae *AsyncError
+		assert(errors.As(err, &ae), t, "expected *AsyncError, got %T", err)
+		equals(OpMirror, ae.Op, t)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for mirror error")
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package lumberjack
+
+// newNamedMutex 在 Windows 以外的平台上不可用，返回 nil 使 NamedMutex 退化为锁文件
+func newNamedMutex(name string) (procLocker, error) {
+	return nil, nil
+}
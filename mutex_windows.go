@@ -0,0 +1,51 @@
+//go:build windows
+// +build windows
+
+package lumberjack
+
+import (
+	"runtime"
+
+	"golang.org/x/sys/windows"
+)
+
+// namedMutex 以 Windows 命名互斥量实现进程间锁。互斥量归属于获取它的线程，
+// 因此持有期间把 goroutine 固定在当前线程上。
+type namedMutex struct {
+	h windows.Handle
+}
+
+// newNamedMutex 创建或打开名为 name 的互斥量
+func newNamedMutex(name string) (procLocker, error) {
+	p, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	h, err := windows.CreateMutex(nil, false, p)
+	// 互斥量已由其他进程创建时 CreateMutex 返回有效句柄及 ERROR_ALREADY_EXISTS
+	if err != nil && err != windows.ERROR_ALREADY_EXISTS {
+		return nil, err
+	}
+	return &namedMutex{h: h}, nil
+}
+
+func (m *namedMutex) lock() error {
+	runtime.LockOSThread()
+	ev, err := windows.WaitForSingleObject(m.h, windows.INFINITE)
+	// 持有者未释放就退出时得到 WAIT_ABANDONED，此时互斥量已归本线程所有
+	if ev == windows.WAIT_OBJECT_0 || ev == windows.WAIT_ABANDONED {
+		return nil
+	}
+	runtime.UnlockOSThread()
+	return err
+}
+
+func (m *namedMutex) unlock() error {
+	err := windows.ReleaseMutex(m.h)
+	runtime.UnlockOSThread()
+	return err
+}
+
+func (m *namedMutex) close() error {
+	return windows.CloseHandle(m.h)
+}
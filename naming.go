@@ -0,0 +1,342 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// placeholderRe 匹配 FilenamePattern 中的占位符
+var placeholderRe = regexp.MustCompile(`\{[a-z]+\}`)
+
+// backupPattern 是编译后的 FilenamePattern，同时用于生成和识别备份文件名
+type backupPattern struct {
+	raw    string
+	layout string         // 时间戳格式，对应 {timestamp}
+	prefix string         // 日志文件名去掉扩展名的部分，对应 {name}
+	ext    string         // 日志文件的扩展名，对应 {ext}
+	loc    *time.Location // 解析时间戳使用的时区，nil 表示 UTC
+	re     *regexp.Regexp
+	// tsGroup / seqGroup 是 {timestamp} / {seq} 在正则中的分组序号，0 表示不含
+	tsGroup, seqGroup int
+}
+
+// filenamePattern 编译 FilenamePattern，未配置时返回 nil
+func (l *Logger) filenamePattern() (*backupPattern, error) {
+	if l.FilenamePattern == "" {
+		return nil, nil
+	}
+	filename := filepath.Base(l.filename())
+	ext := filepath.Ext(filename)
+	p, err := compileBackupPattern(l.FilenamePattern, l.timeFormat(), filename[:len(filename)-len(ext)], ext)
+	if err != nil {
+		return nil, err
+	}
+	p.loc = l.location()
+	return p, nil
+}
+
+// compileBackupPattern 把 FilenamePattern 转换为用于识别备份文件名的正则表达式
+func compileBackupPattern(pattern, layout, prefix, ext string) (*backupPattern, error) {
+	if strings.ContainsAny(pattern, `/\`) {
+		return nil, fmt.Errorf("filename pattern %q must not contain path separators", pattern)
+	}
+
+	p := &backupPattern{raw: pattern, layout: layout, prefix: prefix, ext: ext}
+	var expr strings.Builder
+	expr.WriteString("^")
+	group, last := 0, 0
+	for _, loc := range placeholderRe.FindAllStringIndex(pattern, -1) {
+		expr.WriteString(regexp.QuoteMeta(pattern[last:loc[0]]))
+		last = loc[1]
+		switch pattern[loc[0]:loc[1]] {
+		case "{name}":
+			expr.WriteString(regexp.QuoteMeta(prefix))
+		case "{ext}":
+			expr.WriteString(regexp.QuoteMeta(ext))
+		case "{timestamp}":
+			group++
+			p.tsGroup = group
+			expr.WriteString("(.+?)")
+		case "{seq}":
+			group++
+			p.seqGroup = group
+			expr.WriteString(`(\d+)`)
+		case "{pid}":
+			expr.WriteString(`\d+`)
+		case "{hostname}":
+			// 不限定为本机主机名，以便管理共享目录中其他主机产生的备份
+			expr.WriteString(`.+?`)
+		default:
+			return nil, fmt.Errorf("unknown placeholder %s in filename pattern %q", pattern[loc[0]:loc[1]], pattern)
+		}
+	}
+	expr.WriteString(regexp.QuoteMeta(pattern[last:]))
+	expr.WriteString("$")
+
+	if p.tsGroup == 0 && p.seqGroup == 0 {
+		return nil, fmt.Errorf("filename pattern %q must contain {timestamp} or {seq}", pattern)
+	}
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid filename pattern %q: %s", pattern, err)
+	}
+	p.re = re
+	return p, nil
+}
+
+// format 按模板生成备份文件名（不含目录），ts 为已格式化的时间戳
+func (p *backupPattern) format(ts string, seq int) string {
+	return placeholderRe.ReplaceAllStringFunc(p.raw, func(ph string) string {
+		switch ph {
+		case "{name}":
+			return p.prefix
+		case "{ext}":
+			return p.ext
+		case "{timestamp}":
+			return ts
+		case "{seq}":
+			return strconv.Itoa(seq)
+		case "{pid}":
+			return strconv.Itoa(os.Getpid())
+		case "{hostname}":
+			host, err := os.Hostname()
+			if err != nil || host == "" {
+				host = "localhost"
+			}
+			return host
+		}
+		return ph
+	})
+}
+
+// parse 识别由该模板生成的备份文件名（可带压缩后缀），返回其中的时间戳和序号。
+// 模板不含 {timestamp} 时返回的时间为零值。
+func (p *backupPattern) parse(filename string) (t time.Time, seq int, ok bool) {
+	m := p.re.FindStringSubmatch(trimCompressSuffix(filename))
+	if m == nil {
+		return time.Time{}, 0, false
+	}
+	if p.tsGroup > 0 {
+		var err error
+		if t, err = parseBackupTime(p.layout, m[p.tsGroup], p.loc); err != nil {
+			return time.Time{}, 0, false
+		}
+	}
+	if p.seqGroup > 0 {
+		var err error
+		if seq, err = strconv.Atoi(m[p.seqGroup]); err != nil {
+			return time.Time{}, 0, false
+		}
+	}
+	return t, seq, true
+}
+
+// timeFormat 返回备份文件名中时间戳使用的格式
+func (l *Logger) timeFormat() string {
+	if l.BackupTimeFormat != "" {
+		return l.BackupTimeFormat
+	}
+	return backupTimeFormat
+}
+
+// validateTimeFormat 检查时间格式能否用于备份文件名：不能包含路径分隔符，
+// 且格式化后的结果必须能被原样解析回来。
+func validateTimeFormat(layout string) error {
+	if strings.ContainsAny(layout, `/\`) {
+		return fmt.Errorf("backup time format %q must not contain path separators", layout)
+	}
+	ts := time.Date(2001, 2, 3, 4, 5, 6, 7000000, time.UTC).Format(layout)
+	if ts == layout {
+		return fmt.Errorf("backup time format %q contains no time elements", layout)
+	}
+	if _, err := time.Parse(layout, ts); err != nil {
+		return fmt.Errorf("invalid backup time format %q: %s", layout, err)
+	}
+	return nil
+}
+
+// newBackupName 返回当前日志文件本次轮转后应使用的备份路径
+func (l *Logger) newBackupName(dir, name string) (string, error) {
+	if err := validateTimeFormat(l.timeFormat()); err != nil {
+		return "", err
+	}
+	if l.NumberedBackups {
+		if l.FilenamePattern != "" {
+			return "", fmt.Errorf("NumberedBackups cannot be combined with FilenamePattern")
+		}
+		if err := l.shiftNumberedBackups(); err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, filepath.Base(name)+".1"), nil
+	}
+
+	p, err := l.filenamePattern()
+	if err != nil {
+		return "", err
+	}
+
+	ts := l.now().In(l.location()).Format(l.timeFormat())
+
+	build := func(ts string) string {
+		return backupName(dir, name, ts)
+	}
+	if p != nil {
+		seq, err := l.nextSeq(p)
+		if err != nil {
+			return "", err
+		}
+		build = func(ts string) string {
+			return filepath.Join(dir, p.format(ts, seq))
+		}
+	}
+
+	// 同一时间戳内多次轮转时追加 "-N" 后缀，避免覆盖已有备份。
+	// 模板不含 {timestamp} 时名称由递增序号保证唯一。
+	newname := build(ts)
+	if p == nil || p.tsGroup > 0 {
+		for n := 1; l.backupExists(newname); n++ {
+			newname = build(fmt.Sprintf("%s-%d", ts, n))
+		}
+	}
+	return newname, nil
+}
+
+// nextSeq 返回模板中 {seq} 的下一个值，模板不含 {seq} 时返回 0
+func (l *Logger) nextSeq(p *backupPattern) (int, error) {
+	seq := 0
+	if p.seqGroup > 0 {
+		// 序号在已有备份的最大序号上递增，保证删除旧备份后序号也不会被复用
+		files, err := l.oldLogFiles()
+		if err != nil {
+			return 0, err
+		}
+		for _, f := range files {
+			if f.seq > seq {
+				seq = f.seq
+			}
+		}
+		seq++
+	}
+	return seq, nil
+}
+
+// backupExists 判断备份文件是否已存在。只存在同名的压缩或加密文件时同样视为
+// 已存在：它来自同一时间戳内更早的轮转，沿用该名称会使后台处理覆盖它。
+func (l *Logger) backupExists(name string) bool {
+	if _, err := l.stat(name); err == nil {
+		return true
+	}
+	// 依次检查压缩、加密以及先压缩再加密后的文件名
+	for _, suffix := range append([]string{""}, allCompressSuffixes()...) {
+		if suffix != "" {
+			if _, err := l.stat(name + suffix); err == nil {
+				return true
+			}
+		}
+		for _, encrypted := range encryptSuffixes {
+			if _, err := l.stat(name + suffix + encrypted); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// processedName 返回备份 name 经过压缩、加密后的实际路径。name 本身仍存在或
+// 找不到处理后的文件时返回 name。
+func (l *Logger) processedName(name string) string {
+	if _, err := l.stat(name); err == nil {
+		return name
+	}
+	for _, suffix := range append([]string{""}, allCompressSuffixes()...) {
+		candidates := []string{name + suffix}
+		for _, encrypted := range encryptSuffixes {
+			candidates = append(candidates, name+suffix+encrypted)
+		}
+		for _, c := range candidates {
+			if _, err := l.stat(c); err == nil {
+				return c
+			}
+		}
+	}
+	return name
+}
+
+// parseBackupTime 按 layout 解析备份文件名中的时间戳，时间戳不含时区时按 loc
+// （nil 表示 UTC）解释。可识别为避免重名而追加的 "-N" 后缀。
+func parseBackupTime(layout, ts string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	t, err := time.ParseInLocation(layout, ts, loc)
+	if err == nil {
+		return t, nil
+	}
+	if i := strings.LastIndexByte(ts, '-'); i > 0 && isDigits(ts[i+1:]) {
+		if t, err := time.ParseInLocation(layout, ts[:i], loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// isDigits 判断 s 是否为非空的十进制数字串
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// numberFromName 识别序号命名模式下的备份文件名 base.N 或其压缩版本（如
+// base.N.gz），返回序号 N
+func numberFromName(filename, base string) (int, bool) {
+	if !strings.HasPrefix(filename, base+".") {
+		return 0, false
+	}
+	num := trimCompressSuffix(filename[len(base)+1:])
+	n, err := strconv.Atoi(num)
+	if err != nil || n <= 0 || strconv.Itoa(n) != num {
+		return 0, false
+	}
+	return n, true
+}
+
+// shiftNumberedBackups 把已有备份的序号依次加一，从最大序号开始重命名，
+// 为新的 .1 备份腾出位置。持有 backupMu，避免与后台压缩同时操作同一文件。
+func (l *Logger) shiftNumberedBackups() error {
+	l.backupMu.Lock()
+	defer l.backupMu.Unlock()
+
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return err
+	}
+	base := filepath.Base(l.filename())
+	for i := len(files) - 1; i >= 0; i-- {
+		f := files[i]
+		suffix := strings.TrimPrefix(f.Name(), base+"."+strconv.Itoa(f.seq))
+		newname := filepath.Join(f.dir, base+"."+strconv.Itoa(f.seq+1)+suffix)
+		if err := l.rename(f.path(), newname); err != nil {
+			return fmt.Errorf("can't shift numbered backup: %s", err)
+		}
+		if err := moveChecksum(f.path(), newname); err != nil {
+			return fmt.Errorf("can't shift numbered backup checksum: %s", err)
+		}
+		if err := l.moveHold(f.path(), newname); err != nil {
+			return fmt.Errorf("can't shift numbered backup hold marker: %s", err)
+		}
+	}
+	return nil
+}
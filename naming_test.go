@@ -0,0 +1,243 @@
+package lumberjack
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFilenamePattern(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestFilenamePattern", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:        filename,
+		FilenamePattern: "{name}{ext}.{timestamp}.{pid}",
+		MaxBackups:      1,
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	first := filepath.Join(dir, "foobar.log."+fakeTime().UTC().Format(backupTimeFormat)+"."+strconv.Itoa(os.Getpid()))
+	existsWithContent(first, b, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// we need to wait a little bit since the files get deleted on a different
+	// goroutine.
+	<-time.After(10 * time.Millisecond)
+
+	// 清理同样按模板识别备份
+	notExist(first, t)
+	fileCount(dir, 2, t)
+}
+
+func TestFilenamePatternSeq(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestFilenamePatternSeq", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:        filename,
+		FilenamePattern: "{name}-{seq}{ext}",
+		MaxBackups:      2,
+	}
+	defer l.Close()
+	for i := 0; i < 3; i++ {
+		_, err := l.Write([]byte(strconv.Itoa(i)))
+		isNil(err, t)
+		isNil(l.Rotate(), t)
+		<-time.After(10 * time.Millisecond)
+	}
+
+	// 序号不因删除旧备份而复用
+	notExist(filepath.Join(dir, "foobar-1.log"), t)
+	existsWithContent(filepath.Join(dir, "foobar-2.log"), []byte("1"), t)
+	existsWithContent(filepath.Join(dir, "foobar-3.log"), []byte("2"), t)
+	fileCount(dir, 3, t)
+}
+
+func TestCompileBackupPattern(t *testing.T) {
+	for _, pattern := range []string{
+		"{name}{ext}",
+		"{name}-{bogus}{ext}",
+		"logs/{name}-{timestamp}{ext}",
+	} {
+		_, err := compileBackupPattern(pattern, backupTimeFormat, "foo", ".log")
+		notNil(err, t)
+	}
+
+	p, err := compileBackupPattern("{name}.{hostname}.{timestamp}{ext}", backupTimeFormat, "foo", ".log")
+	isNil(err, t)
+	ts := time.Date(2014, 5, 4, 14, 44, 33, 555000000, time.UTC)
+	got, _, ok := p.parse("foo.web-01.2014-05-04T14-44-33.555.log.gz")
+	equals(true, ok, t)
+	equals(ts, got, t)
+	_, _, ok = p.parse("bar.web-01.2014-05-04T14-44-33.555.log")
+	equals(false, ok, t)
+}
+
+func TestNumberedBackups(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestNumberedBackups", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:        filename,
+		NumberedBackups: true,
+		MaxBackups:      2,
+	}
+	defer l.Close()
+	for _, s := range []string{"first", "second", "third"} {
+		_, err := l.Write([]byte(s))
+		isNil(err, t)
+		isNil(l.Rotate(), t)
+		<-time.After(10 * time.Millisecond)
+	}
+
+	// 最新的备份序号最小，超出 MaxBackups 的 .3 被删除
+	existsWithContent(filename+".1", []byte("third"), t)
+	existsWithContent(filename+".2", []byte("second"), t)
+	notExist(filename+".3", t)
+	fileCount(dir, 3, t)
+}
+
+func TestNumberFromName(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     int
+		ok       bool
+	}{
+		{"foo.log.1", 1, true},
+		{"foo.log.12.gz", 12, true},
+		{"foo.log", 0, false},
+		{"foo.log.0", 0, false},
+		{"foo.log.01", 0, false},
+		{"foo.log.x", 0, false},
+		{"bar.log.1", 0, false},
+	}
+	for _, test := range tests {
+		n, ok := numberFromName(test.filename, "foo.log")
+		equals(test.want, n, t)
+		equals(test.ok, ok, t)
+	}
+}
+
+func TestBackupTimeFormat(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestBackupTimeFormat", t)
+	defer os.RemoveAll(dir)
+
+	const layout = "20060102-150405.000"
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:         filename,
+		BackupTimeFormat: layout,
+		MaxBackups:       1,
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	first := filepath.Join(dir, "foobar-"+fakeTime().UTC().Format(layout)+".log")
+	existsWithContent(first, b, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	<-time.After(10 * time.Millisecond)
+
+	// 清理时按配置的格式解析已有备份
+	notExist(first, t)
+	exists(filepath.Join(dir, "foobar-"+fakeTime().UTC().Format(layout)+".log"), t)
+	fileCount(dir, 2, t)
+}
+
+func TestValidateTimeFormat(t *testing.T) {
+	isNil(validateTimeFormat(backupTimeFormat), t)
+	isNil(validateTimeFormat("2006-01-02"), t)
+	notNil(validateTimeFormat("2006/01/02"), t)
+	notNil(validateTimeFormat("static"), t)
+}
+
+func TestBackupNameCollision(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestBackupNameCollision", t)
+	defer os.RemoveAll(dir)
+
+	const layout = "2006-01-02"
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:         filename,
+		BackupTimeFormat: layout,
+		MaxBackups:       2,
+	}
+	defer l.Close()
+
+	// 同一天内的三次轮转不会互相覆盖
+	for _, s := range []string{"first", "second", "third"} {
+		_, err := l.Write([]byte(s))
+		isNil(err, t)
+		isNil(l.Rotate(), t)
+		<-time.After(10 * time.Millisecond)
+	}
+
+	prefix := filepath.Join(dir, "foobar-"+fakeTime().UTC().Format(layout))
+	notExist(prefix+".log", t)
+	existsWithContent(prefix+"-1.log", []byte("second"), t)
+	existsWithContent(prefix+"-2.log", []byte("third"), t)
+	fileCount(dir, 3, t)
+}
+
+func TestBackupNameFrozenClockCompress(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestBackupNameFrozenClockCompress", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		Compress: true,
+	}
+	defer l.Close()
+
+	// 时间不前进，每次轮转的时间戳相同；已压缩的备份不能被后来的备份覆盖
+	for i := 0; i < 3; i++ {
+		_, err := l.Write([]byte("boo!"))
+		isNil(err, t)
+		isNil(l.Rotate(), t)
+		isNil(l.WaitIdle(context.Background()), t)
+	}
+
+	first := backupFile(dir)
+	exists(first+compressSuffix, t)
+	exists(first[:len(first)-len(".log")]+"-1.log"+compressSuffix, t)
+	exists(first[:len(first)-len(".log")]+"-2.log"+compressSuffix, t)
+	fileCount(dir, 4, t)
+}
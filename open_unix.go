@@ -7,12 +7,21 @@ import (
 	"os"
 )
 
-// openFile 在非 Windows 平台上打开文件，直接使用标准库的 os.OpenFile
-func openFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+// openFileOnce 在非 Windows 平台上打开文件，直接使用标准库的 os.OpenFile
+func openFileOnce(name string, flag int, perm os.FileMode) (*os.File, error) {
 	return os.OpenFile(name, flag, perm)
 }
 
-// renameFile 在非 Windows 平台上重命名文件，直接使用标准库的 os.Rename
-func renameFile(oldpath, newpath string) error {
+// renameFileOnce 在非 Windows 平台上重命名文件，直接使用标准库的 os.Rename
+func renameFileOnce(oldpath, newpath string) error {
 	return os.Rename(oldpath, newpath)
 }
+
+// moveFileWriteThrough 在非 Windows 平台上等同于 renameFileOnce
+func moveFileWriteThrough(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+// defaultRetryPolicy 是未设置 RetryPolicy 时使用的重试策略，非 Windows 平台上
+// 不重试
+var defaultRetryPolicy RetryPolicy = NoRetry
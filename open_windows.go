@@ -4,15 +4,20 @@
 package lumberjack
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
+
+	"golang.org/x/sys/windows"
 )
 
-// openFile 在 Windows 平台上打开文件，使用适当的共享模式避免文件占用冲突
+// openFileOnce 在 Windows 平台上打开文件，使用适当的共享模式避免文件占用冲突
 // 允许其他进程读取和删除文件，这样可以避免 "The process cannot access the file" 错误
-// 如果遇到文件占用错误，会进行短暂重试
-func openFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+// 文件被短暂占用时的重试由 openFileRetry 按 RetryPolicy 完成
+func openFileOnce(name string, flag int, perm os.FileMode) (*os.File, error) {
 	// 将 Go 的文件标志转换为 Windows 的访问模式和创建模式
 	var access uint32
 	switch flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR) {
@@ -54,42 +59,22 @@ func openFile(name string, flag int, perm os.FileMode) (*os.File, error) {
 	// 文件属性
 	attrs := uint32(syscall.FILE_ATTRIBUTE_NORMAL)
 
-	// 转换路径为 UTF-16
-	pathp, err := syscall.UTF16PtrFromString(name)
+	// 转换路径为 UTF-16，超长路径加上 \\?\ 前缀
+	pathp, err := syscall.UTF16PtrFromString(fixLongPath(name))
 	if err != nil {
 		return nil, err
 	}
 
-	// 重试机制：在 Windows 上，即使使用了共享模式，文件可能仍被短暂占用
-	// 最多重试 3 次，每次间隔 10ms
-	var handle syscall.Handle
-	maxRetries := 3
-	for i := 0; i < maxRetries; i++ {
-		handle, err = syscall.CreateFile(
-			pathp,
-			access,
-			shareMode,
-			nil,
-			createMode,
-			attrs,
-			0,
-		)
-		if err == nil {
-			break
-		}
-
-		// 检查是否是文件占用错误
-		if errno, ok := err.(syscall.Errno); ok {
-			// ERROR_SHARING_VIOLATION (32) 或 ERROR_LOCK_VIOLATION (33)
-			if errno == 32 || errno == 33 {
-				if i < maxRetries-1 {
-					// 短暂等待后重试
-					time.Sleep(10 * time.Millisecond)
-					continue
-				}
-			}
-		}
-		// 其他错误或重试次数用尽，直接返回
+	handle, err := syscall.CreateFile(
+		pathp,
+		access,
+		shareMode,
+		nil,
+		createMode,
+		attrs,
+		0,
+	)
+	if err != nil {
 		return nil, err
 	}
 
@@ -97,33 +82,83 @@ func openFile(name string, flag int, perm os.FileMode) (*os.File, error) {
 	return os.NewFile(uintptr(handle), name), nil
 }
 
-// renameFile 在 Windows 平台上重命名文件，带重试机制
-// 在文件轮转时，可能会遇到短暂的文件占用问题
-func renameFile(oldpath, newpath string) error {
-	// 重试机制：最多重试 5 次，每次间隔 20ms
-	maxRetries := 5
-	var err error
-	for i := 0; i < maxRetries; i++ {
-		err = os.Rename(oldpath, newpath)
-		if err == nil {
-			return nil
-		}
-
-		// 检查是否是文件占用错误
-		if errno, ok := err.(*os.LinkError); ok {
-			if syserr, ok := errno.Err.(syscall.Errno); ok {
-				// ERROR_SHARING_VIOLATION (32) 或 ERROR_LOCK_VIOLATION (33) 或 ERROR_ACCESS_DENIED (5)
-				if syserr == 32 || syserr == 33 || syserr == 5 {
-					if i < maxRetries-1 {
-						// 短暂等待后重试
-						time.Sleep(20 * time.Millisecond)
-						continue
-					}
-				}
-			}
-		}
-		// 其他错误或重试次数用尽，直接返回
+// renameFileOnce 在 Windows 平台上重命名文件
+func renameFileOnce(oldpath, newpath string) error {
+	return os.Rename(fixLongPath(oldpath), fixLongPath(newpath))
+}
+
+// moveFileWriteThrough 以 MoveFileEx(MOVEFILE_REPLACE_EXISTING|MOVEFILE_WRITE_THROUGH)
+// 重命名文件，操作在数据真正写入磁盘后才返回。os.Rename 同样基于 MoveFileEx，
+// 但不等待写入完成。
+func moveFileWriteThrough(oldpath, newpath string) error {
+	from, err := syscall.UTF16PtrFromString(fixLongPath(oldpath))
+	if err != nil {
 		return err
 	}
-	return err
+	to, err := syscall.UTF16PtrFromString(fixLongPath(newpath))
+	if err != nil {
+		return err
+	}
+	if err := windows.MoveFileEx(from, to, windows.MOVEFILE_REPLACE_EXISTING|windows.MOVEFILE_WRITE_THROUGH); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+	return nil
 }
+
+// longPathLimit 是需要加 \\?\ 前缀的路径长度。MAX_PATH 为 260，但创建目录时
+// 还要为 8.3 文件名预留 12 个字符，与标准库保持一致取 248。
+const longPathLimit = 248
+
+// fixLongPath 把超过 longPathLimit 的路径转换为 \\?\ 形式的绝对路径，使
+// CreateFile 等 API 能够打开深层目录中的文件，否则会返回"系统找不到指定的
+// 路径"。该形式不做任何路径解析，因此先转换为绝对路径并清理 "."、".." 和
+// "/"。UNC 路径 \\server\share 转换为 \\?\UNC\server\share。
+func fixLongPath(path string) string {
+	if len(path) < longPathLimit {
+		return path
+	}
+	if strings.HasPrefix(path, `\\?\`) || strings.HasPrefix(path, `\\.\`) {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	abs = filepath.Clean(abs)
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+	return `\\?\` + abs
+}
+
+// Windows 上表示文件被短暂占用的错误码
+const (
+	errAccessDenied     = syscall.Errno(5)  // ERROR_ACCESS_DENIED
+	errSharingViolation = syscall.Errno(32) // ERROR_SHARING_VIOLATION
+	errLockViolation    = syscall.Errno(33) // ERROR_LOCK_VIOLATION
+)
+
+// IsTransient 判断文件操作的错误是否可能是暂时的，重试后可能成功。Windows 上
+// 为文件被其他进程占用（共享冲突、锁冲突）以及杀毒软件、索引服务短暂打开
+// 文件时出现的拒绝访问。
+func IsTransient(err error) bool {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return false
+	}
+	return errno == errSharingViolation || errno == errLockViolation || errno == errAccessDenied
+}
+
+// defaultRetryPolicy 是未设置 RetryPolicy 时使用的重试策略：即使使用了共享
+// 模式，文件仍可能被短暂占用。打开文件时遇到共享冲突或锁冲突最多尝试 3 次，
+// 每次间隔 10ms；重命名和删除时还包括拒绝访问，最多尝试 5 次，每次间隔 20ms。
+var defaultRetryPolicy RetryPolicy = RetryFunc(func(op string, attempt int, err error) (time.Duration, bool) {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return 0, false
+	}
+	if op == RetryOpOpen {
+		return 10 * time.Millisecond, attempt < 3 && (errno == errSharingViolation || errno == errLockViolation)
+	}
+	return 20 * time.Millisecond, attempt < 5 && IsTransient(err)
+})
@@ -0,0 +1,100 @@
+package lumberjack
+
+import (
+	"fmt"
+	"time"
+)
+
+// Option 在 New 中修改 Logger 的配置，也可以自行编写，例如
+//
+//	func(l *lumberjack.Logger) { l.SymlinkName = "current.log" }
+type Option func(*Logger)
+
+// New 创建写入 filename 的 Logger，依次应用 opts 后校验配置（见 Validate）
+// 并立即打开日志文件，使配置错误和无法创建文件等问题在启动时就暴露出来，
+// 而不是等到首次写入。出错时返回的 Logger 为 nil。
+func New(filename string, opts ...Option) (*Logger, error) {
+	l := &Logger{Filename: filename}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if err := l.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %s", err)
+	}
+	if err := l.openNow(); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// openNow 在尚未打开日志文件时打开
+func (l *Logger) openNow() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	unlock, err := l.lockShared()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if l.file != nil {
+		return nil
+	}
+	return l.openExistingOrNew(0)
+}
+
+// WithMaxSize 设置 MaxSize，单位为 MB
+func WithMaxSize(megabytes int) Option {
+	return func(l *Logger) {
+		l.MaxSize = megabytes
+	}
+}
+
+// WithMaxBackups 设置 MaxBackups
+func WithMaxBackups(n int) Option {
+	return func(l *Logger) {
+		l.MaxBackups = n
+	}
+}
+
+// WithMaxAge 设置 MaxAgeDuration
+func WithMaxAge(d time.Duration) Option {
+	return func(l *Logger) {
+		l.MaxAgeDuration = d
+	}
+}
+
+// WithCompression 设置备份的压缩算法，取值见 Compression
+func WithCompression(codec string) Option {
+	return func(l *Logger) {
+		l.Compression = codec
+	}
+}
+
+// WithLocalTime 使备份文件名中的时间戳使用本地时间
+func WithLocalTime() Option {
+	return func(l *Logger) {
+		l.LocalTime = true
+	}
+}
+
+// WithLocation 设置 Location，备份时间戳和定时轮转改用时区 loc
+func WithLocation(loc *time.Location) Option {
+	return func(l *Logger) {
+		l.Location = loc
+	}
+}
+
+// WithBackupDir 设置 BackupDir
+func WithBackupDir(dir string) Option {
+	return func(l *Logger) {
+		l.BackupDir = dir
+	}
+}
+
+// WithClock 设置 Clock，便于测试中模拟时间流逝，见 lumberjacktest.FakeClock
+func WithClock(c Clock) Option {
+	return func(l *Logger) {
+		l.Clock = c
+	}
+}
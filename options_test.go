@@ -0,0 +1,76 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestNew", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l, err := New(filename,
+		WithMaxSize(10),
+		WithMaxBackups(3),
+		WithMaxAge(72*time.Hour),
+		WithCompression("zstd"),
+		WithLocalTime(),
+		func(l *Logger) { l.MaxLines = 100 },
+	)
+	isNil(err, t)
+	defer l.Close()
+
+	equals(10, l.MaxSize, t)
+	equals(3, l.MaxBackups, t)
+	equals(72*time.Hour, l.MaxAgeDuration, t)
+	equals("zstd", l.Compression, t)
+	equals(true, l.LocalTime, t)
+	equals(100, l.MaxLines, t)
+
+	// 文件在 New 返回时已经创建
+	existsWithContent(filename, []byte{}, t)
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	dir := makeTempDir("TestNewInvalidConfig", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l, err := New(filename, WithCompression("lz4"))
+	notNil(err, t)
+	assert(l == nil, t, "expected nil Logger on error")
+	notExist(filename, t)
+}
+
+func TestNewOpenError(t *testing.T) {
+	dir := makeTempDir("TestNewOpenError", t)
+	defer os.RemoveAll(dir)
+
+	// 日志目录的位置已被普通文件占用，无法创建
+	blocker := filepath.Join(dir, "blocker")
+	isNil(os.WriteFile(blocker, []byte("x"), 0644), t)
+	_, err := New(filepath.Join(blocker, "foobar.log"))
+	notNil(err, t)
+}
+
+func TestWithClock(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestWithClock", t)
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	l, err := New(logFile(dir), WithClock(&manualClock{now: now}))
+	isNil(err, t)
+	defer l.Close()
+
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+
+	// 备份时间戳取自 Clock 而不是 currentTime
+	existsWithContent(filepath.Join(dir, "foobar-2020-01-02T03-04-05.006.log"), []byte("boo!"), t)
+}
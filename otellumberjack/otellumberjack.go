@@ -0,0 +1,103 @@
+// Package otellumberjack 把 lumberjack 的轮转、清理、压缩等操作上报为
+// OpenTelemetry 的 span 和指标，让卡住或失败的后台操作出现在追踪后端中。
+//
+//	inst, err := otellumberjack.New()
+//	if err != nil {
+//		return err
+//	}
+//	logger := &lumberjack.Logger{
+//		Filename:        "/var/log/myapp/foo.log",
+//		Instrumentation: inst,
+//	}
+package otellumberjack
+
+import (
+	"context"
+	"time"
+
+	"github.com/ai-mmo/lumberjack"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName 是 tracer 和 meter 的名称
+const instrumentationName = "github.com/ai-mmo/lumberjack/otellumberjack"
+
+// Instrumentation 实现 lumberjack.Instrumentation，每个操作生成一个名为
+// lumberjack.<操作> 的 span，并把耗时记录到 lumberjack.operation.duration
+// 直方图中（属性 lumberjack.operation 和 lumberjack.outcome）。
+type Instrumentation struct {
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+}
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// Option 配置 Instrumentation
+type Option func(*config)
+
+// WithTracerProvider 指定创建 span 使用的 TracerProvider，默认使用全局的
+// otel.GetTracerProvider()
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+// WithMeterProvider 指定记录指标使用的 MeterProvider，默认使用全局的
+// otel.GetMeterProvider()
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = mp
+	}
+}
+
+// New 创建 Instrumentation
+func New(opts ...Option) (*Instrumentation, error) {
+	c := config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	duration, err := c.meterProvider.Meter(instrumentationName).Float64Histogram(
+		"lumberjack.operation.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of lumberjack rotation and background operations."),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Instrumentation{
+		tracer:   c.tracerProvider.Tracer(instrumentationName),
+		duration: duration,
+	}, nil
+}
+
+// StartOperation 实现 lumberjack.Instrumentation
+func (i *Instrumentation) StartOperation(op lumberjack.Operation, file string) func(err error) {
+	opAttr := attribute.String("lumberjack.operation", string(op))
+	ctx, span := i.tracer.Start(context.Background(), "lumberjack."+string(op),
+		trace.WithAttributes(opAttr, attribute.String("file.path", file)))
+	start := time.Now()
+
+	return func(err error) {
+		outcome := "success"
+		if err != nil {
+			outcome = "error"
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		i.duration.Record(ctx, time.Since(start).Seconds(),
+			metric.WithAttributes(opAttr, attribute.String("lumberjack.outcome", outcome)))
+	}
+}
@@ -0,0 +1,91 @@
+package otellumberjack
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ai-mmo/lumberjack"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestInstrumentation(t *testing.T) (*Instrumentation, *tracetest.SpanRecorder, *sdkmetric.ManualReader) {
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	inst, err := New(
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return inst, spans, reader
+}
+
+func TestStartOperation(t *testing.T) {
+	inst, spans, reader := newTestInstrumentation(t)
+
+	inst.StartOperation(lumberjack.OpCompress, "foo.log.1")(nil)
+	inst.StartOperation(lumberjack.OpCleanup, "foo.log")(errors.New("boom"))
+
+	ended := spans.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(ended))
+	}
+	if ended[0].Name() != "lumberjack.compress" || ended[0].Status().Code == codes.Error {
+		t.Fatalf("unexpected span %s with status %v", ended[0].Name(), ended[0].Status())
+	}
+	if ended[1].Name() != "lumberjack.cleanup" || ended[1].Status().Code != codes.Error {
+		t.Fatalf("unexpected span %s with status %v", ended[1].Name(), ended[1].Status())
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	hist := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Histogram[float64])
+	if len(hist.DataPoints) != 2 {
+		t.Fatalf("expected 2 data points, got %d", len(hist.DataPoints))
+	}
+}
+
+func TestLoggerInstrumentation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestLoggerInstrumentation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	inst, spans, _ := newTestInstrumentation(t)
+	l := &lumberjack.Logger{
+		Filename:        filepath.Join(dir, "foo.log"),
+		Compress:        true,
+		CompressSync:    true,
+		Instrumentation: inst,
+	}
+	defer l.Close()
+
+	if _, err := l.Write([]byte("boo!")); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	names := make(map[string]bool)
+	for _, s := range spans.Ended() {
+		names[s.Name()] = true
+	}
+	for _, name := range []string{"lumberjack.rotate", "lumberjack.cleanup", "lumberjack.compress"} {
+		if !names[name] {
+			t.Errorf("missing span %s, got %v", name, names)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package lumberjack
+
+// applyOwner 在设置了 Owner 或 Group 时修改 name 的属主，未设置的一项保持不变
+func (l *Logger) applyOwner(name string) error {
+	if l.Owner == 0 && l.Group == 0 {
+		return nil
+	}
+	uid, gid := -1, -1
+	if l.Owner != 0 {
+		uid = l.Owner
+	}
+	if l.Group != 0 {
+		gid = l.Group
+	}
+	return setOwner(name, uid, gid)
+}
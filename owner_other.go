@@ -0,0 +1,9 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package lumberjack
+
+// setOwner 在不支持 uid/gid 的平台上不做任何事
+func setOwner(_ string, _, _ int) error {
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package lumberjack
+
+import "os"
+
+// osChown is a var so we can mock it out during tests.
+var osChown = os.Chown
+
+// setOwner 把 name 的属主设置为 uid、gid，-1 表示保持不变
+func setOwner(name string, uid, gid int) error {
+	return osChown(name, uid, gid)
+}
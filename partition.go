@@ -0,0 +1,47 @@
+package lumberjack
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// partitionLayout 是按日期分区时子目录的时间格式
+const partitionLayout = "2006/01/02"
+
+// newBackupDir 返回本次轮转产生的备份应放入的目录
+func (l *Logger) newBackupDir() string {
+	dir := l.backupDir()
+	if l.PartitionByDate && !l.NumberedBackups {
+		t := l.now().In(l.location())
+		dir = filepath.Join(dir, filepath.FromSlash(t.Format(partitionLayout)))
+	}
+	return dir
+}
+
+// backupDirs 返回需要扫描备份文件的所有目录。按日期分区时，除备份目录本身外
+// 还包括其下所有 YYYY/MM/DD 形式的子目录。
+func (l *Logger) backupDirs() ([]string, error) {
+	dir := l.backupDir()
+	if !l.PartitionByDate || l.NumberedBackups {
+		return []string{dir}, nil
+	}
+	parts, err := l.globDirs(dir, "[0-9][0-9][0-9][0-9]", "[0-9][0-9]", "[0-9][0-9]")
+	if err != nil {
+		return nil, fmt.Errorf("can't list backup partitions: %s", err)
+	}
+	return append([]string{dir}, parts...), nil
+}
+
+// pruneBackupDir 删除按日期分区后变空的子目录，逐级向上直到备份目录本身。
+// 目录非空时删除会失败并停止，因此不会误删任何文件。
+func (l *Logger) pruneBackupDir(dir string) {
+	if !l.PartitionByDate {
+		return
+	}
+	root := filepath.Clean(l.backupDir())
+	for dir = filepath.Clean(dir); dir != root && len(dir) > len(root); dir = filepath.Dir(dir) {
+		if err := l.fsys().Remove(dir); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPartitionByDate(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestPartitionByDate", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:        filename,
+		PartitionByDate: true,
+		MaxBackups:      1,
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	firstDir := filepath.Join(dir, filepath.FromSlash(fakeTime().UTC().Format(partitionLayout)))
+	existsWithContent(backupFile(firstDir), b, t)
+
+	b2 := []byte("foo!")
+	n, err = l.Write(b2)
+	isNil(err, t)
+	equals(len(b2), n, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	secondDir := filepath.Join(dir, filepath.FromSlash(fakeTime().UTC().Format(partitionLayout)))
+
+	// we need to wait a little bit since the files get deleted on a different
+	// goroutine.
+	<-time.After(10 * time.Millisecond)
+
+	// 跨分区的 MaxBackups 生效，旧分区目录被删空后一并移除
+	existsWithContent(backupFile(secondDir), b2, t)
+	notExist(firstDir, t)
+	fileCount(secondDir, 1, t)
+}
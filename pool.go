@@ -0,0 +1,140 @@
+package lumberjack
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// workerPool 是一个可动态调整上限的计数信号量，限制同时进行的任务数
+type workerPool struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int
+	active int
+}
+
+func newWorkerPool(limit int) *workerPool {
+	p := &workerPool{limit: limit}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+// acquire 阻塞直到获得一个空闲名额
+func (p *workerPool) acquire() {
+	p.mu.Lock()
+	for p.active >= p.limit {
+		p.cond.Wait()
+	}
+	p.active++
+	p.mu.Unlock()
+}
+
+// release 归还一个名额
+func (p *workerPool) release() {
+	p.mu.Lock()
+	p.active--
+	p.mu.Unlock()
+	p.cond.Signal()
+}
+
+// setLimit 调整上限，n 小于 1 时视为 1
+func (p *workerPool) setLimit(n int) {
+	if n < 1 {
+		n = 1
+	}
+	p.mu.Lock()
+	p.limit = n
+	p.mu.Unlock()
+	p.cond.Broadcast()
+}
+
+// compressPool 是进程内所有 Logger 共享的压缩名额，
+// 避免大量 Logger 同时轮转时并发压缩占满 CPU 和内存
+var compressPool = newWorkerPool(runtime.NumCPU())
+
+// SetCompressWorkers 设置进程内所有 Logger 合计同时进行的压缩任务上限，
+// 默认为 CPU 核数。n 小于 1 时视为 1。
+func SetCompressWorkers(n int) {
+	compressPool.setLimit(n)
+}
+
+// processBackups 压缩和加密给定的备份文件。单个 Logger 最多同时处理
+// CompressWorkers 个文件（默认 1，即逐个处理），每个压缩任务还需要从共享的
+// compressPool 中取得名额。comp 为 nil 时不压缩，enc 为 nil 时不加密。
+// 返回遇到的第一个错误。
+func (l *Logger) processBackups(files []logInfo, comp Compressor, enc *backupEncrypter) error {
+	workers := l.CompressWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(files) {
+		workers = len(files)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	jobs := make(chan logInfo)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range jobs {
+				if err := l.processBackup(f, comp, enc); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, f := range files {
+		jobs <- f
+	}
+	close(jobs)
+	wg.Wait()
+	return firstErr
+}
+
+// processBackup 依次对单个备份执行压缩（comp 非空且尚未压缩时）和加密
+// （enc 非空且尚未加密时）
+func (l *Logger) processBackup(f logInfo, comp Compressor, enc *backupEncrypter) error {
+	fn := f.path()
+	if comp != nil && !isCompressed(f.Name()) {
+		suffix := comp.Ext()
+		compressPool.acquire()
+		end := l.startOp(OpCompress, fn)
+		start := time.Now()
+		err := compressLogFile(fn, fn+suffix, comp, l.Checksum)
+		end(err)
+		compressPool.release()
+		if err != nil {
+			return err
+		}
+		l.metrics.observeCompression(time.Since(start))
+		if err := l.applyOwner(fn + suffix); err != nil {
+			return fmt.Errorf("failed to chown compressed log file: %v", err)
+		}
+		l.notifyCompress(fn, fn+suffix)
+		fn += suffix
+	}
+	if enc != nil && !isEncrypted(fn) {
+		end := l.startOp(OpEncrypt, fn)
+		err := encryptBackup(fn, enc, l.Checksum, l.retryPolicy())
+		end(err)
+		if err != nil {
+			return err
+		}
+		if err := l.applyOwner(fn + enc.suffix); err != nil {
+			return fmt.Errorf("failed to chown encrypted backup: %v", err)
+		}
+		l.notifyCompress(fn, fn+enc.suffix)
+	}
+	return nil
+}
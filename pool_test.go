@@ -0,0 +1,76 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolLimit(t *testing.T) {
+	p := newWorkerPool(2)
+
+	var (
+		mu          sync.Mutex
+		active, max int
+		wg          sync.WaitGroup
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.acquire()
+			mu.Lock()
+			active++
+			if active > max {
+				max = active
+			}
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			active--
+			mu.Unlock()
+			p.release()
+		}()
+	}
+	wg.Wait()
+	equals(2, max, t)
+}
+
+func TestCompressWorkers(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestCompressWorkers", t)
+	defer os.RemoveAll(dir)
+
+	// 启动前已有多个未压缩的备份
+	data := []byte("data")
+	var backups []string
+	for i := 0; i < 4; i++ {
+		backup := backupFile(dir)
+		isNil(ioutil.WriteFile(backup, data, 0644), t)
+		backups = append(backups, backup)
+		newFakeTime()
+	}
+
+	l := &Logger{
+		Filename:        logFile(dir),
+		Compress:        true,
+		CompressWorkers: 3,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// we need to wait a little bit since the files get compressed on a different
+	// goroutine.
+	<-time.After(300 * time.Millisecond)
+
+	for _, backup := range backups {
+		notExist(backup, t)
+		exists(backup+compressSuffix, t)
+	}
+	fileCount(dir, 5, t)
+}
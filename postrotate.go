@@ -0,0 +1,63 @@
+package lumberjack
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// backupPlaceholder 是 PostRotateCommand 参数中代表备份路径的占位符
+const backupPlaceholder = "{backup}"
+
+// queuePostRotate 登记一个刚轮转出的备份，由之后的后台处理执行 PostRotateCommand
+func (l *Logger) queuePostRotate(name string) {
+	if len(l.PostRotateCommand) == 0 {
+		return
+	}
+	l.postRotate.push(name)
+}
+
+// runPostRotate 对已登记的备份依次执行 PostRotateCommand，失败通过 Errors
+// 通道上报，不会重试
+func (l *Logger) runPostRotate() {
+	for _, name := range l.postRotate.take() {
+		fn := l.processedName(name)
+		if err := l.postRotateCommand(fn); err != nil {
+			l.reportError(OpPostRotate, err)
+		}
+	}
+}
+
+// postRotateCommand 以备份 backup 执行 PostRotateCommand：参数中的 {backup}
+// 替换为备份路径，环境变量 LUMBERJACK_BACKUP 和 LUMBERJACK_LOGFILE 分别为
+// 备份路径和日志文件路径
+func (l *Logger) postRotateCommand(backup string) (err error) {
+	end := l.startOp(OpPostRotate, backup)
+	defer func() { end(err) }()
+
+	args := make([]string, len(l.PostRotateCommand))
+	for i, arg := range l.PostRotateCommand {
+		args[i] = strings.ReplaceAll(arg, backupPlaceholder, backup)
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = append(os.Environ(),
+		"LUMBERJACK_BACKUP="+backup,
+		"LUMBERJACK_LOGFILE="+l.filename(),
+	)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(out.String())
+		if len(msg) > 512 {
+			msg = msg[:512]
+		}
+		if msg != "" {
+			return fmt.Errorf("post-rotate command %s failed: %s: %s", args[0], err, msg)
+		}
+		return fmt.Errorf("post-rotate command %s failed: %s", args[0], err)
+	}
+	return nil
+}
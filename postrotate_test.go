@@ -0,0 +1,77 @@
+package lumberjack
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPostRotateCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	currentTime = fakeTime
+	dir := makeTempDir("TestPostRotateCommand", t)
+	defer os.RemoveAll(dir)
+
+	out := filepath.Join(dir, "out.txt")
+	l := &Logger{
+		Filename:          logFile(dir),
+		Compress:          true,
+		CompressSync:      true,
+		PostRotateCommand: []string{"sh", "-c", `echo "$1 $LUMBERJACK_BACKUP $LUMBERJACK_LOGFILE" > ` + out, "sh", "{backup}"},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// 命令收到的是压缩后的备份
+	backup := backupFile(dir) + compressSuffix
+	existsWithContent(out, []byte(backup+" "+backup+" "+logFile(dir)+"\n"), t)
+}
+
+func TestPostRotateCommandError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	currentTime = fakeTime
+	dir := makeTempDir("TestPostRotateCommandError", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:          logFile(dir),
+		CompressSync:      true,
+		PostRotateCommand: []string{"sh", "-c", "echo oops; exit 3"},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	select {
+	case err := <-l.Errors():
+		var ae *AsyncError
+		assert(errors.As(err, &ae), t, "expected *AsyncError, got %T", err)
+		equals(OpPostRotate, ae.Op, t)
+		assert(strings.Contains(err.Error(), "oops"), t, "expected command output in %q", err)
+	default:
+		t.Fatal("expected post-rotate error")
+	}
+	// 命令失败不影响日志写入
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+	existsWithContent(logFile(dir), []byte("foo!"), t)
+}
+
+func TestPostRotateCommandValidate(t *testing.T) {
+	l := &Logger{PostRotateCommand: []string{"", "{backup}"}}
+	notNil(l.Validate(), t)
+}
@@ -0,0 +1,22 @@
+package lumberjack
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// preallocate 以 F_PREALLOCATE 为 f 预留 size 字节的磁盘空间，文件大小保持不变。
+// 先尝试分配连续空间，失败时退回非连续分配。
+func preallocate(f *os.File, size int64) error {
+	fstore := &unix.Fstore_t{
+		Flags:   unix.F_ALLOCATECONTIG | unix.F_ALLOCATEALL,
+		Posmode: unix.F_PEOFPOSMODE,
+		Length:  size,
+	}
+	if err := unix.FcntlFstore(f.Fd(), unix.F_PREALLOCATE, fstore); err == nil {
+		return nil
+	}
+	fstore.Flags = unix.F_ALLOCATEALL
+	return unix.FcntlFstore(f.Fd(), unix.F_PREALLOCATE, fstore)
+}
@@ -0,0 +1,19 @@
+package lumberjack
+
+import (
+	"os"
+	"syscall"
+)
+
+// fallocKeepSize 即 FALLOC_FL_KEEP_SIZE，只分配空间而不改变文件大小
+const fallocKeepSize = 0x1
+
+// preallocate 为 f 预留 size 字节的磁盘空间，文件大小保持不变
+func preallocate(f *os.File, size int64) error {
+	for {
+		err := syscall.Fallocate(int(f.Fd()), fallocKeepSize, 0, size)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package lumberjack
+
+import (
+	"errors"
+	"os"
+)
+
+// preallocate 在不支持预分配的平台上返回错误，Preallocate 不生效
+func preallocate(f *os.File, size int64) error {
+	return errors.New("preallocation is not supported on this platform")
+}
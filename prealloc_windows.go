@@ -0,0 +1,23 @@
+//go:build windows
+// +build windows
+
+package lumberjack
+
+import (
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// fileAllocationInfo 对应 FILE_ALLOCATION_INFO
+type fileAllocationInfo struct {
+	AllocationSize int64
+}
+
+// preallocate 设置 f 的分配大小，预留 size 字节的磁盘空间而不移动文件末尾
+func preallocate(f *os.File, size int64) error {
+	info := fileAllocationInfo{AllocationSize: size}
+	return windows.SetFileInformationByHandle(windows.Handle(f.Fd()), windows.FileAllocationInfo,
+		(*byte)(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info)))
+}
@@ -0,0 +1,205 @@
+package lumberjack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Processor 是轮转后处理流水线中的一步，对备份 path 执行压缩、加密、上传等
+// 处理。生成新文件的处理器应把新文件命名为 path 加后缀（例如 path.gz），并在
+// 成功后删除 path，流水线据此找到新文件交给下一步；删除备份后流水线结束。
+// 实现必须可以被并发调用。
+type Processor interface {
+	Process(ctx context.Context, path string) error
+}
+
+// ProcessorFunc 把普通函数适配为 Processor
+type ProcessorFunc func(ctx context.Context, path string) error
+
+// Process 调用 f(ctx, path)
+func (f ProcessorFunc) Process(ctx context.Context, path string) error {
+	return f(ctx, path)
+}
+
+// CompressProcessor 返回按 codec（gzip、zstd、zip 或用 RegisterCompressor
+// 注册的名称）和 level 压缩备份的处理器，生成带压缩后缀的文件并删除原文件。
+// 已压缩的备份保持不变。
+func CompressProcessor(codec string, level int) Processor {
+	return ProcessorFunc(func(ctx context.Context, path string) error {
+		if isCompressed(path) {
+			return nil
+		}
+		c, err := newCompressor(strings.ToLower(codec), level)
+		if err != nil {
+			return err
+		}
+		return compressLogFile(path, path+c.Ext(), c, false)
+	})
+}
+
+// ChecksumProcessor 返回为备份写入 .sha256 旁路文件的处理器，可用
+// VerifyChecksum 或 sha256sum -c 校验
+func ChecksumProcessor() Processor {
+	return ProcessorFunc(func(ctx context.Context, path string) error {
+		sum, err := fileChecksum(path)
+		if err != nil {
+			return err
+		}
+		return writeChecksum(path, sum)
+	})
+}
+
+// EncryptProcessor 返回以 32 字节密钥 key 加密备份的处理器，生成 .enc 文件并
+// 删除明文，格式与 Encrypt 相同，可用 DecryptFile 解密
+func EncryptProcessor(key []byte) Processor {
+	return ProcessorFunc(func(ctx context.Context, path string) error {
+		if isEncrypted(path) {
+			return nil
+		}
+		if len(key) != 32 {
+			return fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+		}
+		enc := &backupEncrypter{encSuffix, func(w io.Writer) (io.WriteCloser, error) {
+			return newEncryptWriter(w, key)
+		}}
+		return encryptBackup(path, enc, false, contextRetryPolicy(ctx))
+	})
+}
+
+// UploadProcessor 返回用 u 上传备份的处理器
+func UploadProcessor(u Uploader) Processor {
+	return ProcessorFunc(u.Upload)
+}
+
+// DeleteProcessor 返回删除备份及其校验和旁路文件的处理器，通常作为上传之后
+// 的最后一步，使本地不保留已上传的备份
+func DeleteProcessor() Processor {
+	return ProcessorFunc(func(ctx context.Context, path string) error {
+		return removeBackup(contextRetryPolicy(ctx), path)
+	})
+}
+
+// processStepKey 是 process 传给内置处理器的 context 键，值为 *processStep
+type processStepKey struct{}
+
+// processStep 是流水线中正在执行的一步，内置处理器通过它取得所属的 Logger，
+// 输出文件不按 path 加后缀命名时把它记录在 output 中
+type processStep struct {
+	l      *Logger
+	output string
+}
+
+// contextStep 返回 ctx 中携带的流水线步骤，不在 Logger 的流水线中时返回 nil
+func contextStep(ctx context.Context) *processStep {
+	s, _ := ctx.Value(processStepKey{}).(*processStep)
+	return s
+}
+
+// contextRetryPolicy 返回 ctx 所属 Logger 的重试策略，没有时返回默认策略
+func contextRetryPolicy(ctx context.Context) RetryPolicy {
+	if s := contextStep(ctx); s != nil {
+		return s.l.retryPolicy()
+	}
+	return defaultRetryPolicy
+}
+
+// checkProcessors 检查 Processors 是否与内置的压缩、加密同时配置
+func (l *Logger) checkProcessors() error {
+	if len(l.Processors) > 0 && (l.compression() != "" || l.Encrypt || len(l.AgeRecipients) > 0) {
+		return errors.New("Processors cannot be combined with Compress, Encrypt or AgeRecipients")
+	}
+	return nil
+}
+
+// queueProcess 登记一个刚轮转出的备份，由之后的后台处理执行 Processors
+func (l *Logger) queueProcess(name string) {
+	if len(l.Processors) == 0 {
+		return
+	}
+	l.processing.push(name)
+}
+
+// runProcessors 对已登记的备份依次执行 Processors，失败通过 Errors 通道上报
+func (l *Logger) runProcessors() {
+	for _, name := range l.processing.take() {
+		if err := l.process(l.millContext(), name); err != nil {
+			l.reportError(OpProcess, err)
+		}
+	}
+}
+
+// process 让备份 name 依次经过 Processors。每一步失败后按 ProcessorRetries
+// 和 ProcessorRetryDelay 重试，重试用完后放弃该备份的后续步骤。
+func (l *Logger) process(ctx context.Context, name string) error {
+	for i, p := range l.Processors {
+		info, err := os.Stat(name)
+		if err != nil {
+			// 备份已被清理或由上一步删除
+			return nil
+		}
+		step := &processStep{l: l}
+		stepCtx := context.WithValue(ctx, processStepKey{}, step)
+		end := l.startOp(OpProcess, name)
+		err = retryBackoff(ctx, l.clock(), l.ProcessorRetries, l.ProcessorRetryDelay, func() error {
+			return p.Process(stepCtx, name)
+		})
+		end(err)
+		if err != nil {
+			return fmt.Errorf("processor %d failed on %s: %w", i, name, err)
+		}
+		if step.output != "" {
+			// 处理器自行记录了输出文件，并已发出相应的通知
+			name = step.output
+			continue
+		}
+		next := processorOutput(name)
+		if next != name {
+			l.notifyCompress(name, next)
+			name = next
+		} else if _, err := os.Stat(name); os.IsNotExist(err) {
+			// 这一步删除了备份，例如 DeleteProcessor
+			l.audit(AuditDelete, name, "", info.Size())
+		}
+	}
+	return nil
+}
+
+// processorOutput 返回处理器处理 name 后的文件：name 仍存在时为 name，否则为
+// 同目录下以 name 加后缀命名的最短文件名（校验和旁路文件除外）。找不到时返回
+// name。
+func processorOutput(name string) string {
+	if _, err := os.Stat(name); err == nil {
+		return name
+	}
+	matches, _ := filepath.Glob(globEscape(name) + ".*")
+	out := ""
+	for _, m := range matches {
+		if strings.HasSuffix(m, checksumSuffix) {
+			continue
+		}
+		if out == "" || len(m) < len(out) {
+			out = m
+		}
+	}
+	if out == "" {
+		return name
+	}
+	return out
+}
+
+// globEscape 转义 name 中的通配符，使其在 filepath.Glob 中按字面匹配
+func globEscape(name string) string {
+	var b strings.Builder
+	for _, c := range name {
+		if strings.ContainsRune(`*?[\`, c) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,148 @@
+package lumberjack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestProcessors(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestProcessors", t)
+	defer os.RemoveAll(dir)
+
+	var uploaded []byte
+	var steps [][2]string
+	l := &Logger{
+		Filename:     logFile(dir),
+		CompressSync: true,
+		Processors: []Processor{
+			CompressProcessor("gzip", 0),
+			EncryptProcessor(bytes.Repeat([]byte{7}, 32)),
+			ChecksumProcessor(),
+			ProcessorFunc(func(ctx context.Context, path string) error {
+				isNil(VerifyChecksum(path), t)
+				return nil
+			}),
+			UploadProcessor(UploaderFunc(func(ctx context.Context, path string) error {
+				b, err := os.ReadFile(path)
+				uploaded = b
+				return err
+			})),
+			DeleteProcessor(),
+		},
+		OnCompress: func(src, dst string) {
+			steps = append(steps, [2]string{src, dst})
+		},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	backup := backupFile(dir)
+	isNil(l.Rotate(), t)
+
+	equals([][2]string{
+		{backup, backup + compressSuffix},
+		{backup + compressSuffix, backup + compressSuffix + encSuffix},
+	}, steps, t)
+	assert(len(uploaded) > 0, t, "expected encrypted backup to be uploaded")
+
+	// 上传后备份及其旁路文件已被删除
+	fileCount(dir, 1, t)
+	existsWithContent(logFile(dir), []byte{}, t)
+}
+
+func TestProcessorRetry(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestProcessorRetry", t)
+	defer os.RemoveAll(dir)
+
+	calls, succeedAt := 0, 3
+	l := &Logger{
+		Filename:            logFile(dir),
+		CompressSync:        true,
+		ProcessorRetries:    2,
+		ProcessorRetryDelay: time.Millisecond,
+		Processors: []Processor{
+			ProcessorFunc(func(ctx context.Context, path string) error {
+				calls++
+				if calls != succeedAt {
+					return errors.New("busy")
+				}
+				return nil
+			}),
+		},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	equals(3, calls, t)
+
+	// 重试用完后放弃并上报错误
+	succeedAt = 0
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	equals(6, calls, t)
+	select {
+	case err := <-l.Errors():
+		var ae *AsyncError
+		assert(errors.As(err, &ae), t, "expected *AsyncError, got %T", err)
+		equals(OpProcess, ae.Op, t)
+	default:
+		t.Fatal("expected processor error")
+	}
+}
+
+func TestProcessorsValidate(t *testing.T) {
+	l := &Logger{
+		Compress:   true,
+		Processors: []Processor{DeleteProcessor()},
+	}
+	notNil(l.Validate(), t)
+
+	l = &Logger{ProcessorRetries: -1}
+	notNil(l.Validate(), t)
+}
+
+func TestProcessorRetryPolicy(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestProcessorRetryPolicy", t)
+	defer os.RemoveAll(dir)
+
+	var ops []string
+	l := &Logger{
+		Filename:     logFile(dir),
+		CompressSync: true,
+		RetryPolicy: RetryFunc(func(op string, attempt int, err error) (time.Duration, bool) {
+			ops = append(ops, op)
+			return 0, false
+		}),
+		Processors: []Processor{
+			ProcessorFunc(func(ctx context.Context, path string) error {
+				// 内置处理器从 ctx 取得 Logger 的重试策略
+				contextRetryPolicy(ctx).Retry("probe", 1, errors.New("boom"))
+				return nil
+			}),
+			DeleteProcessor(),
+		},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	equals([]string{"probe"}, ops, t)
+	fileCount(dir, 1, t)
+}
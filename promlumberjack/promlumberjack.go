@@ -0,0 +1,85 @@
+// Package promlumberjack 把 lumberjack.Logger 的运行指标导出为 Prometheus
+// 指标。指标取自 Logger.Stats()，不使用 Prometheus 的程序无需引入该依赖。
+//
+//	logger := &lumberjack.Logger{Filename: "/var/log/myapp/foo.log"}
+//	prometheus.MustRegister(promlumberjack.NewCollector(logger))
+package promlumberjack
+
+import (
+	"github.com/ai-mmo/lumberjack"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector 把一个 Logger 的运行指标导出为 Prometheus 指标
+type Collector struct {
+	l *lumberjack.Logger
+
+	bytesWritten *prometheus.Desc
+	fileSize     *prometheus.Desc
+	rotations    *prometheus.Desc
+	compression  *prometheus.Desc
+	removals     *prometheus.Desc
+	writeErrors  *prometheus.Desc
+	dropped      *prometheus.Desc
+	diskDropped  *prometheus.Desc
+}
+
+// NewCollector 返回导出 l 运行指标的 Collector，注册到 Registry 后即可采集。
+// 所有指标都带有 filename 标签，多个 Logger 可以注册到同一个 Registry：
+//
+//	lumberjack_bytes_written_total            成功写入的字节数
+//	lumberjack_file_size_bytes                当前日志文件的大小
+//	lumberjack_rotations_total                轮转次数
+//	lumberjack_compression_duration_seconds   备份压缩耗时
+//	lumberjack_cleanup_deletions_total        清理时删除的备份数
+//	lumberjack_write_errors_total             返回错误的写入次数
+//	lumberjack_dropped_writes_total           异步队列已满或超过限速而被丢弃的写入数
+//	lumberjack_disk_dropped_writes_total      磁盘空间不足而被丢弃的写入数
+func NewCollector(l *lumberjack.Logger) *Collector {
+	labels := prometheus.Labels{"filename": l.CurrentFile()}
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc("lumberjack_"+name, help, nil, labels)
+	}
+	return &Collector{
+		l:            l,
+		bytesWritten: desc("bytes_written_total", "Total number of bytes written to the log file."),
+		fileSize:     desc("file_size_bytes", "Current size of the active log file in bytes."),
+		rotations:    desc("rotations_total", "Total number of log file rotations."),
+		compression:  desc("compression_duration_seconds", "Time spent compressing rotated log files."),
+		removals:     desc("cleanup_deletions_total", "Total number of old log files deleted by cleanup."),
+		writeErrors:  desc("write_errors_total", "Total number of writes that returned an error."),
+		dropped:      desc("dropped_writes_total", "Total number of writes dropped because the async queue was full or the rate limit was exceeded."),
+		diskDropped:  desc("disk_dropped_writes_total", "Total number of writes dropped because of disk space pressure."),
+	}
+}
+
+// Describe 实现 prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.bytesWritten
+	ch <- c.fileSize
+	ch <- c.rotations
+	ch <- c.compression
+	ch <- c.removals
+	ch <- c.writeErrors
+	ch <- c.dropped
+	ch <- c.diskDropped
+}
+
+// Collect 实现 prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	s := c.l.Stats()
+	counter := func(d *prometheus.Desc, v int64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, float64(v))
+	}
+	counter(c.bytesWritten, s.BytesWritten)
+	ch <- prometheus.MustNewConstMetric(c.fileSize, prometheus.GaugeValue, float64(s.CurrentSize))
+	counter(c.rotations, s.Rotations)
+	ch <- prometheus.MustNewConstSummary(c.compression,
+		uint64(s.Compressions),
+		s.CompressionTime.Seconds(),
+		nil)
+	counter(c.removals, s.Removals)
+	counter(c.writeErrors, s.WriteErrors)
+	counter(c.dropped, s.DroppedWrites)
+	counter(c.diskDropped, s.DiskDropped)
+}
@@ -0,0 +1,92 @@
+package promlumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ai-mmo/lumberjack"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// gatherMetrics 采集注册表中的指标，返回指标名到数值的映射。
+// summary 类型的指标记录观测次数。
+func gatherMetrics(reg *prometheus.Registry, t *testing.T) map[string]float64 {
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[string]float64)
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				values[mf.GetName()] = m.GetCounter().GetValue()
+			case dto.MetricType_GAUGE:
+				values[mf.GetName()] = m.GetGauge().GetValue()
+			case dto.MetricType_SUMMARY:
+				values[mf.GetName()] = float64(m.GetSummary().GetSampleCount())
+			}
+		}
+	}
+	return values
+}
+
+func TestCollector(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestCollector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l := &lumberjack.Logger{
+		Filename:     filepath.Join(dir, "foo.log"),
+		MaxSizeStr:   "10B",
+		MaxBackups:   1,
+		Compress:     true,
+		CompressSync: true,
+	}
+	defer l.Close()
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(NewCollector(l)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{"boo!", "foooooo!"} {
+		if _, err := l.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := l.Write([]byte("123456789012")); err == nil {
+		t.Fatal("expected error for write larger than MaxSize")
+	}
+
+	values := gatherMetrics(reg, t)
+	for name, want := range map[string]float64{
+		"lumberjack_bytes_written_total":          12,
+		"lumberjack_file_size_bytes":              0,
+		"lumberjack_rotations_total":              2,
+		"lumberjack_compression_duration_seconds": 2,
+		"lumberjack_cleanup_deletions_total":      1,
+		"lumberjack_write_errors_total":           1,
+		"lumberjack_dropped_writes_total":         0,
+		"lumberjack_disk_dropped_writes_total":    0,
+	} {
+		if got := values[name]; got != want {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+
+	// 不同文件的 Logger 可以注册到同一个 Registry
+	other := &lumberjack.Logger{Filename: filepath.Join(dir, "other.log")}
+	defer other.Close()
+	if err := reg.Register(NewCollector(other)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,74 @@
+package lumberjack
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimitMode 决定写入速度超过 MaxBytesPerSecond 时如何处理
+type RateLimitMode string
+
+const (
+	// RateLimitBlock 阻塞 Write 直到令牌桶中有足够的额度，不丢弃日志
+	RateLimitBlock RateLimitMode = "block"
+	// RateLimitDrop 丢弃超出额度的写入，Write 立即返回
+	RateLimitDrop RateLimitMode = "drop"
+)
+
+// rateLimiter 是按字节计算的令牌桶，容量为一秒的额度
+type rateLimiter struct {
+	mu     sync.Mutex
+	tokens float64   // 当前可用的字节数，阻塞模式下可以为负，表示已预支的额度
+	last   time.Time // 上次补充令牌的时间，零值表示尚未使用
+}
+
+// throttle 按 MaxBytesPerSecond 为 n 字节的写入申请额度。阻塞模式下等待到
+// 额度足够后返回 true；丢弃模式下额度不足时返回 false，调用方应丢弃本次写入。
+// 调用方不能持有 l.mu，否则等待期间会阻塞其他操作。
+func (l *Logger) throttle(n int) bool {
+	if l.MaxBytesPerSecond <= 0 || n == 0 {
+		return true
+	}
+	rate := float64(l.MaxBytesPerSecond)
+
+	r := &l.rate
+	r.mu.Lock()
+	now := l.now()
+	if r.last.IsZero() {
+		r.tokens = rate
+	} else if elapsed := now.Sub(r.last); elapsed > 0 {
+		r.tokens += elapsed.Seconds() * rate
+		if r.tokens > rate {
+			r.tokens = rate
+		}
+	}
+	r.last = now
+
+	if l.RateLimitMode == RateLimitDrop {
+		// 单次写入超过一秒的额度时，等令牌桶装满后放行，避免永远被丢弃
+		need := float64(n)
+		if need > rate {
+			need = rate
+		}
+		if r.tokens < need {
+			r.mu.Unlock()
+			l.metrics.droppedWrites.Add(1)
+			return false
+		}
+		r.tokens -= float64(n)
+		r.mu.Unlock()
+		return true
+	}
+
+	// 先预支额度再等待，使并发的写入按到达顺序排队
+	r.tokens -= float64(n)
+	deficit := -r.tokens
+	r.mu.Unlock()
+	if deficit <= 0 {
+		return true
+	}
+	timer := l.newTimer(time.Duration(deficit / rate * float64(time.Second)))
+	defer timer.Stop()
+	<-timer.C()
+	return true
+}
@@ -0,0 +1,96 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRateLimitDrop(t *testing.T) {
+	dir := makeTempDir("TestRateLimitDrop", t)
+	defer os.RemoveAll(dir)
+
+	clock := &manualClock{now: fakeTime()}
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:          filename,
+		Clock:             clock,
+		MaxBytesPerSecond: 10,
+		RateLimitMode:     RateLimitDrop,
+	}
+	defer l.Close()
+
+	n, err := l.Write([]byte("0123456789"))
+	isNil(err, t)
+	equals(10, n, t)
+	// 额度已用完，本次写入被丢弃但仍返回成功
+	n, err = l.Write([]byte("abc"))
+	isNil(err, t)
+	equals(3, n, t)
+	existsWithContent(filename, []byte("0123456789"), t)
+	equals(int64(1), l.Stats().DroppedWrites, t)
+
+	// 0.5 秒后补充了 5 字节的额度
+	clock.Advance(500 * time.Millisecond)
+	_, err = l.Write([]byte("abc"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("0123456789abc"), t)
+}
+
+func TestRateLimitBlock(t *testing.T) {
+	dir := makeTempDir("TestRateLimitBlock", t)
+	defer os.RemoveAll(dir)
+
+	clock := &manualClock{now: fakeTime()}
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:          filename,
+		Clock:             clock,
+		MaxBytesPerSecond: 10,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("0123456789"))
+	isNil(err, t)
+
+	clock.mu.Lock()
+	timers := len(clock.timers)
+	clock.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := l.Write([]byte("abcde"))
+		done <- err
+	}()
+
+	// 等待写入方开始等待额度
+	waitFor(func() bool {
+		clock.mu.Lock()
+		defer clock.mu.Unlock()
+		return len(clock.timers) > timers
+	}, t)
+	select {
+	case <-done:
+		t.Fatal("write should block until tokens are available")
+	default:
+	}
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case err := <-done:
+		isNil(err, t)
+	case <-time.After(time.Second):
+		t.Fatal("write did not resume after tokens were refilled")
+	}
+	existsWithContent(filename, []byte("0123456789abcde"), t)
+}
+
+func TestValidateRateLimit(t *testing.T) {
+	l := &Logger{Filename: "foo.log", MaxBytesPerSecond: -1}
+	notNil(l.Validate(), t)
+
+	l = &Logger{Filename: "foo.log", RateLimitMode: "bogus"}
+	notNil(l.Validate(), t)
+
+	l = &Logger{Filename: "foo.log", MaxBytesPerSecond: 1 << 20, RateLimitMode: RateLimitDrop}
+	isNil(l.Validate(), t)
+}
@@ -0,0 +1,72 @@
+package lumberjack
+
+import (
+	"bytes"
+	"strconv"
+	"unicode/utf8"
+)
+
+// prepareRecord 依次按 Transforms、脱敏配置、MaxLineBytes 与 AppendNewline
+// 处理一次写入的数据，不需要处理时返回 p 本身
+func (l *Logger) prepareRecord(p []byte) ([]byte, error) {
+	q, err := l.applyTransforms(p)
+	if err != nil {
+		return nil, err
+	}
+	q = l.truncateRecord(q)
+	if l.AppendNewline && len(q) > 0 && q[len(q)-1] != '\n' {
+		if l.transforming() || len(q) == len(p) {
+			// 不能修改调用方的切片，Transform 也可能原样返回它
+			q = append(make([]byte, 0, len(q)+1), q...)
+		}
+		q = append(q, '\n')
+	}
+	return q, nil
+}
+
+// truncateRecord 在 p 去掉末尾换行后超过 MaxLineBytes 时返回截断后的记录：
+// 保留前 MaxLineBytes 字节（不拆开 UTF-8 字符），追加
+// "...[truncated N bytes]" 标记，并保留原有的末尾换行。不需要截断时返回 p。
+func (l *Logger) truncateRecord(p []byte) []byte {
+	if l.MaxLineBytes <= 0 || len(p) <= l.MaxLineBytes {
+		return p
+	}
+	body, nl := p, []byte(nil)
+	if bytes.HasSuffix(body, []byte{'\n'}) {
+		body, nl = body[:len(body)-1], []byte{'\n'}
+		if bytes.HasSuffix(body, []byte{'\r'}) {
+			body, nl = body[:len(body)-1], []byte("\r\n")
+		}
+	}
+	if len(body) <= l.MaxLineBytes {
+		return p
+	}
+
+	keep := l.MaxLineBytes
+	// 最多回退 utf8.UTFMax-1 个字节找到字符起始位置，非 UTF-8 内容按字节截断
+	for i := keep; i > 0 && i > keep-utf8.UTFMax; i-- {
+		if utf8.RuneStart(body[i]) {
+			keep = i
+			break
+		}
+	}
+
+	out := make([]byte, 0, keep+32+len(nl))
+	out = append(out, body[:keep]...)
+	out = append(out, "...[truncated "...)
+	out = strconv.AppendInt(out, int64(len(body)-keep), 10)
+	out = append(out, " bytes]"...)
+	return append(out, nl...)
+}
+
+// recordN 把写入处理后的记录 q 得到的字节数 n 换算为调用方传入的 p 的字节数，
+// 写入成功时视为 p 已全部写入
+func recordN(p, q []byte, n int, err error) int {
+	if len(q) == len(p) {
+		return n
+	}
+	if err == nil || n > len(p) {
+		return len(p)
+	}
+	return n
+}
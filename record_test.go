@@ -0,0 +1,76 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMaxLineBytes(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestMaxLineBytes", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		MaxLineBytes: 5,
+	}
+	defer l.Close()
+
+	b := []byte("0123456789\n")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+
+	// 不超过限制的写入（不计末尾换行）原样写入
+	_, err = l.Write([]byte("abcde\n"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("01234...[truncated 5 bytes]\nabcde\n"), t)
+}
+
+func TestTruncateRecord(t *testing.T) {
+	l := &Logger{MaxLineBytes: 4}
+	tests := []struct {
+		in, out string
+	}{
+		{"abcd", "abcd"},
+		{"abcd\r\n", "abcd\r\n"},
+		{"abcdef", "abcd...[truncated 2 bytes]"},
+		{"abcdef\r\n", "abcd...[truncated 2 bytes]\r\n"},
+		// 不拆开多字节字符
+		{"ab日本", "ab...[truncated 6 bytes]"},
+	}
+	for _, tt := range tests {
+		equals(tt.out, string(l.truncateRecord([]byte(tt.in))), t)
+	}
+}
+
+func TestAppendNewline(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestAppendNewline", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:      filename,
+		AppendNewline: true,
+		MaxLineBytes:  5,
+	}
+	defer l.Close()
+
+	b := []byte("foo")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+	// 调用方的切片不被修改
+	equals("foo", string(b), t)
+
+	_, err = l.Write([]byte("bar\n"))
+	isNil(err, t)
+	_, err = l.Write([]byte("0123456789"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("foo\nbar\n01234...[truncated 5 bytes]\n"), t)
+	equals(int64(len("foo\nbar\n01234...[truncated 5 bytes]\n")), l.CurrentSize(), t)
+}
@@ -0,0 +1,70 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// tmpSuffix 是打包、更新符号链接等过程中使用的临时文件后缀
+const tmpSuffix = ".tmp"
+
+// recoverPartial 清理上次进程异常退出时残留在备份目录中的中间文件，在首次
+// 打开日志文件时执行一次：
+//
+//   - 以日志文件名开头的 .tmp 临时文件直接删除；
+//   - 原始备份与其压缩（或加密）文件同时存在，说明处理没有完成（原文件只在
+//     成功后才删除），此时删除可能被截断的结果文件及其校验和旁路文件，后台
+//     会基于完好的原文件重新处理。
+func (l *Logger) recoverPartial() {
+	if l.customFS() {
+		// 中间文件只会由本地文件系统上的压缩、加密和打包产生
+		return
+	}
+	l.backupMu.Lock()
+	defer l.backupMu.Unlock()
+
+	dirs, err := l.backupDirs()
+	if err != nil {
+		l.logDebug("扫描残留文件失败: %v，文件: %s", err, l.filename())
+		return
+	}
+	base := filepath.Base(l.filename())
+	stem := base[:len(base)-len(filepath.Ext(base))]
+
+	for _, dir := range dirs {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			l.logDebug("扫描残留文件失败: %v，目录: %s", err, dir)
+			continue
+		}
+		names := make(map[string]bool, len(files))
+		for _, f := range files {
+			if !f.IsDir() {
+				names[f.Name()] = true
+			}
+		}
+		for name := range names {
+			if !strings.HasPrefix(name, stem) {
+				continue
+			}
+			path := filepath.Join(dir, name)
+			if strings.HasSuffix(name, tmpSuffix) {
+				if err := removeBackup(l.retryPolicy(), path); err == nil {
+					l.logDebug("删除残留的临时文件: %s", path)
+				}
+				continue
+			}
+			if !isCompressed(name) {
+				continue
+			}
+			orig := sourceName(name)
+			if !names[orig] || (orig == base && dir == l.dir()) {
+				continue
+			}
+			if err := removeBackup(l.retryPolicy(), path); err == nil {
+				l.logDebug("删除未完成的压缩或加密文件: %s", path)
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecoverPartialCompression(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestRecoverPartialCompression", t)
+	defer os.RemoveAll(dir)
+
+	// 模拟压缩中途崩溃：原始备份完好，压缩文件被截断，另有一个残留的临时文件
+	backup := backupFile(dir)
+	isNil(ioutil.WriteFile(backup, []byte("original"), 0644), t)
+	isNil(ioutil.WriteFile(backup+compressSuffix, []byte("trunc"), 0644), t)
+	isNil(ioutil.WriteFile(backup+compressSuffix+checksumSuffix, []byte("bogus"), 0644), t)
+	tmp := filepath.Join(dir, "foobar-2000-01-01.tar.gz"+tmpSuffix)
+	isNil(ioutil.WriteFile(tmp, []byte("partial"), 0644), t)
+	// 与日志文件名无关的文件不受影响
+	other := filepath.Join(dir, "other.log.tmp")
+	isNil(ioutil.WriteFile(other, []byte("keep"), 0644), t)
+
+	l := &Logger{
+		Filename: logFile(dir),
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("foo"))
+	isNil(err, t)
+
+	notExist(tmp, t)
+	notExist(backup+compressSuffix, t)
+	notExist(backup+compressSuffix+checksumSuffix, t)
+	existsWithContent(backup, []byte("original"), t)
+	exists(other, t)
+}
+
+func TestRecoverPartialRecompress(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestRecoverPartialRecompress", t)
+	defer os.RemoveAll(dir)
+
+	backup := backupFile(dir)
+	isNil(ioutil.WriteFile(backup, []byte("original"), 0644), t)
+	isNil(ioutil.WriteFile(backup+compressSuffix, []byte("trunc"), 0644), t)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		Compress: true,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("foo"))
+	isNil(err, t)
+
+	// 后台基于原文件重新压缩
+	<-time.After(300 * time.Millisecond)
+	notExist(backup, t)
+	exists(backup+compressSuffix, t)
+	fileCount(dir, 2, t)
+}
@@ -0,0 +1,87 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 常用的脱敏正则表达式，可以直接放入 RedactPatterns
+const (
+	// RedactEmail 匹配电子邮件地址
+	RedactEmail = `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`
+	// RedactBearerToken 匹配 HTTP Authorization 头中的 Bearer 令牌
+	RedactBearerToken = `(?i)bearer\s+[A-Za-z0-9._~+/-]+=*`
+	// RedactAWSAccessKey 匹配 AWS 访问密钥 ID
+	RedactAWSAccessKey = `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`
+)
+
+// defaultRedactReplacement 是 RedactReplacement 为空时使用的替换文本
+const defaultRedactReplacement = "[REDACTED]"
+
+// RedactRegexp 返回把 patterns 匹配到的内容替换为 replacement 的 Transform，
+// replacement 按字面使用，不展开 $1 等分组引用
+func RedactRegexp(replacement string, patterns ...*regexp.Regexp) Transform {
+	repl := []byte(replacement)
+	return func(p []byte) []byte {
+		for _, re := range patterns {
+			p = re.ReplaceAllLiteral(p, repl)
+		}
+		return p
+	}
+}
+
+// RedactJSONKeys 返回脱敏 JSON 行中指定键的 Transform：键名（不区分大小写，
+// 任意嵌套层级）为 keys 之一的字符串、数字、布尔值或 null 被替换为字符串
+// replacement，对象和数组类型的值保持不变。只做词法匹配，不解析整行，因此
+// 保留原有的键顺序和格式，非 JSON 的行中形如 "key": value 的内容同样会被脱敏。
+func RedactJSONKeys(replacement string, keys ...string) Transform {
+	if len(keys) == 0 {
+		return func(p []byte) []byte { return p }
+	}
+	quoted := make([]string, len(keys))
+	for i, k := range keys {
+		quoted[i] = regexp.QuoteMeta(k)
+	}
+	re := regexp.MustCompile(`(?i)("(?:` + strings.Join(quoted, "|") + `)"\s*:\s*)` +
+		`(?:"(?:[^"\\]|\\.)*"|-?[0-9][0-9.eE+-]*|true|false|null)`)
+	// json.Marshal 不会因字符串失败
+	value, _ := json.Marshal(replacement)
+	return func(p []byte) []byte {
+		return re.ReplaceAllFunc(p, func(m []byte) []byte {
+			prefix := re.FindSubmatch(m)[1]
+			out := make([]byte, 0, len(prefix)+len(value))
+			return append(append(out, prefix...), value...)
+		})
+	}
+}
+
+// redactor 返回按 RedactPatterns 和 RedactKeys 脱敏的 Transform，未配置时
+// 返回 nil。正则表达式只编译一次。
+func (l *Logger) redactor() (Transform, error) {
+	if len(l.RedactPatterns) == 0 && len(l.RedactKeys) == 0 {
+		return nil, nil
+	}
+	l.redactOnce.Do(func() {
+		repl := l.RedactReplacement
+		if repl == "" {
+			repl = defaultRedactReplacement
+		}
+		var patterns []*regexp.Regexp
+		for _, s := range l.RedactPatterns {
+			re, err := regexp.Compile(s)
+			if err != nil {
+				l.redactErr = fmt.Errorf("invalid redact pattern %q: %s", s, err)
+				return
+			}
+			patterns = append(patterns, re)
+		}
+		keys := RedactJSONKeys(repl, l.RedactKeys...)
+		regexps := RedactRegexp(repl, patterns...)
+		l.redact = func(p []byte) []byte {
+			return regexps(keys(p))
+		}
+	})
+	return l.redact, l.redactErr
+}
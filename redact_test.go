@@ -0,0 +1,66 @@
+package lumberjack
+
+import (
+	"os"
+	"regexp"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestRedact", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:       filename,
+		RedactPatterns: []string{RedactEmail, RedactBearerToken},
+		RedactKeys:     []string{"password", "token"},
+		AppendNewline:  true,
+	}
+	defer l.Close()
+
+	b := []byte(`{"user":"bob@example.com","Password":"s3cr\"et","token":12345,"auth":"Bearer abc.def"}`)
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+	existsWithContent(filename, []byte(`{"user":"[REDACTED]","Password":"[REDACTED]","token":"[REDACTED]","auth":"[REDACTED]"}`+"\n"), t)
+}
+
+func TestRedactJSONKeys(t *testing.T) {
+	redact := RedactJSONKeys("***", "secret")
+	tests := []struct {
+		in, out string
+	}{
+		{`{"secret": "x", "a": 1}`, `{"secret": "***", "a": 1}`},
+		{`{"a":{"SECRET":true}}`, `{"a":{"SECRET":"***"}}`},
+		// 对象和数组类型的值保持不变
+		{`{"secret":{"a":1}}`, `{"secret":{"a":1}}`},
+		{`secret=x`, `secret=x`},
+	}
+	for _, tt := range tests {
+		equals(tt.out, string(redact([]byte(tt.in))), t)
+	}
+}
+
+func TestRedactRegexpLiteral(t *testing.T) {
+	redact := RedactRegexp("$1", regexp.MustCompile(`(a)b`))
+	equals("x$1x", string(redact([]byte("xabx"))), t)
+}
+
+func TestRedactInvalidPattern(t *testing.T) {
+	dir := makeTempDir("TestRedactInvalidPattern", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:       logFile(dir),
+		RedactPatterns: []string{"("},
+	}
+	defer l.Close()
+
+	notNil(l.Validate(), t)
+	_, err := l.Write([]byte("foo"))
+	notNil(err, t)
+	fileCount(dir, 0, t)
+}
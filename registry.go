@@ -0,0 +1,85 @@
+package lumberjack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// registry 保存通过 Register 登记的 Logger
+var registry = struct {
+	sync.Mutex
+	loggers map[string]*Logger
+}{loggers: make(map[string]*Logger)}
+
+// Register 以 name 登记 l，使 CloseAll 在优雅退出时一并关闭它，适合同时使用
+// 大量 Logger 的应用。name 已被登记时返回错误。
+func Register(name string, l *Logger) error {
+	if l == nil {
+		return errors.New("nil Logger")
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.loggers[name]; ok {
+		return fmt.Errorf("logger %q already registered", name)
+	}
+	registry.loggers[name] = l
+	return nil
+}
+
+// Unregister 取消登记 name，不关闭对应的 Logger
+func Unregister(name string) {
+	registry.Lock()
+	defer registry.Unlock()
+	delete(registry.loggers, name)
+}
+
+// Lookup 返回以 name 登记的 Logger，未登记时返回 nil
+func Lookup(name string) *Logger {
+	registry.Lock()
+	defer registry.Unlock()
+	return registry.loggers[name]
+}
+
+// Registered 返回所有已登记的名称，按字典序排列
+func Registered() []string {
+	registry.Lock()
+	defer registry.Unlock()
+	names := make([]string, 0, len(registry.loggers))
+	for name := range registry.loggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CloseAll 并发地以 CloseContext 关闭并取消登记所有已登记的 Logger，等待
+// 它们写完异步队列并完成排队的压缩与清理。返回所有关闭错误的合并结果；
+// ctx 结束时不再等待，尚未关闭完的 Logger 返回 ctx.Err()，关闭仍在后台继续。
+func CloseAll(ctx context.Context) error {
+	registry.Lock()
+	loggers := registry.loggers
+	registry.loggers = make(map[string]*Logger)
+	registry.Unlock()
+
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+	)
+	for name, l := range loggers {
+		wg.Add(1)
+		go func(name string, l *Logger) {
+			defer wg.Done()
+			if err := l.CloseContext(ctx); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("close %s: %w", name, err))
+				mu.Unlock()
+			}
+		}(name, l)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
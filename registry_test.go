@@ -0,0 +1,55 @@
+package lumberjack
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestRegistry", t)
+	defer os.RemoveAll(dir)
+	defer CloseAll(context.Background())
+
+	a := &Logger{Filename: filepath.Join(dir, "a.log")}
+	b := &Logger{Filename: filepath.Join(dir, "b.log"), AsyncQueue: 4}
+	isNil(Register("a", a), t)
+	isNil(Register("b", b), t)
+	notNil(Register("a", b), t)
+	notNil(Register("c", nil), t)
+
+	equals([]string{"a", "b"}, Registered(), t)
+	assert(Lookup("a") == a, t, "expected registered logger")
+	assert(Lookup("missing") == nil, t, "expected nil for unknown name")
+
+	_, err := a.Write([]byte("boo!"))
+	isNil(err, t)
+	_, err = b.Write([]byte("foo!"))
+	isNil(err, t)
+
+	isNil(CloseAll(context.Background()), t)
+	equals(0, len(Registered()), t)
+
+	// 关闭前异步队列中的数据已写完
+	existsWithContent(filepath.Join(dir, "b.log"), []byte("foo!"), t)
+	_, err = a.Write([]byte("late"))
+	notNil(err, t)
+}
+
+func TestUnregister(t *testing.T) {
+	dir := makeTempDir("TestUnregister", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: filepath.Join(dir, "a.log")}
+	defer l.Close()
+	isNil(Register("TestUnregister", l), t)
+	Unregister("TestUnregister")
+	assert(Lookup("TestUnregister") == nil, t, "expected logger to be unregistered")
+	isNil(CloseAll(context.Background()), t)
+
+	// 取消登记的 Logger 不受 CloseAll 影响
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+}
@@ -0,0 +1,138 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// NotifyReopen 在进程收到 sigs 中的任一信号时让 l 切换日志文件，与 Unix 守护
+// 进程收到 SIGHUP 后重新打开日志的惯例一致，例如：
+//
+//	stop := lumberjack.NotifyReopen(l, syscall.SIGHUP)
+//	defer stop()
+//
+// 若日志文件已被外部工具（如 logrotate）移走或删除，关闭旧文件并在原路径重新
+// 打开，不再向已被移走的文件写入；否则执行一次 Rotate。切换失败时错误通过
+// Errors 上报。Logger 已关闭时忽略信号。
+//
+// 返回的函数停止监听信号，可以重复调用。未指定任何信号时不做任何事。
+func NotifyReopen(l *Logger, sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		return func() {}
+	}
+
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, sigs...)
+
+	go func() {
+		for {
+			select {
+			case <-c:
+				if err := l.reopenOrRotate(); err != nil {
+					l.reportError(OpRotate, err)
+					l.logDebug("收到信号后切换日志文件失败: %v，文件: %s", err, l.filename())
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(c)
+			close(done)
+		})
+	}
+}
+
+// Reopen 关闭当前文件并在日志文件路径上重新打开，不做轮转、不产生备份，相当
+// 于 nginx 收到 USR1 后的行为。适合在外部工具移走日志文件、或修正了文件权限
+// 和属主之后调用：路径上已有文件时追加写入，否则新建。Logger 已关闭时返回错误。
+func (l *Logger) Reopen() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return errClosed
+	}
+	unlock, err := l.lockShared()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := l.reopen(); err != nil {
+		return fmt.Errorf("can't reopen log file: %s", err)
+	}
+	return nil
+}
+
+// reopenOrRotate 在日志文件已被外部移走时重新打开，否则执行轮转
+func (l *Logger) reopenOrRotate() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil
+	}
+	if l.fileMoved() {
+		return l.reopen()
+	}
+	unlock, err := l.lockShared()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return l.rotate()
+}
+
+// checkMoved 在配置了 ReopenCheckInterval 时定期检查日志文件是否已被外部
+// 移走或删除，是则在原路径重新打开。调用方必须持有 l.mu。
+func (l *Logger) checkMoved() error {
+	if l.ReopenCheckInterval <= 0 || l.file == nil {
+		return nil
+	}
+	now := l.now()
+	if !l.lastMoveCheck.IsZero() && now.Sub(l.lastMoveCheck) < l.ReopenCheckInterval {
+		return nil
+	}
+	l.lastMoveCheck = now
+
+	if !l.fileMoved() {
+		return nil
+	}
+	l.logDebug("日志文件已被外部移走，重新打开: %s", l.filename())
+	if err := l.reopen(); err != nil {
+		return fmt.Errorf("can't reopen moved log file: %s", err)
+	}
+	return nil
+}
+
+// fileMoved 判断当前打开的文件是否已不在日志文件路径上，即已被外部重命名或
+// 删除。无法确定时返回 false。
+func (l *Logger) fileMoved() bool {
+	if l.file == nil || l.customFS() {
+		return false
+	}
+	held, err := l.file.Stat()
+	if err != nil {
+		return false
+	}
+	info, err := osStat(l.activeName())
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+	return !os.SameFile(held, info)
+}
+
+// reopen 关闭当前文件并在日志文件路径上重新打开，不产生备份
+func (l *Logger) reopen() error {
+	if err := l.close(); err != nil {
+		return err
+	}
+	return l.openExistingOrNew(0)
+}
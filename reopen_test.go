@@ -0,0 +1,123 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReopenOrRotate(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestReopenOrRotate", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// 文件仍在原位置时执行正常轮转
+	isNil(l.reopenOrRotate(), t)
+	existsWithContent(backupFile(dir), []byte("boo!"), t)
+	fileCount(dir, 2, t)
+
+	// 模拟 logrotate 把日志文件移走
+	_, err = l.Write([]byte("foo"))
+	isNil(err, t)
+	moved := filename + ".1"
+	isNil(os.Rename(filename, moved), t)
+
+	isNil(l.reopenOrRotate(), t)
+	existsWithContent(moved, []byte("foo"), t)
+	_, err = l.Write([]byte("bar"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("bar"), t)
+	existsWithContent(moved, []byte("foo"), t)
+	fileCount(dir, 3, t)
+
+	// 关闭后忽略
+	isNil(l.Close(), t)
+	isNil(l.reopenOrRotate(), t)
+	fileCount(dir, 3, t)
+}
+
+func TestReopenCheckInterval(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestReopenCheckInterval", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:            filename,
+		ReopenCheckInterval: time.Minute,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	moved := filename + ".1"
+	isNil(os.Rename(filename, moved), t)
+
+	// 未到检查间隔，仍写入被移走的文件
+	_, err = l.Write([]byte("foo"))
+	isNil(err, t)
+	existsWithContent(moved, []byte("boo!foo"), t)
+	notExist(filename, t)
+
+	newFakeTime()
+	_, err = l.Write([]byte("bar"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("bar"), t)
+	existsWithContent(moved, []byte("boo!foo"), t)
+
+	// 被删除时同样重新创建
+	isNil(os.Remove(filename), t)
+	newFakeTime()
+	_, err = l.Write([]byte("baz"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("baz"), t)
+	equals(int64(3), l.CurrentSize(), t)
+}
+
+func TestReopen(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestReopen", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// 文件仍在原位置时追加写入，不产生备份
+	isNil(l.Reopen(), t)
+	_, err = l.Write([]byte("foo"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("boo!foo"), t)
+	fileCount(dir, 1, t)
+
+	// 文件被外部移走后在原路径重新创建
+	moved := filename + ".1"
+	isNil(os.Rename(filename, moved), t)
+	isNil(l.Reopen(), t)
+	_, err = l.Write([]byte("bar"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("bar"), t)
+	existsWithContent(moved, []byte("boo!foo"), t)
+	fileCount(dir, 2, t)
+
+	isNil(l.Close(), t)
+	notNil(l.Reopen(), t)
+}
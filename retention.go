@@ -0,0 +1,96 @@
+package lumberjack
+
+import "time"
+
+// hasGFS 判断是否配置了祖父-父-子（GFS）保留策略
+func (l *Logger) hasGFS() bool {
+	return l.KeepDaily > 0 || l.KeepWeekly > 0 || l.KeepMonthly > 0
+}
+
+// applyGFS 按 KeepDaily、KeepWeekly、KeepMonthly 划分 files（按时间从新到旧
+// 排列）：每个日、周（ISO 周）、月各保留其中最新的一个备份，分别保留最近的
+// N、M、K 个周期。一个备份只要被任一层级保留就不会被删除。日期取自备份
+// 文件名中的时间戳，即按 LocalTime 决定的时区计算。
+func (l *Logger) applyGFS(files []logInfo) (remaining, remove []logInfo) {
+	type tier struct {
+		keep int
+		key  func(t time.Time) int
+		seen map[int]bool
+	}
+	tiers := []*tier{
+		{keep: l.KeepDaily, key: func(t time.Time) int {
+			y, m, d := t.Date()
+			return y*10000 + int(m)*100 + d
+		}},
+		{keep: l.KeepWeekly, key: func(t time.Time) int {
+			y, w := t.ISOWeek()
+			return y*100 + w
+		}},
+		{keep: l.KeepMonthly, key: func(t time.Time) int {
+			return t.Year()*100 + int(t.Month())
+		}},
+	}
+	for _, t := range tiers {
+		t.seen = make(map[int]bool)
+	}
+
+	for _, f := range files {
+		kept := false
+		for _, t := range tiers {
+			k := t.key(f.timestamp)
+			if t.seen[k] || len(t.seen) >= t.keep {
+				continue
+			}
+			t.seen[k] = true
+			kept = true
+		}
+		if kept {
+			remaining = append(remaining, f)
+		} else {
+			remove = append(remove, f)
+		}
+	}
+	return remaining, remove
+}
+
+// ThinKeep 的可选值
+const (
+	// ThinKeepFirst 每天保留最早的备份
+	ThinKeepFirst = "first"
+	// ThinKeepLast 每天保留最新的备份
+	ThinKeepLast = "last"
+)
+
+// applyThinning 对早于 ThinAfter 的备份按自然日稀疏化：每天只保留最早（
+// ThinKeep 为 first 或空）或最新（last）的一个，其余删除。files 按时间从新到
+// 旧排列，日期取自备份文件名中的时间戳。
+func (l *Logger) applyThinning(files []logInfo) (remaining, remove []logInfo) {
+	cutoff := l.now().Add(-l.ThinAfter)
+	day := func(t time.Time) int {
+		y, m, d := t.Date()
+		return y*10000 + int(m)*100 + d
+	}
+
+	// 每天要保留的备份的下标。从新到旧遍历时，最先遇到的是当天最新的备份，
+	// 最后遇到的是当天最早的备份。
+	keep := make(map[int]int)
+	for i, f := range files {
+		if !f.timestamp.Before(cutoff) {
+			continue
+		}
+		k := day(f.timestamp)
+		if _, ok := keep[k]; ok && l.ThinKeep == ThinKeepLast {
+			continue
+		}
+		keep[k] = i
+	}
+
+	for i, f := range files {
+		if f.timestamp.Before(cutoff) && keep[day(f.timestamp)] != i {
+			remove = append(remove, f)
+		} else {
+			remaining = append(remaining, f)
+		}
+	}
+	return remaining, remove
+}
@@ -0,0 +1,141 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// makeBackups 在 dir 中为 times 中的每个时间创建一个备份文件，时间戳按 ts
+// 自身的时区格式化，返回文件名
+func makeBackups(dir string, t testing.TB, times ...time.Time) []string {
+	var names []string
+	for _, ts := range times {
+		name := backupName(dir, logFile(dir), ts.Format(backupTimeFormat))
+		isNil(os.WriteFile(name, []byte("boo!"), 0644), t)
+		names = append(names, filepath.Base(name))
+	}
+	return names
+}
+
+// remainingBackups 返回 dir 中除当前日志文件外的文件名
+func remainingBackups(dir string, t testing.TB) []string {
+	entries, err := os.ReadDir(dir)
+	isNil(err, t)
+	var names []string
+	for _, e := range entries {
+		if e.Name() != filepath.Base(logFile(dir)) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestGFSRetention(t *testing.T) {
+	dir := makeTempDir("TestGFSRetention", t)
+	defer os.RemoveAll(dir)
+
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2024, month, day, hour, 0, 0, 0, time.UTC)
+	}
+	names := makeBackups(dir, t,
+		at(3, 20, 12), // 周三，ISO 第 12 周
+		at(3, 20, 6),
+		at(3, 19, 12),
+		at(3, 18, 12),
+		at(3, 17, 12), // 周日，第 11 周
+		at(3, 12, 12),
+		at(3, 5, 12), // 第 10 周
+		at(2, 20, 12),
+		at(1, 10, 12),
+	)
+
+	l := &Logger{Filename: logFile(dir), KeepDaily: 3, KeepWeekly: 2, KeepMonthly: 2}
+	defer l.Close()
+	removed, err := l.Purge()
+	isNil(err, t)
+	equals(4, len(removed), t)
+
+	// 每日：3-20、3-19、3-18；每周：第 12 周、第 11 周（3-17）；每月：3 月、2 月
+	want := []string{names[0], names[2], names[3], names[4], names[7]}
+	sort.Strings(want)
+	equals(want, remainingBackups(dir, t), t)
+}
+
+func TestGFSRetentionLocalTime(t *testing.T) {
+	dir := makeTempDir("TestGFSRetentionLocalTime", t)
+	defer os.RemoveAll(dir)
+
+	// 两个备份在 UTC 下属于同一天，在 UTC+8 下分属两天
+	loc := time.Local
+	time.Local = time.FixedZone("UTC+8", 8*3600)
+	defer func() { time.Local = loc }()
+	makeBackups(dir, t,
+		time.Date(2024, 3, 20, 20, 0, 0, 0, time.UTC).In(time.Local),
+		time.Date(2024, 3, 20, 10, 0, 0, 0, time.UTC).In(time.Local),
+	)
+
+	l := &Logger{Filename: logFile(dir), KeepDaily: 2, LocalTime: true}
+	defer l.Close()
+	removed, err := l.Purge()
+	isNil(err, t)
+	equals(0, len(removed), t)
+}
+
+func TestGFSRetentionSameDay(t *testing.T) {
+	dir := makeTempDir("TestGFSRetentionSameDay", t)
+	defer os.RemoveAll(dir)
+
+	names := makeBackups(dir, t,
+		time.Date(2024, 3, 20, 20, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 20, 10, 0, 0, 0, time.UTC),
+	)
+	l := &Logger{Filename: logFile(dir), KeepDaily: 2}
+	defer l.Close()
+	removed, err := l.Purge()
+	isNil(err, t)
+	equals([]string{filepath.Join(dir, names[1])}, removed, t)
+}
+
+func TestThinning(t *testing.T) {
+	for _, keep := range []string{"", ThinKeepFirst, ThinKeepLast} {
+		t.Run(keep, func(t *testing.T) {
+			dir := makeTempDir("TestThinning"+keep, t)
+			defer os.RemoveAll(dir)
+
+			at := func(day, hour int) time.Time {
+				return time.Date(2024, 3, day, hour, 0, 0, 0, time.UTC)
+			}
+			names := makeBackups(dir, t,
+				at(20, 18), // 最近 48 小时内，全部保留
+				at(20, 6),
+				at(18, 20),
+				at(18, 12),
+				at(18, 2),
+				at(17, 12),
+			)
+
+			l := &Logger{Filename: logFile(dir), ThinAfter: 48 * time.Hour, ThinKeep: keep}
+			l.Clock = &manualClock{now: at(21, 0)}
+			defer l.Close()
+			removed, err := l.Purge()
+			isNil(err, t)
+			equals(2, len(removed), t)
+
+			want := []string{names[0], names[1], names[4], names[5]}
+			if keep == ThinKeepLast {
+				want = []string{names[0], names[1], names[2], names[5]}
+			}
+			sort.Strings(want)
+			equals(want, remainingBackups(dir, t), t)
+		})
+	}
+}
+
+func TestThinKeepValidate(t *testing.T) {
+	l := &Logger{ThinAfter: time.Hour, ThinKeep: "middle"}
+	notNil(l.Validate(), t)
+}
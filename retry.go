@@ -0,0 +1,156 @@
+package lumberjack
+
+import (
+	"os"
+	"time"
+)
+
+// 传给 RetryPolicy 的文件操作名称
+const (
+	RetryOpOpen   = "open"   // 打开日志文件
+	RetryOpRename = "rename" // 轮转时重命名日志文件或备份
+	RetryOpRemove = "remove" // 清理时删除备份
+)
+
+// RetryPolicy 决定文件操作失败后是否重试以及重试前等待多久。Retry 在操作
+// op（RetryOpOpen、RetryOpRename 或 RetryOpRemove）第 attempt 次（从 1 开始）
+// 失败并返回 err 后调用，retry 为 false 时不再重试并返回 err。实现必须可以
+// 被并发调用。
+type RetryPolicy interface {
+	Retry(op string, attempt int, err error) (wait time.Duration, retry bool)
+}
+
+// RetryFunc 把普通函数适配为 RetryPolicy
+type RetryFunc func(op string, attempt int, err error) (wait time.Duration, retry bool)
+
+// Retry 调用 f
+func (f RetryFunc) Retry(op string, attempt int, err error) (time.Duration, bool) {
+	return f(op, attempt, err)
+}
+
+// NoRetry 是从不重试的 RetryPolicy
+var NoRetry RetryPolicy = RetryFunc(func(string, int, error) (time.Duration, bool) {
+	return 0, false
+})
+
+// Backoff 是按指数退避重试的 RetryPolicy，适合部署在 NFS、SMB 等网络共享上、
+// 会短暂返回 EBUSY/EACCES 的场景：
+//
+//	l.RetryPolicy = lumberjack.Backoff{Attempts: 5, Delay: 10 * time.Millisecond}
+type Backoff struct {
+	// Attempts 是最多尝试的次数（包括第一次），不大于 1 时不重试
+	Attempts int
+	// Delay 是第一次重试前的等待时间，之后每次翻倍
+	Delay time.Duration
+	// MaxDelay 是单次等待时间的上限，0 表示不限制
+	MaxDelay time.Duration
+	// Retryable 判断错误是否值得重试，为 nil 时使用 IsTransient
+	Retryable func(err error) bool
+}
+
+// Retry 实现 RetryPolicy
+func (b Backoff) Retry(op string, attempt int, err error) (time.Duration, bool) {
+	if attempt >= b.Attempts {
+		return 0, false
+	}
+	retryable := b.Retryable
+	if retryable == nil {
+		retryable = IsTransient
+	}
+	if !retryable(err) {
+		return 0, false
+	}
+	wait := b.Delay
+	for i := 1; i < attempt && (b.MaxDelay <= 0 || wait < b.MaxDelay); i++ {
+		wait *= 2
+	}
+	if b.MaxDelay > 0 && wait > b.MaxDelay {
+		wait = b.MaxDelay
+	}
+	return wait, true
+}
+
+// retryOp 执行 fn，失败时按 p 重试
+func retryOp(p RetryPolicy, op string, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		wait, ok := p.Retry(op, attempt, err)
+		if !ok {
+			return err
+		}
+		time.Sleep(wait)
+	}
+}
+
+// retryPolicy 返回该 Logger 使用的重试策略
+func (l *Logger) retryPolicy() RetryPolicy {
+	if l.RetryPolicy != nil {
+		return l.RetryPolicy
+	}
+	return defaultRetryPolicy
+}
+
+// rename 是轮转时重命名日志文件和备份使用的函数，按配置选择重命名方式并在
+// 失败时按 RetryPolicy 重试
+func (l *Logger) rename(oldpath, newpath string) error {
+	once := renameFileOnce
+	if l.customFS() {
+		once = l.fsys().Rename
+	} else if l.WriteThroughRename {
+		once = moveFileWriteThrough
+	}
+	return retryOp(l.retryPolicy(), RetryOpRename, func() error {
+		return once(oldpath, newpath)
+	})
+}
+
+// move 把日志文件移动到轮转目标 newpath，本地文件系统上重命名失败时改为
+// 复制后删除
+func (l *Logger) move(oldpath, newpath string) error {
+	if l.customFS() {
+		return l.rename(oldpath, newpath)
+	}
+	return moveFile(l.retryPolicy(), oldpath, newpath)
+}
+
+// openFileRetry 打开文件，失败时按 p 重试
+func openFileRetry(p RetryPolicy, name string, flag int, perm os.FileMode) (f *os.File, err error) {
+	err = retryOp(p, RetryOpOpen, func() error {
+		f, err = openFileOnce(name, flag, perm)
+		return err
+	})
+	return f, err
+}
+
+// renameFileRetry 重命名文件，失败时按 p 重试
+func renameFileRetry(p RetryPolicy, oldpath, newpath string) error {
+	return retryOp(p, RetryOpRename, func() error {
+		return renameFileOnce(oldpath, newpath)
+	})
+}
+
+// removeFileRetry 删除文件，失败时按 p 重试。文件不存在时不重试，
+// 原样返回该错误。
+func removeFileRetry(p RetryPolicy, name string) (err error) {
+	retryOp(p, RetryOpRemove, func() error {
+		err = os.Remove(name)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	})
+	return err
+}
+
+// openFile 按默认重试策略打开文件
+func openFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	return openFileRetry(defaultRetryPolicy, name, flag, perm)
+}
+
+// renameFile 按默认重试策略重命名文件
+func renameFile(oldpath, newpath string) error {
+	return renameFileRetry(defaultRetryPolicy, oldpath, newpath)
+}
@@ -0,0 +1,7 @@
+package lumberjack
+
+// IsTransient 判断文件操作的错误是否可能是暂时的。Plan 9 上没有可以区分的
+// 错误码，总是返回 false。
+func IsTransient(err error) bool {
+	return false
+}
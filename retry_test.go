@@ -0,0 +1,126 @@
+package lumberjack
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	errBusy := errors.New("busy")
+	b := Backoff{
+		Attempts:  5,
+		Delay:     10 * time.Millisecond,
+		MaxDelay:  30 * time.Millisecond,
+		Retryable: func(err error) bool { return err == errBusy },
+	}
+
+	for attempt, want := range []time.Duration{10, 20, 30, 30} {
+		wait, ok := b.Retry(RetryOpRename, attempt+1, errBusy)
+		assert(ok, t, "expected retry on attempt %d", attempt+1)
+		equals(want*time.Millisecond, wait, t)
+	}
+	_, ok := b.Retry(RetryOpRename, 5, errBusy)
+	assert(!ok, t, "expected no retry after Attempts")
+	_, ok = b.Retry(RetryOpRename, 1, errors.New("other"))
+	assert(!ok, t, "expected no retry for non-retryable error")
+
+	_, ok = NoRetry.Retry(RetryOpOpen, 1, errBusy)
+	assert(!ok, t, "expected NoRetry not to retry")
+}
+
+func TestRetryPolicy(t *testing.T) {
+	dir := makeTempDir("TestRetryPolicy", t)
+	defer os.RemoveAll(dir)
+
+	var ops []string
+	p := RetryFunc(func(op string, attempt int, err error) (time.Duration, bool) {
+		ops = append(ops, op)
+		return 0, attempt < 3
+	})
+
+	// 失败的操作按策略重试
+	missing := filepath.Join(dir, "missing")
+	notNil(renameFileRetry(p, missing, filepath.Join(dir, "other")), t)
+	equals([]string{RetryOpRename, RetryOpRename, RetryOpRename}, ops, t)
+
+	ops = nil
+	_, err := openFileRetry(p, missing, os.O_RDONLY, 0)
+	notNil(err, t)
+	equals(3, len(ops), t)
+
+	// 删除不存在的文件不重试，并保留原始错误
+	ops = nil
+	err = removeFileRetry(p, missing)
+	assert(os.IsNotExist(err), t, "expected not-exist error, got %v", err)
+	equals(0, len(ops), t)
+
+	// 重试成功后返回 nil
+	attempts := 0
+	err = retryOp(p, RetryOpOpen, func() error {
+		attempts++
+		if attempts < 2 {
+			return errors.New("busy")
+		}
+		return nil
+	})
+	isNil(err, t)
+	equals(2, attempts, t)
+}
+
+func TestLoggerRetryPolicy(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestLoggerRetryPolicy", t)
+	defer os.RemoveAll(dir)
+
+	var calls int
+	l := &Logger{
+		Filename: logFile(dir),
+		RetryPolicy: RetryFunc(func(op string, attempt int, err error) (time.Duration, bool) {
+			calls++
+			return 0, false
+		}),
+	}
+	defer l.Close()
+
+	// 操作成功时不调用 RetryPolicy
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	equals(0, calls, t)
+
+	_, err = openFileRetry(l.retryPolicy(), filepath.Join(dir, "missing"), os.O_RDONLY, 0)
+	notNil(err, t)
+	equals(1, calls, t)
+
+	// 未设置时使用默认策略
+	l.RetryPolicy = nil
+	_, ok := l.retryPolicy().Retry(RetryOpRename, 1, os.ErrNotExist)
+	assert(!ok, t, "expected default policy not to retry not-exist errors")
+}
+
+func TestWriteThroughRename(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestWriteThroughRename", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:           logFile(dir),
+		WriteThroughRename: true,
+		NumberedBackups:    true,
+	}
+	defer l.Close()
+
+	for _, s := range []string{"boo!", "foo", "bar"} {
+		_, err := l.Write([]byte(s))
+		isNil(err, t)
+		isNil(l.Rotate(), t)
+	}
+	existsWithContent(logFile(dir)+".1", []byte("bar"), t)
+	existsWithContent(logFile(dir)+".2", []byte("foo"), t)
+	existsWithContent(logFile(dir)+".3", []byte("boo!"), t)
+}
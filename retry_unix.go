@@ -0,0 +1,18 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package lumberjack
+
+import (
+	"errors"
+	"syscall"
+)
+
+// IsTransient 判断文件操作的错误是否可能是暂时的，重试后可能成功，例如 NFS、
+// SMB 共享上文件被其他客户端占用时返回的 EBUSY、EACCES，以及 EAGAIN、EINTR。
+func IsTransient(err error) bool {
+	return errors.Is(err, syscall.EBUSY) ||
+		errors.Is(err, syscall.EACCES) ||
+		errors.Is(err, syscall.EAGAIN) ||
+		errors.Is(err, syscall.EINTR)
+}
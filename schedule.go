@@ -0,0 +1,123 @@
+package lumberjack
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// nextRotation 返回 from 之后的下一个定时轮转时间点，有多个定时规则时取最早者。
+// 未配置任何定时轮转时返回零值。
+func (l *Logger) nextRotation(from time.Time) (time.Time, error) {
+	var next time.Time
+	earliest := func(t time.Time) {
+		if !t.IsZero() && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+
+	if l.RotationInterval > 0 {
+		earliest(from.Truncate(l.RotationInterval).Add(l.RotationInterval))
+	}
+	if l.RotateDaily {
+		d := from.In(l.location())
+		earliest(time.Date(d.Year(), d.Month(), d.Day()+1, 0, 0, 0, 0, d.Location()))
+	}
+	if l.HourlyDirs {
+		earliest(l.nextHour(from))
+	}
+	if l.RotateSchedule != "" {
+		s, err := parseCron(l.RotateSchedule)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid rotation schedule: %s", err)
+		}
+		earliest(s.next(from.In(l.location())))
+	}
+	return next, nil
+}
+
+// rotationDue 判断当前文件是否已到达定时轮转时间点
+func (l *Logger) rotationDue() bool {
+	return !l.rotateAt.IsZero() && !l.now().Before(l.rotateAt)
+}
+
+// location 返回备份文件时间戳以及定时轮转所使用的时区
+func (l *Logger) location() *time.Location {
+	if l.Location != nil {
+		return l.Location
+	}
+	if l.TimeZone != "" {
+		if loc, err := loadLocation(l.TimeZone); err == nil {
+			return loc
+		}
+		return time.UTC
+	}
+	if l.LocalTime {
+		return time.Local
+	}
+	return time.UTC
+}
+
+// locations 缓存已加载的时区，time.LoadLocation 每次都会读取时区数据库
+var locations sync.Map
+
+// loadLocation 按名称加载时区并缓存
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %s", name, err)
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// scheduleRotation 按当前文件的定时轮转时间点重新设置计时器。
+// 仅由后台 goroutine 调用。
+func (l *Logger) scheduleRotation(timer Timer) {
+	l.mu.Lock()
+	at := l.rotateAt
+	l.mu.Unlock()
+
+	timer.Stop()
+	if at.IsZero() {
+		return
+	}
+	timer.Reset(at.Sub(l.now()))
+}
+
+// rotateIfDue 在定时轮转时间点到达时执行轮转，由后台 goroutine 的计时器触发，
+// 因此即使没有写入也能按时轮转。
+func (l *Logger) rotateIfDue() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed || l.file == nil || !l.rotationDue() {
+		return
+	}
+	// 其他进程可能已经完成本次轮转，持锁后重新判断
+	unlock, err := l.lockShared()
+	if err != nil {
+		l.reportError(OpRotate, err)
+		l.logDebug("定时轮转获取进程间锁失败: %v，文件: %s", err, l.filename())
+		return
+	}
+	defer unlock()
+	if l.file == nil || !l.rotationDue() {
+		return
+	}
+	// 空文件不轮转，避免空闲时产生大量空备份，只推进下一次轮转时间点
+	if l.size == 0 {
+		if l.rotateAt, err = l.nextRotation(l.now()); err != nil {
+			l.reportError(OpRotate, err)
+			l.logDebug("计算下一次定时轮转时间失败: %v，文件: %s", err, l.filename())
+		}
+		return
+	}
+	if err := l.rotate(); err != nil {
+		l.reportError(OpRotate, err)
+		l.logDebug("定时轮转失败: %v，文件: %s", err, l.filename())
+	}
+}
@@ -0,0 +1,219 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRotationInterval(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestRotationInterval", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:         filename,
+		MaxSize:          100,
+		RotationInterval: 24 * time.Hour,
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+
+	// 同一周期内的写入不会触发轮转
+	b2 := []byte("foo!")
+	n, err = l.Write(b2)
+	isNil(err, t)
+	equals(len(b2), n, t)
+	existsWithContent(filename, append(b, b2...), t)
+	fileCount(dir, 1, t)
+
+	newFakeTime()
+
+	// 跨过轮转时间点后，即使远未达到 MaxSize 也会轮转
+	b3 := []byte("baaaaar!")
+	n, err = l.Write(b3)
+	isNil(err, t)
+	equals(len(b3), n, t)
+	existsWithContent(filename, b3, t)
+	existsWithContent(backupFile(dir), append(b, b2...), t)
+	fileCount(dir, 2, t)
+}
+
+func TestRotationIntervalOnResume(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestRotationIntervalOnResume", t)
+	defer os.RemoveAll(dir)
+
+	// 已有文件的修改时间早于上一个轮转时间点，启动后的首次写入应先补做轮转
+	filename := logFile(dir)
+	data := []byte("old!")
+	err := os.WriteFile(filename, data, 0644)
+	isNil(err, t)
+	old := fakeTime().Add(-48 * time.Hour)
+	isNil(os.Chtimes(filename, old, old), t)
+
+	l := &Logger{
+		Filename:         filename,
+		RotationInterval: 24 * time.Hour,
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+	existsWithContent(filename, b, t)
+	existsWithContent(backupFile(dir), data, t)
+	fileCount(dir, 2, t)
+}
+
+func TestRotateSchedule(t *testing.T) {
+	// 把假时钟拨到午夜前 50ms，使 cron 计时器很快触发
+	fakeTimeMutex.Lock()
+	saved := fakeCurrentTime
+	fakeCurrentTime = time.Date(2030, 1, 1, 23, 59, 59, 950000000, time.UTC)
+	fakeTimeMutex.Unlock()
+	defer func() {
+		fakeTimeMutex.Lock()
+		fakeCurrentTime = saved
+		fakeTimeMutex.Unlock()
+	}()
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestRotateSchedule", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:       filename,
+		RotateSchedule: "0 0 * * *",
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+
+	fakeTimeMutex.Lock()
+	fakeCurrentTime = time.Date(2030, 1, 2, 0, 0, 1, 0, time.UTC)
+	fakeTimeMutex.Unlock()
+
+	// 没有任何写入，后台 goroutine 也应按时完成轮转
+	<-time.After(300 * time.Millisecond)
+
+	existsWithContent(filename, []byte{}, t)
+	existsWithContent(backupFile(dir), b, t)
+	fileCount(dir, 2, t)
+}
+
+func TestRotateScheduleInvalid(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestRotateScheduleInvalid", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:       logFile(dir),
+		RotateSchedule: "not a cron",
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	notNil(err, t)
+}
+
+func TestRotateDaily(t *testing.T) {
+	fakeTimeMutex.Lock()
+	saved := fakeCurrentTime
+	fakeCurrentTime = time.Date(2030, 1, 1, 23, 59, 59, 950000000, time.Local)
+	fakeTimeMutex.Unlock()
+	defer func() {
+		fakeTimeMutex.Lock()
+		fakeCurrentTime = saved
+		fakeTimeMutex.Unlock()
+	}()
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestRotateDaily", t)
+	defer os.RemoveAll(dir)
+
+	// 启动前留下一个前一天的旧文件，首次写入时应补做轮转
+	filename := logFile(dir)
+	data := []byte("yesterday!")
+	isNil(os.WriteFile(filename, data, 0644), t)
+	old := fakeTime().Add(-24 * time.Hour)
+	isNil(os.Chtimes(filename, old, old), t)
+
+	l := &Logger{
+		Filename:    filename,
+		RotateDaily: true,
+		LocalTime:   true,
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+	existsWithContent(backupFileLocal(dir), data, t)
+	fileCount(dir, 2, t)
+
+	// 跨过本地零点后由后台 goroutine 轮转
+	fakeTimeMutex.Lock()
+	fakeCurrentTime = time.Date(2030, 1, 2, 0, 0, 1, 0, time.Local)
+	fakeTimeMutex.Unlock()
+	<-time.After(300 * time.Millisecond)
+
+	existsWithContent(filename, []byte{}, t)
+	existsWithContent(backupFileLocal(dir), b, t)
+	fileCount(dir, 3, t)
+}
+
+func TestLocation(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	dir := makeTempDir("TestLocation", t)
+	defer os.RemoveAll(dir)
+
+	// UTC 次日 02:00，纽约仍是前一天 22:00
+	now := time.Date(2024, 3, 20, 2, 0, 0, 0, time.UTC)
+	l := &Logger{Filename: logFile(dir), TimeZone: "America/New_York", RotateDaily: true}
+	l.Clock = &manualClock{now: now}
+	defer l.Close()
+	isNil(l.Validate(), t)
+
+	next, err := l.nextRotation(now)
+	isNil(err, t)
+	equals(time.Date(2024, 3, 20, 0, 0, 0, 0, ny).UnixNano(), next.UnixNano(), t)
+
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	name := backupName(dir, logFile(dir), now.In(ny).Format(backupTimeFormat))
+	exists(name, t)
+
+	// 从文件名解析出的时间戳按同一时区解释，得到原来的时刻
+	backups, err := l.Backups()
+	isNil(err, t)
+	equals(1, len(backups), t)
+	equals(now.UnixNano(), backups[0].Timestamp.UnixNano(), t)
+	equals("2024-03-19", l.backupDay(backups[0].Timestamp), t)
+
+	// Location 优先于 TimeZone
+	l.Location = time.UTC
+	equals(time.UTC, l.location(), t)
+}
+
+func TestTimeZoneInvalid(t *testing.T) {
+	l := &Logger{Filename: "foo.log", TimeZone: "Nowhere/Special"}
+	notNil(l.Validate(), t)
+}
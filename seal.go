@@ -0,0 +1,106 @@
+package lumberjack
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// sealVersion 是封存记录中 lumberjack_seal 字段的取值
+const sealVersion = "v1"
+
+// SealRecord 是设置 Seal 时在轮转前追加到日志文件末尾的一行 JSON 封存记录。
+// Records、Bytes 和 SHA256 描述封存记录之前的全部内容（含文件头）。
+type SealRecord struct {
+	Seal     string    `json:"lumberjack_seal"` // 封存记录格式版本
+	Records  int64     `json:"records"`         // 记录（行）数
+	Bytes    int64     `json:"bytes"`           // 字节数
+	SHA256   string    `json:"sha256"`          // 内容的 SHA-256（十六进制）
+	SealedAt time.Time `json:"sealed_at"`       // 封存时间
+}
+
+// seal 在当前日志文件被轮转前向其末尾追加封存记录。文件不以换行结尾时先补
+// 一个换行，使封存记录独占最后一行。文件不存在时什么也不做。
+func (l *Logger) seal() error {
+	name := l.filename()
+	f, err := l.fsys().OpenFile(name, os.O_RDWR|os.O_APPEND, 0)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("can't seal log file: %s", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	var rec SealRecord
+	last := byte('\n')
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			h.Write(buf[:n])
+			rec.Records += int64(bytes.Count(buf[:n], []byte{'\n'}))
+			rec.Bytes += int64(n)
+			last = buf[n-1]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("can't read log file to seal: %s", err)
+		}
+	}
+
+	var trailer []byte
+	if last != '\n' {
+		h.Write([]byte{'\n'})
+		rec.Records++
+		rec.Bytes++
+		trailer = append(trailer, '\n')
+	}
+	rec.Seal = sealVersion
+	rec.SHA256 = hex.EncodeToString(h.Sum(nil))
+	rec.SealedAt = l.now()
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	trailer = append(append(trailer, line...), '\n')
+	if _, err := f.Write(trailer); err != nil {
+		return fmt.Errorf("can't write seal record: %s", err)
+	}
+	return nil
+}
+
+// VerifySeal 检查未压缩的备份 path 末尾的封存记录（见 Logger.Seal）与文件
+// 内容是否一致，用于发现被截断或不完整的备份。成功时返回封存记录。
+func VerifySeal(path string) (SealRecord, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return SealRecord{}, err
+	}
+	var rec SealRecord
+	body := bytes.TrimSuffix(b, []byte{'\n'})
+	i := bytes.LastIndexByte(body, '\n') + 1
+	if len(body) == len(b) || json.Unmarshal(body[i:], &rec) != nil || rec.Seal == "" {
+		return SealRecord{}, errors.New("no seal record at end of file")
+	}
+	content := b[:i]
+	sum := sha256.Sum256(content)
+	switch {
+	case int64(len(content)) != rec.Bytes:
+		return rec, fmt.Errorf("sealed %d bytes, found %d", rec.Bytes, len(content))
+	case int64(bytes.Count(content, []byte{'\n'})) != rec.Records:
+		return rec, fmt.Errorf("sealed %d records, found %d", rec.Records, bytes.Count(content, []byte{'\n'}))
+	case hex.EncodeToString(sum[:]) != rec.SHA256:
+		return rec, errors.New("seal checksum mismatch")
+	}
+	return rec, nil
+}
@@ -0,0 +1,63 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSeal(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestSeal", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), Seal: true, HeaderBytes: []byte("#v1\n")}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo\nfoo\n"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	first := backupFile(dir)
+
+	rec, err := VerifySeal(first)
+	isNil(err, t)
+	equals(int64(3), rec.Records, t)
+	equals(int64(12), rec.Bytes, t)
+	equals(fakeTime().UnixNano(), rec.SealedAt.UnixNano(), t)
+	existsWithContent(logFile(dir), []byte("#v1\n"), t)
+
+	// 不以换行结尾的文件先补一个换行
+	newFakeTime()
+	_, err = l.Write([]byte("bar"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	rec, err = VerifySeal(backupFile(dir))
+	isNil(err, t)
+	equals(int64(2), rec.Records, t)
+	equals(int64(8), rec.Bytes, t)
+
+	// 被截断或修改的备份无法通过校验
+	b, err := os.ReadFile(first)
+	isNil(err, t)
+	b[5] = 'x'
+	isNil(os.WriteFile(first, b, 0644), t)
+	_, err = VerifySeal(first)
+	notNil(err, t)
+
+	isNil(os.WriteFile(first, b[:6], 0644), t)
+	_, err = VerifySeal(first)
+	notNil(err, t)
+}
+
+func TestSealNotOnClose(t *testing.T) {
+	dir := makeTempDir("TestSealNotOnClose", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), Seal: true}
+	_, err := l.Write([]byte("boo\n"))
+	isNil(err, t)
+	isNil(l.Close(), t)
+	existsWithContent(logFile(dir), []byte("boo\n"), t)
+	_, err = VerifySeal(logFile(dir))
+	notNil(err, t)
+}
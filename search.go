@@ -0,0 +1,143 @@
+package lumberjack
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// SearchOptions 控制 Search 的范围
+type SearchOptions struct {
+	// Since 和 Until 按备份文件名中的时间戳筛选要搜索的文件：一个备份包含
+	// 上一次轮转到它的时间戳之间写入的内容，与 [Since, Until] 没有交集的文件
+	// 被跳过。零值表示不限制。行内的时间不会被解析。
+	Since, Until time.Time
+
+	// SkipActive 为 true 时不搜索当前日志文件，只搜索备份
+	SkipActive bool
+
+	// MaxResults 是最多返回的匹配行数，0 表示不限制
+	MaxResults int
+}
+
+// SearchMatch 是 Search 找到的一行
+type SearchMatch struct {
+	Path  string // 匹配所在的文件，当前日志文件或备份
+	Entry string // 按天打包的归档中条目的文件名，其他文件为空
+	Line  int64  // 行号，从 1 开始
+	Text  string // 行内容，不含行尾换行
+}
+
+// Search 在当前日志文件和全部备份中按正则表达式 pattern 逐行搜索，按写入
+// 时间从旧到新返回匹配的行。压缩、加密（使用 EncryptionKey 或
+// EncryptionKeyFile）和按天打包的备份会在读取时透明地解开，结果以流的方式
+// 产生，不会把整个备份读入内存。
+//
+// 迭代产生的错误不会中止搜索：无法读取的文件以 err 非空的结果报告，调用方
+// 可以跳过后继续迭代。pattern 无效或 ctx 结束时产生错误并停止。
+func (l *Logger) Search(ctx context.Context, pattern string, opts SearchOptions) iter.Seq2[SearchMatch, error] {
+	return func(yield func(SearchMatch, error) bool) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			yield(SearchMatch{}, fmt.Errorf("invalid search pattern: %s", err))
+			return
+		}
+		files, err := l.filesBetween(opts.Since, opts.Until, !opts.SkipActive)
+		if err != nil {
+			yield(SearchMatch{}, err)
+			return
+		}
+
+		found := 0
+		emit := func(m SearchMatch, err error) bool {
+			if err == nil {
+				found++
+			}
+			return yield(m, err) && (opts.MaxResults <= 0 || found < opts.MaxResults)
+		}
+		for _, path := range files {
+			if err := ctx.Err(); err != nil {
+				yield(SearchMatch{}, err)
+				return
+			}
+			if !l.searchFile(ctx, path, re, emit) {
+				return
+			}
+		}
+	}
+}
+
+// searchFile 搜索单个文件，返回 false 表示应停止搜索
+func (l *Logger) searchFile(ctx context.Context, path string, re *regexp.Regexp, emit func(SearchMatch, error) bool) bool {
+	var r io.ReadCloser
+	var err error
+	if path == l.activeName() {
+		// 当前文件可能还有未写入的缓冲数据，只搜索已在磁盘上的部分
+		r, err = l.openActive()
+	} else {
+		r, err = l.openBackupReader(path)
+	}
+	if err != nil {
+		return emit(SearchMatch{Path: path}, fmt.Errorf("can't search %s: %s", path, err))
+	}
+	defer r.Close()
+
+	if _, ok := l.bundleTime(filepath.Base(path)); !ok {
+		return searchLines(ctx, r, SearchMatch{Path: path}, re, emit)
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return true
+		}
+		if err != nil {
+			return emit(SearchMatch{Path: path}, fmt.Errorf("can't search %s: %s", path, err))
+		}
+		if !searchLines(ctx, tr, SearchMatch{Path: path, Entry: hdr.Name}, re, emit) {
+			return false
+		}
+	}
+}
+
+// searchLines 逐行搜索 r，匹配的行以 at 为模板产生结果
+func searchLines(ctx context.Context, r io.Reader, at SearchMatch, re *regexp.Regexp, emit func(SearchMatch, error) bool) bool {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			at.Line++
+			if at.Line%1024 == 0 && ctx.Err() != nil {
+				emit(SearchMatch{}, ctx.Err())
+				return false
+			}
+			text := line
+			if text[len(text)-1] == '\n' {
+				text = text[:len(text)-1]
+			}
+			if re.Match(text) {
+				m := at
+				m.Text = string(text)
+				if !emit(m, nil) {
+					return false
+				}
+			}
+		}
+		if err == io.EOF {
+			return true
+		}
+		if err != nil {
+			where := at.Path
+			if at.Entry != "" {
+				where += ":" + at.Entry
+			}
+			return emit(SearchMatch{Path: at.Path, Entry: at.Entry}, fmt.Errorf("can't search %s: %s", where, err))
+		}
+	}
+}
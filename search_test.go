@@ -0,0 +1,88 @@
+package lumberjack
+
+import (
+	"context"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// collectSearch 收集 Search 的全部结果
+func collectSearch(l *Logger, pattern string, opts SearchOptions, t testing.TB) []SearchMatch {
+	var matches []SearchMatch
+	for m, err := range l.Search(context.Background(), pattern, opts) {
+		isNil(err, t)
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+func TestSearch(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestSearch", t)
+	defer os.RemoveAll(dir)
+
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	isNil(err, t)
+	l := &Logger{Filename: logFile(dir), Compress: true, CompressSync: true}
+	defer l.Close()
+
+	_, err = l.Write([]byte("one error\ntwo ok\n"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	gz := backupFile(dir) + compressSuffix
+	equals(1, len(collectSearch(l, "error", SearchOptions{SkipActive: true}, t)), t)
+
+	newFakeTime()
+	l.Encrypt = true
+	l.EncryptionKey = key
+	_, err = l.Write([]byte("three ok\nfour error\n"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	enc := backupFile(dir) + compressSuffix + encSuffix
+	exists(enc, t)
+	// 之前的备份也随之被加密
+	gz += encSuffix
+
+	_, err = l.Write([]byte("five error"))
+	isNil(err, t)
+
+	matches := collectSearch(l, "error$", SearchOptions{}, t)
+	equals(3, len(matches), t)
+	equals(SearchMatch{Path: gz, Line: 1, Text: "one error"}, matches[0], t)
+	equals(SearchMatch{Path: enc, Line: 2, Text: "four error"}, matches[1], t)
+	equals(SearchMatch{Path: logFile(dir), Line: 1, Text: "five error"}, matches[2], t)
+
+	equals(1, len(collectSearch(l, "error", SearchOptions{MaxResults: 1}, t)), t)
+	equals(2, len(collectSearch(l, "error", SearchOptions{SkipActive: true}, t)), t)
+
+	for _, err := range l.Search(context.Background(), "(", SearchOptions{}) {
+		notNil(err, t)
+	}
+}
+
+func TestSearchTimeRange(t *testing.T) {
+	dir := makeTempDir("TestSearchTimeRange", t)
+	defer os.RemoveAll(dir)
+
+	base := time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)
+	names := makeBackups(dir, t, base.Add(3*time.Hour), base.Add(2*time.Hour), base.Add(time.Hour))
+	isNil(os.WriteFile(logFile(dir), []byte("boo!"), 0644), t)
+
+	l := &Logger{Filename: logFile(dir)}
+	defer l.Close()
+
+	var got []string
+	for _, m := range collectSearch(l, "boo", SearchOptions{
+		Since: base.Add(90 * time.Minute),
+		Until: base.Add(150 * time.Minute),
+	}, t) {
+		got = append(got, filepath.Base(m.Path))
+	}
+	// 01:00 的备份内容早于 Since，03:00 的备份内容始于 02:00 之后；当前文件始于 03:00
+	equals([]string{names[1], names[0]}, got, t)
+}
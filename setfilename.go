@@ -0,0 +1,84 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// SetFilename 让 l 改为写入 path，适合多租户服务在不新建 Logger、不丢失统计
+// 计数的情况下重定向某个租户的日志。当前文件像 Rotate 一样被封存（设置 Seal
+// 时）并轮转为旧路径的备份，随后对旧路径同步执行最后一次压缩、上传与清理，
+// 使其备份仍按 MaxBackups、MaxAge 等配置保留；这一步的失败通过 Errors 通道
+// 上报，不影响切换。之后的轮转、压缩与清理只针对新路径的备份。
+//
+// Filename 字段保持不变，CurrentFile 返回新的路径。path 与当前路径相同时不做
+// 任何事；Logger 已关闭时返回错误。
+func (l *Logger) SetFilename(path string) error {
+	if path == "" {
+		return errors.New("empty log file path")
+	}
+	path = filepath.Clean(path)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return errClosed
+	}
+	if path == filepath.Clean(l.baseFilename()) {
+		return nil
+	}
+	if err := l.leaveFile(); err != nil {
+		return err
+	}
+	// 进程间锁与日志文件路径绑定，换到新路径后重新创建
+	if err := l.closeLock(); err != nil {
+		return fmt.Errorf("can't close inter-process lock: %s", err)
+	}
+
+	l.filenameOverride.Store(path)
+	l.lastBackup = ""
+	l.logDebug("切换日志文件路径: %s", l.filename())
+
+	unlock, err := l.lockShared()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := l.openExistingOrNew(0); err != nil {
+		return fmt.Errorf("can't open new log file: %s", err)
+	}
+	return nil
+}
+
+// leaveFile 在切换路径前持有旧路径的进程间锁关闭、封存并轮转当前文件，然后
+// 处理旧路径的备份。调用方必须持有 l.mu。
+func (l *Logger) leaveFile() error {
+	unlock, err := l.lockShared()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := l.close(); err != nil {
+		return err
+	}
+	if l.Seal {
+		if err := l.seal(); err != nil {
+			return err
+		}
+	}
+	l.lastBackup = ""
+	name := l.activeName()
+	if _, err := l.stat(name); err == nil {
+		if l.lastBackup, err = l.moveToBackup(name, ""); err != nil {
+			return err
+		}
+		l.rotated()
+	}
+	// 切换后后台处理只针对新路径，旧路径的备份在这里处理最后一次
+	if err := l.millRunOnce(); err != nil {
+		l.reportError(OpCleanup, err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetFilename(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestSetFilename", t)
+	defer os.RemoveAll(dir)
+
+	oldDir := filepath.Join(dir, "a")
+	newDir := filepath.Join(dir, "b")
+	filename := logFile(oldDir)
+	l := &Logger{
+		Filename: filename,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	_, err = l.Write([]byte("foo"))
+	isNil(err, t)
+
+	newFakeTime()
+	newName := logFile(newDir)
+	isNil(l.SetFilename(newName), t)
+	equals(newName, l.CurrentFile(), t)
+	equals(filename, l.Filename, t)
+
+	_, err = l.Write([]byte("bar"))
+	isNil(err, t)
+	existsWithContent(newName, []byte("bar"), t)
+	// 旧文件被轮转为旧路径的备份
+	notExist(filename, t)
+	existsWithContent(backupFile(oldDir), []byte("foo"), t)
+	fileCount(oldDir, 2, t)
+
+	// 之后的轮转只在新路径上进行，统计计数不丢失
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	existsWithContent(backupFile(newDir), []byte("bar"), t)
+	fileCount(newDir, 2, t)
+	fileCount(oldDir, 2, t)
+	stats := l.Stats()
+	equals(int64(10), stats.BytesWritten, t)
+	equals(int64(3), stats.Rotations, t)
+
+	// 相同路径不做任何事
+	isNil(l.SetFilename(newName), t)
+	fileCount(newDir, 2, t)
+
+	notNil(l.SetFilename(""), t)
+	isNil(l.Close(), t)
+	notNil(l.SetFilename(filename), t)
+}
+
+func TestSetFilenameSeal(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestSetFilenameSeal", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		Seal:     true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(err, t)
+	isNil(l.SetFilename(filepath.Join(dir, "other.log")), t)
+
+	rec, err := VerifySeal(backupFile(dir))
+	isNil(err, t)
+	equals(int64(1), rec.Records, t)
+}
+
+func TestSetFilenameRetention(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestSetFilenameRetention", t)
+	defer os.RemoveAll(dir)
+
+	oldDir := filepath.Join(dir, "a")
+	filename := logFile(oldDir)
+	l := &Logger{
+		Filename:     filename,
+		MaxBackups:   1,
+		Compress:     true,
+		CompressSync: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	_, err = l.Write([]byte("foo"))
+	isNil(err, t)
+
+	// 旧路径的最后一个文件也被压缩，并按 MaxBackups 清理旧路径的备份
+	newFakeTime()
+	isNil(l.SetFilename(logFile(filepath.Join(dir, "b"))), t)
+	fileCount(oldDir, 1, t)
+	exists(backupFile(oldDir)+compressSuffix, t)
+}
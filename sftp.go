@@ -0,0 +1,480 @@
+package lumberjack
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SFTP 协议（版本 3）中用到的报文类型
+const (
+	sftpInit    = 1
+	sftpVersion = 2
+	sftpOpen    = 3
+	sftpClose   = 4
+	sftpWrite   = 6
+	sftpRemove  = 13
+	sftpStat    = 17
+	sftpRename  = 18
+	sftpStatus  = 101
+	sftpHandle  = 102
+	sftpAttrs   = 105
+)
+
+// SFTP 打开文件的标志和状态码
+const (
+	sftpFlagWrite  = 0x02
+	sftpFlagCreate = 0x08
+	sftpFlagTrunc  = 0x10
+
+	sftpOK         = 0
+	sftpNoSuchFile = 2
+
+	sftpAttrSize = 0x01
+)
+
+// sftpChunk 是每个 WRITE 请求携带的数据量，OpenSSH 服务器接受的上限为 256KB
+const sftpChunk = 32 * 1024
+
+// SFTPUploader 通过 SFTP 把备份上传到远端主机的 Dir 目录，适用于无法访问云存储、
+// 需要把日志集中到内网日志主机的环境。文件先写入同名的 .part 文件，传输完成后
+// 再重命名，中途断开时下一次尝试从 .part 已有的长度继续传输。
+type SFTPUploader struct {
+	// Addr 是 SSH 服务地址，例如 "loghost:22"
+	Addr string
+
+	// Config 是 SSH 客户端配置，包含用户名、认证方式和主机密钥校验
+	Config *ssh.ClientConfig
+
+	// Dir 是远端目录，必须已经存在。为空时上传到登录后的当前目录。
+	Dir string
+
+	// Retries 是单个文件失败后的重试次数，默认为 0，即不重试。每次重试都会
+	// 重新连接并从已上传的位置继续。
+	Retries int
+
+	// RetryDelay 是第一次重试前的等待时间，之后每次翻倍，默认为 1 秒
+	RetryDelay time.Duration
+
+	// BytesPerSecond 限制上传速率（字节/秒），避免占满日志主机或专线的带宽。
+	// 默认为 0，即不限速。
+	BytesPerSecond int64
+
+	// agentAuth 为 true 时每次连接都通过 SSH_AUTH_SOCK 指向的 ssh-agent 认证，
+	// 由 sftp:// 形式的 UploadTarget 设置
+	agentAuth bool
+
+	// dial 建立 SFTP 会话，测试时替换为内存中的服务器
+	dial func(ctx context.Context) (io.ReadWriteCloser, error)
+}
+
+// Upload 上传本地文件 name，失败时按 Retries 重试
+func (s *SFTPUploader) Upload(ctx context.Context, name string) error {
+	return retryBackoff(ctx, systemClock{}, s.Retries, s.RetryDelay, func() error {
+		return s.upload(ctx, name)
+	})
+}
+
+// upload 建立一次连接并上传 name，远端已有部分内容时从其末尾继续
+func (s *SFTPUploader) upload(ctx context.Context, name string) error {
+	dial := s.dial
+	if dial == nil {
+		dial = s.dialSSH
+	}
+	rw, err := dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer rw.Close()
+	c, err := newSFTPConn(rw)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	dst := path.Join(s.Dir, filepath.Base(name))
+	if size, err := c.size(dst); err == nil && size == info.Size() {
+		// 上一次传输已经完成，只是没有来得及确认
+		return nil
+	}
+	part := dst + ".part"
+	offset, err := c.size(part)
+	if err != nil && !isSFTPNotExist(err) {
+		return err
+	}
+	flags := uint32(sftpFlagWrite | sftpFlagCreate)
+	if offset > info.Size() {
+		// 远端的残留文件比本地还大，无法续传
+		flags |= sftpFlagTrunc
+		offset = 0
+	}
+	h, err := c.open(part, flags)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		c.closeHandle(h)
+		return err
+	}
+	if err := s.copy(ctx, c, h, f, offset); err != nil {
+		c.closeHandle(h)
+		return err
+	}
+	if err := c.closeHandle(h); err != nil {
+		return err
+	}
+	if err := c.rename(part, dst); err != nil {
+		// SFTP v3 的 RENAME 不覆盖已存在的文件，先删除不完整的旧文件
+		if errRemove := c.remove(dst); errRemove != nil {
+			return err
+		}
+		return c.rename(part, dst)
+	}
+	return nil
+}
+
+// copy 从 r 读取数据，按 BytesPerSecond 限速写入远端文件 h 的 offset 处
+func (s *SFTPUploader) copy(ctx context.Context, c *sftpConn, h string, r io.Reader, offset int64) error {
+	start := time.Now()
+	var sent int64
+	buf := make([]byte, sftpChunk)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, err := r.Read(buf)
+		if n > 0 {
+			if errWrite := c.write(h, uint64(offset), buf[:n]); errWrite != nil {
+				return errWrite
+			}
+			offset += int64(n)
+			sent += int64(n)
+			if s.BytesPerSecond > 0 {
+				// 发送速度超前时等待，使平均速率不超过限制
+				due := start.Add(time.Duration(float64(sent) / float64(s.BytesPerSecond) * float64(time.Second)))
+				if wait := time.Until(due); wait > 0 {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case <-time.After(wait):
+					}
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// dialSSH 连接 Addr 并启动 sftp 子系统
+func (s *SFTPUploader) dialSSH(ctx context.Context) (io.ReadWriteCloser, error) {
+	if s.Config == nil {
+		return nil, errors.New("sftp uploader has no ssh config")
+	}
+	config := s.Config
+	if s.agentAuth {
+		// agent 的签名器在握手过程中才签名，连接要保持到握手结束
+		ag, err := dialAgent(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer ag.Close()
+		c := *config
+		c.Auth = append([]ssh.AuthMethod{ssh.PublicKeysCallback(agent.NewClient(ag).Signers)}, config.Auth...)
+		config = &c
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return nil, err
+	}
+	sc, chans, reqs, err := ssh.NewClientConn(conn, s.Addr, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	client := ssh.NewClient(sc, chans, reqs)
+	session, err := client.NewSession()
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	w, err := session.StdinPipe()
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	r, err := session.StdoutPipe()
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	if err := session.RequestSubsystem("sftp"); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &sshStream{Reader: r, WriteCloser: w, client: client}, nil
+}
+
+// sshStream 把 SSH 会话的输入输出组合为 io.ReadWriteCloser，关闭时断开连接
+type sshStream struct {
+	io.Reader
+	io.WriteCloser
+	client *ssh.Client
+}
+
+func (s *sshStream) Close() error {
+	s.WriteCloser.Close()
+	return s.client.Close()
+}
+
+// dialAgent 连接 SSH_AUTH_SOCK 指向的 ssh-agent
+func dialAgent(ctx context.Context) (net.Conn, error) {
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return nil, errors.New("SSH_AUTH_SOCK is not set")
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, "unix", sock)
+}
+
+// sftpTargetConfig 为 sftp:// 形式的 UploadTarget 生成 SSH 配置，连接时按
+// ~/.ssh/known_hosts 校验主机密钥。认证方式由 SFTPUploader 的 agentAuth
+// 在每次连接时加入。
+func sftpTargetConfig(user string) *ssh.ClientConfig {
+	hostKeys := func(host string, remote net.Addr, key ssh.PublicKey) error {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		check, err := knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+		if err != nil {
+			return fmt.Errorf("can't load known_hosts: %s", err)
+		}
+		return check(host, remote, key)
+	}
+	return &ssh.ClientConfig{
+		User:            user,
+		HostKeyCallback: hostKeys,
+		Timeout:         30 * time.Second,
+	}
+}
+
+// sftpError 是服务器返回的非成功状态
+type sftpError struct {
+	Code uint32
+	Msg  string
+}
+
+func (e *sftpError) Error() string {
+	if e.Msg != "" {
+		return fmt.Sprintf("sftp: %s (status %d)", e.Msg, e.Code)
+	}
+	return fmt.Sprintf("sftp: status %d", e.Code)
+}
+
+// isSFTPNotExist 判断 err 是否表示远端文件不存在
+func isSFTPNotExist(err error) bool {
+	var se *sftpError
+	return errors.As(err, &se) && se.Code == sftpNoSuchFile
+}
+
+// sftpConn 是只实现上传所需请求的 SFTP 客户端，同一时间只有一个未完成的请求
+type sftpConn struct {
+	mu sync.Mutex
+	rw io.ReadWriter
+	id uint32
+}
+
+// newSFTPConn 在 rw 上完成 SFTP 版本协商
+func newSFTPConn(rw io.ReadWriter) (*sftpConn, error) {
+	c := &sftpConn{rw: rw}
+	if err := c.send(sftpInit, uint32(3)); err != nil {
+		return nil, err
+	}
+	typ, _, err := c.recv()
+	if err != nil {
+		return nil, err
+	}
+	if typ != sftpVersion {
+		return nil, fmt.Errorf("sftp: unexpected packet type %d during init", typ)
+	}
+	return c, nil
+}
+
+// send 编码并发送一个报文，字段可以是 uint32、uint64、string 或 []byte
+func (c *sftpConn) send(typ byte, fields ...interface{}) error {
+	b := []byte{0, 0, 0, 0, typ}
+	for _, f := range fields {
+		switch v := f.(type) {
+		case uint32:
+			b = binary.BigEndian.AppendUint32(b, v)
+		case uint64:
+			b = binary.BigEndian.AppendUint64(b, v)
+		case string:
+			b = binary.BigEndian.AppendUint32(b, uint32(len(v)))
+			b = append(b, v...)
+		case []byte:
+			b = binary.BigEndian.AppendUint32(b, uint32(len(v)))
+			b = append(b, v...)
+		default:
+			panic(fmt.Sprintf("sftp: unsupported field type %T", f))
+		}
+	}
+	binary.BigEndian.PutUint32(b, uint32(len(b)-4))
+	_, err := c.rw.Write(b)
+	return err
+}
+
+// recv 读取一个报文，返回类型和其余内容
+func (c *sftpConn) recv() (byte, []byte, error) {
+	var hdr [4]byte
+	if _, err := io.ReadFull(c.rw, hdr[:]); err != nil {
+		return 0, nil, err
+	}
+	n := binary.BigEndian.Uint32(hdr[:])
+	if n == 0 || n > 1<<20 {
+		return 0, nil, fmt.Errorf("sftp: invalid packet length %d", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(c.rw, b); err != nil {
+		return 0, nil, err
+	}
+	return b[0], b[1:], nil
+}
+
+// request 发送带请求 ID 的报文并等待对应的响应，返回去掉 ID 后的内容
+func (c *sftpConn) request(typ byte, fields ...interface{}) (byte, []byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.id++
+	id := c.id
+	if err := c.send(typ, append([]interface{}{id}, fields...)...); err != nil {
+		return 0, nil, err
+	}
+	rtyp, data, err := c.recv()
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(data) < 4 || binary.BigEndian.Uint32(data) != id {
+		return 0, nil, errors.New("sftp: response id mismatch")
+	}
+	data = data[4:]
+	if rtyp == sftpStatus {
+		if err := parseSFTPStatus(data); err != nil {
+			return 0, nil, err
+		}
+	}
+	return rtyp, data, nil
+}
+
+// parseSFTPStatus 解析 STATUS 报文，状态为成功时返回 nil
+func parseSFTPStatus(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("sftp: short status packet")
+	}
+	code := binary.BigEndian.Uint32(data)
+	if code == sftpOK {
+		return nil
+	}
+	msg, _ := sftpString(data[4:])
+	return &sftpError{Code: code, Msg: msg}
+}
+
+// sftpString 解析以长度开头的字符串，返回字符串和剩余内容
+func sftpString(data []byte) (string, []byte) {
+	if len(data) < 4 {
+		return "", nil
+	}
+	n := binary.BigEndian.Uint32(data)
+	if uint32(len(data)-4) < n {
+		return "", nil
+	}
+	return string(data[4 : 4+n]), data[4+n:]
+}
+
+// expectStatus 要求响应为成功的 STATUS 报文
+func expectStatus(typ byte, err error) error {
+	if err != nil {
+		return err
+	}
+	if typ != sftpStatus {
+		return fmt.Errorf("sftp: unexpected packet type %d", typ)
+	}
+	return nil
+}
+
+// size 返回远端文件的大小
+func (c *sftpConn) size(p string) (int64, error) {
+	typ, data, err := c.request(sftpStat, p)
+	if err != nil {
+		return 0, err
+	}
+	if typ != sftpAttrs || len(data) < 4 {
+		return 0, fmt.Errorf("sftp: unexpected packet type %d", typ)
+	}
+	if binary.BigEndian.Uint32(data)&sftpAttrSize == 0 || len(data) < 12 {
+		return 0, errors.New("sftp: server did not report file size")
+	}
+	return int64(binary.BigEndian.Uint64(data[4:])), nil
+}
+
+// open 以 flags 打开远端文件，返回文件句柄
+func (c *sftpConn) open(p string, flags uint32) (string, error) {
+	typ, data, err := c.request(sftpOpen, p, flags, uint32(0))
+	if err != nil {
+		return "", err
+	}
+	if typ != sftpHandle {
+		return "", fmt.Errorf("sftp: unexpected packet type %d", typ)
+	}
+	h, _ := sftpString(data)
+	return h, nil
+}
+
+func (c *sftpConn) write(h string, offset uint64, b []byte) error {
+	return expectStatus(c.requestType(sftpWrite, h, offset, b))
+}
+
+func (c *sftpConn) closeHandle(h string) error {
+	return expectStatus(c.requestType(sftpClose, h))
+}
+
+func (c *sftpConn) rename(from, to string) error {
+	return expectStatus(c.requestType(sftpRename, from, to))
+}
+
+func (c *sftpConn) remove(p string) error {
+	return expectStatus(c.requestType(sftpRemove, p))
+}
+
+// requestType 与 request 相同，只返回响应的类型
+func (c *sftpConn) requestType(typ byte, fields ...interface{}) (byte, error) {
+	rtyp, _, err := c.request(typ, fields...)
+	return rtyp, err
+}
@@ -0,0 +1,301 @@
+package lumberjack
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// fakeSFTPServer 是内存中的 SFTP 服务器，只实现上传用到的请求
+type fakeSFTPServer struct {
+	mu      sync.Mutex
+	files   map[string][]byte
+	written int // 收到的 WRITE 数据总量
+}
+
+func newFakeSFTPServer() *fakeSFTPServer {
+	return &fakeSFTPServer{files: make(map[string][]byte)}
+}
+
+// dial 返回与服务器相连的一端，另一端由服务器 goroutine 处理
+func (s *fakeSFTPServer) dial(ctx context.Context) (io.ReadWriteCloser, error) {
+	client, server := net.Pipe()
+	go s.serve(server)
+	return client, nil
+}
+
+func (s *fakeSFTPServer) serve(conn io.ReadWriteCloser) {
+	defer conn.Close()
+	c := &sftpConn{rw: conn}
+	handles := make(map[string]string)
+	for {
+		typ, data, err := c.recv()
+		if err != nil {
+			return
+		}
+		if typ == sftpInit {
+			c.send(sftpVersion, uint32(3))
+			continue
+		}
+		id := binary.BigEndian.Uint32(data)
+		data = data[4:]
+		status := func(code uint32) {
+			c.send(sftpStatus, id, code, "", "")
+		}
+		s.mu.Lock()
+		switch typ {
+		case sftpStat:
+			p, _ := sftpString(data)
+			if b, ok := s.files[p]; ok {
+				c.send(sftpAttrs, id, uint32(sftpAttrSize), uint64(len(b)))
+			} else {
+				status(sftpNoSuchFile)
+			}
+		case sftpOpen:
+			p, rest := sftpString(data)
+			flags := binary.BigEndian.Uint32(rest)
+			if _, ok := s.files[p]; !ok || flags&sftpFlagTrunc != 0 {
+				s.files[p] = nil
+			}
+			handles[p] = p
+			c.send(sftpHandle, id, p)
+		case sftpWrite:
+			h, rest := sftpString(data)
+			offset := binary.BigEndian.Uint64(rest)
+			b, _ := sftpString(rest[8:])
+			f := s.files[handles[h]]
+			if int(offset) > len(f) {
+				status(4)
+				break
+			}
+			s.files[handles[h]] = append(f[:offset], b...)
+			s.written += len(b)
+			status(sftpOK)
+		case sftpClose:
+			h, _ := sftpString(data)
+			delete(handles, h)
+			status(sftpOK)
+		case sftpRename:
+			from, rest := sftpString(data)
+			to, _ := sftpString(rest)
+			if _, ok := s.files[to]; ok {
+				status(4)
+				break
+			}
+			s.files[to] = s.files[from]
+			delete(s.files, from)
+			status(sftpOK)
+		case sftpRemove:
+			p, _ := sftpString(data)
+			delete(s.files, p)
+			status(sftpOK)
+		default:
+			status(8)
+		}
+		s.mu.Unlock()
+	}
+}
+
+func writeUploadFile(dir string, size int, t testing.TB) (string, []byte) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), size/16)
+	name := filepath.Join(dir, "app-2001.log.gz")
+	isNil(os.WriteFile(name, content, 0600), t)
+	return name, content
+}
+
+func TestSFTPUploader(t *testing.T) {
+	dir := makeTempDir("TestSFTPUploader", t)
+	defer os.RemoveAll(dir)
+	name, content := writeUploadFile(dir, 100*1024, t)
+
+	srv := newFakeSFTPServer()
+	// 目标已存在但内容不完整时被替换
+	srv.files["/logs/app-2001.log.gz"] = []byte("stale")
+	s := &SFTPUploader{Dir: "/logs", dial: srv.dial}
+	isNil(s.Upload(context.Background(), name), t)
+	equals(content, srv.files["/logs/app-2001.log.gz"], t)
+	_, ok := srv.files["/logs/app-2001.log.gz.part"]
+	assert(!ok, t, "expected .part file to be renamed")
+
+	// 已完整上传的文件不再重复传输
+	srv.written = 0
+	isNil(s.Upload(context.Background(), name), t)
+	equals(0, srv.written, t)
+}
+
+func TestSFTPUploaderResume(t *testing.T) {
+	dir := makeTempDir("TestSFTPUploaderResume", t)
+	defer os.RemoveAll(dir)
+	name, content := writeUploadFile(dir, 64*1024, t)
+
+	srv := newFakeSFTPServer()
+	srv.files["app-2001.log.gz.part"] = append([]byte(nil), content[:40000]...)
+	s := &SFTPUploader{dial: srv.dial}
+	isNil(s.Upload(context.Background(), name), t)
+	equals(content, srv.files["app-2001.log.gz"], t)
+	equals(len(content)-40000, srv.written, t)
+}
+
+func TestSFTPUploaderRetry(t *testing.T) {
+	dir := makeTempDir("TestSFTPUploaderRetry", t)
+	defer os.RemoveAll(dir)
+	name, content := writeUploadFile(dir, 1024, t)
+
+	srv := newFakeSFTPServer()
+	attempts := 0
+	s := &SFTPUploader{
+		Retries:    2,
+		RetryDelay: time.Millisecond,
+		dial: func(ctx context.Context) (io.ReadWriteCloser, error) {
+			attempts++
+			if attempts < 3 {
+				return nil, errors.New("connection refused")
+			}
+			return srv.dial(ctx)
+		},
+	}
+	isNil(s.Upload(context.Background(), name), t)
+	equals(3, attempts, t)
+	equals(content, srv.files["app-2001.log.gz"], t)
+
+	attempts = -10
+	notNil(s.Upload(context.Background(), name), t)
+}
+
+func TestSFTPUploaderBandwidth(t *testing.T) {
+	dir := makeTempDir("TestSFTPUploaderBandwidth", t)
+	defer os.RemoveAll(dir)
+	name, content := writeUploadFile(dir, 64*1024, t)
+
+	srv := newFakeSFTPServer()
+	s := &SFTPUploader{BytesPerSecond: 256 * 1024, dial: srv.dial}
+	start := time.Now()
+	isNil(s.Upload(context.Background(), name), t)
+	elapsed := time.Since(start)
+	assert(elapsed >= 200*time.Millisecond, t, "expected upload to be throttled, took %s", elapsed)
+	equals(content, srv.files["app-2001.log.gz"], t)
+}
+
+func TestParseSFTPTarget(t *testing.T) {
+	u, err := ParseUploadTarget("sftp://logs@loghost/var/log/app")
+	isNil(err, t)
+	s, ok := u.(*SFTPUploader)
+	assert(ok, t, "expected *SFTPUploader, got %T", u)
+	equals("loghost:22", s.Addr, t)
+	equals("logs", s.Config.User, t)
+	equals("/var/log/app", s.Dir, t)
+	equals(3, s.Retries, t)
+
+	_, err = ParseUploadTarget("sftp://loghost/var/log")
+	notNil(err, t)
+}
+
+// serveSSH 在 ln 上启动只接受 key 认证的 SSH 服务器，sftp 子系统由 s 处理
+func (s *fakeSFTPServer) serveSSH(ln net.Listener, key ssh.PublicKey, t testing.TB) {
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	isNil(err, t)
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	isNil(err, t)
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(c ssh.ConnMetadata, k ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(k.Marshal(), key.Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("unknown key")
+		},
+	}
+	config.AddHostKey(hostSigner)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serveSSHConn(conn, config)
+		}
+	}()
+}
+
+// serveSSHConn 完成 SSH 握手，并为请求 sftp 子系统的会话提供服务
+func (s *fakeSFTPServer) serveSSHConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for nc := range chans {
+		ch, chReqs, err := nc.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range chReqs {
+				req.Reply(req.Type == "subsystem", nil)
+				if req.Type == "subsystem" {
+					go s.serve(ch)
+				}
+			}
+		}()
+	}
+}
+
+func TestSFTPUploaderAgent(t *testing.T) {
+	dir := makeTempDir("TestSFTPUploaderAgent", t)
+	defer os.RemoveAll(dir)
+	name, content := writeUploadFile(dir, 1024, t)
+
+	// 进程内的 ssh-agent，持有用于登录的私钥
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	isNil(err, t)
+	keyring := agent.NewKeyring()
+	isNil(keyring.Add(agent.AddedKey{PrivateKey: priv}), t)
+	sock := filepath.Join(dir, "agent.sock")
+	agentLn, err := net.Listen("unix", sock)
+	isNil(err, t)
+	defer agentLn.Close()
+	go func() {
+		for {
+			conn, err := agentLn.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", sock)
+
+	signer, err := ssh.NewSignerFromKey(priv)
+	isNil(err, t)
+	srv := newFakeSFTPServer()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	isNil(err, t)
+	defer ln.Close()
+	srv.serveSSH(ln, signer.PublicKey(), t)
+
+	u, err := ParseUploadTarget("sftp://logs@" + ln.Addr().String() + "/logs")
+	isNil(err, t)
+	s := u.(*SFTPUploader)
+	s.Config.HostKeyCallback = ssh.InsecureIgnoreHostKey()
+	s.Retries = 0
+	isNil(s.Upload(context.Background(), name), t)
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	equals(content, srv.files["/logs/app-2001.log.gz"], t)
+}
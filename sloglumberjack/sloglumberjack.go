@@ -0,0 +1,76 @@
+// Package sloglumberjack 把标准库 log/slog 的 JSON 输出写入会自动轮转的
+// lumberjack.Logger，并在记录 Error 及以上级别的日志后调用 Sync，使关键错误
+// 在进程崩溃前已经落盘。
+//
+//	logger := sloglumberjack.New(&lumberjack.Logger{
+//		Filename: "/var/log/myapp/foo.log",
+//		MaxSize:  100,
+//	}, nil)
+//	slog.SetDefault(logger)
+package sloglumberjack
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/ai-mmo/lumberjack"
+)
+
+// Options 配置 Handler
+type Options struct {
+	// HandlerOptions 传给 slog.NewJSONHandler
+	slog.HandlerOptions
+
+	// SyncLevel 是记录后调用 Logger.Sync 的最低级别，默认为 slog.LevelError
+	SyncLevel slog.Leveler
+}
+
+// Handler 把记录以 JSON 格式写入 lumberjack.Logger，级别不低于 SyncLevel 的
+// 记录写入后调用 Sync
+type Handler struct {
+	slog.Handler
+	l         *lumberjack.Logger
+	syncLevel slog.Leveler
+}
+
+// NewHandler 创建写入 l 的 Handler，opts 为 nil 时使用默认配置
+func NewHandler(l *lumberjack.Logger, opts *Options) *Handler {
+	if opts == nil {
+		opts = &Options{}
+	}
+	syncLevel := opts.SyncLevel
+	if syncLevel == nil {
+		syncLevel = slog.LevelError
+	}
+	return &Handler{
+		Handler:   slog.NewJSONHandler(l, &opts.HandlerOptions),
+		l:         l,
+		syncLevel: syncLevel,
+	}
+}
+
+// New 返回使用 NewHandler(l, opts) 的 *slog.Logger
+func New(l *lumberjack.Logger, opts *Options) *slog.Logger {
+	return slog.New(NewHandler(l, opts))
+}
+
+// Handle 实现 slog.Handler
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	err := h.Handler.Handle(ctx, r)
+	if r.Level >= h.syncLevel.Level() {
+		if serr := h.l.Sync(); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+// WithAttrs 实现 slog.Handler
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{Handler: h.Handler.WithAttrs(attrs), l: h.l, syncLevel: h.syncLevel}
+}
+
+// WithGroup 实现 slog.Handler
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{Handler: h.Handler.WithGroup(name), l: h.l, syncLevel: h.syncLevel}
+}
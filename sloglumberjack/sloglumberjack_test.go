@@ -0,0 +1,83 @@
+package sloglumberjack
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ai-mmo/lumberjack"
+)
+
+// syncWriter 记录 Sync 的调用次数，用作 Logger 的 Mirror
+type syncWriter struct {
+	flushes int
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) { return len(p), nil }
+
+func (w *syncWriter) Flush() error {
+	w.flushes++
+	return nil
+}
+
+func TestHandler(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "foo.log")
+	mirror := &syncWriter{}
+	l := &lumberjack.Logger{Filename: filename, Mirror: mirror}
+	defer l.Close()
+
+	logger := New(l, &Options{HandlerOptions: slog.HandlerOptions{Level: slog.LevelDebug}})
+	logger.Debug("debug", "n", 1)
+	logger.With("svc", "api").WithGroup("req").Info("served", "path", "/")
+	if mirror.flushes != 0 {
+		t.Fatalf("expected no sync below error level, got %d", mirror.flushes)
+	}
+	logger.Error("failed", "err", "boom")
+	if mirror.flushes != 1 {
+		t.Fatalf("expected 1 sync after error, got %d", mirror.flushes)
+	}
+
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d: %q", len(lines), b)
+	}
+	var rec map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["svc"] != "api" || rec["req"].(map[string]interface{})["path"] != "/" {
+		t.Fatalf("unexpected record %v", rec)
+	}
+}
+
+func TestHandlerSyncLevel(t *testing.T) {
+	dir := t.TempDir()
+	mirror := &syncWriter{}
+	l := &lumberjack.Logger{Filename: filepath.Join(dir, "foo.log"), Mirror: mirror}
+	defer l.Close()
+
+	logger := New(l, &Options{SyncLevel: slog.LevelWarn})
+	logger.Debug("dropped")
+	logger.Info("info")
+	logger.Warn("warn")
+	if mirror.flushes != 1 {
+		t.Fatalf("expected 1 sync, got %d", mirror.flushes)
+	}
+
+	// 默认配置下 Debug 不输出
+	b, err := ioutil.ReadFile(filepath.Join(dir, "foo.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "dropped") {
+		t.Fatalf("debug record should be filtered: %q", b)
+	}
+}
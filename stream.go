@@ -0,0 +1,187 @@
+package lumberjack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// defaultStreamFlushInterval 是 StreamFlushInterval 的默认值
+const defaultStreamFlushInterval = time.Second
+
+// activeName 返回当前日志文件在磁盘上的路径，设置 StreamCompress 时为日志
+// 文件名加 ".gz"
+func (l *Logger) activeName() string {
+	if l.StreamCompress {
+		return l.filename() + compressSuffix
+	}
+	return l.filename()
+}
+
+// streamFlushInterval 返回两次刷新压缩流的最长间隔
+func (l *Logger) streamFlushInterval() time.Duration {
+	if l.StreamFlushInterval > 0 {
+		return l.StreamFlushInterval
+	}
+	return defaultStreamFlushInterval
+}
+
+// checkStream 检查 StreamCompress 是否与其他配置冲突
+func (l *Logger) checkStream() error {
+	if !l.StreamCompress {
+		return nil
+	}
+	if l.interProcess() {
+		// 多个进程的 gzip 数据交错写入同一文件后无法解压
+		return errors.New("StreamCompress cannot be combined with InterProcessLock or NamedMutex")
+	}
+	if l.Seal {
+		return errors.New("StreamCompress cannot be combined with Seal")
+	}
+	return nil
+}
+
+// startStream 在打开 l.file 后创建写入它的 gzip 流。追加到已有文件时新的
+// gzip 成员接在原有成员之后，gzip 工具会把它们当作一个文件解压。
+// 调用方必须持有 l.mu。
+func (l *Logger) startStream() {
+	if !l.StreamCompress {
+		return
+	}
+	l.gz = gzip.NewWriter(l.file)
+	l.gzDirty = false
+	l.gzFlushed = l.now()
+}
+
+// flushStream 把压缩流中尚未写出的数据以同步刷新的方式写入文件，使已写入的
+// 内容即使没有 gzip 尾部也能被解压读取。调用方必须持有 l.mu。
+func (l *Logger) flushStream() error {
+	if l.gz == nil || !l.gzDirty {
+		return nil
+	}
+	if err := l.gz.Flush(); err != nil {
+		return fmt.Errorf("can't flush compressed log stream: %s", err)
+	}
+	l.gzDirty = false
+	l.gzFlushed = l.now()
+	return nil
+}
+
+// flushStreamIfDue 在距上次刷新已超过 StreamFlushInterval 时刷新压缩流。
+// 调用方必须持有 l.mu。
+func (l *Logger) flushStreamIfDue() error {
+	if l.gz == nil || l.now().Sub(l.gzFlushed) < l.streamFlushInterval() {
+		return nil
+	}
+	return l.flushStream()
+}
+
+// closeStream 结束压缩流并写入 gzip 尾部，使文件成为完整的 gzip 文件。
+// 调用方必须持有 l.mu。
+func (l *Logger) closeStream() error {
+	if l.gz == nil {
+		return nil
+	}
+	err := l.gz.Close()
+	l.gz = nil
+	if err != nil {
+		return fmt.Errorf("can't finish compressed log stream: %s", err)
+	}
+	return nil
+}
+
+// streamTicker 返回后台 goroutine 定期刷新压缩流使用的 ticker，
+// 未设置 StreamCompress 时返回永不触发的 ticker
+func (l *Logger) streamTicker() *ticker {
+	if !l.StreamCompress {
+		return &ticker{}
+	}
+	return l.newTicker(l.streamFlushInterval())
+}
+
+// flushStreamIdle 由后台 goroutine 定期调用，写入停止后已写入的内容也能
+// 按时出现在文件中
+func (l *Logger) flushStreamIdle() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
+	if err := l.flushStreamIfDue(); err != nil {
+		l.reportError(OpWrite, err)
+		l.logDebug("定期刷新压缩流失败: %v，文件: %s", err, l.filename())
+	}
+}
+
+// scanStream 解压已有的压缩日志文件，返回解压后的字节数和行数。文件不完整
+// （例如进程崩溃时尚未写入 gzip 尾部）时返回错误，此时不能在其后追加。
+func (l *Logger) scanStream(name string) (size, lines int64, err error) {
+	f, err := l.fsys().OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+	if info.Size() == 0 {
+		return 0, 0, nil
+	}
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return 0, 0, err
+	}
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := gz.Read(buf)
+		size += int64(n)
+		lines += int64(bytes.Count(buf[:n], []byte{'\n'}))
+		if err == io.EOF {
+			return size, lines, nil
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+}
+
+// openActive 打开当前日志文件用于读取，设置 StreamCompress 时返回解压后的
+// 内容。压缩流尚未写入尾部，读到已刷新内容的末尾时返回 io.EOF。
+func (l *Logger) openActive() (io.ReadCloser, error) {
+	f, err := l.fsys().OpenFile(l.activeName(), os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	if !l.StreamCompress {
+		return f, nil
+	}
+	gz, err := gzip.NewReader(f)
+	if err == io.EOF {
+		// 刚创建、尚未刷新任何内容的文件
+		return &multiCloser{Reader: bytes.NewReader(nil), closers: []io.Closer{f}}, nil
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("can't open compressed log file: %s", err)
+	}
+	return &multiCloser{Reader: liveStreamReader{gz}, closers: []io.Closer{gz, f}}, nil
+}
+
+// liveStreamReader 把正在写入的压缩流末尾的 io.ErrUnexpectedEOF 视为 io.EOF
+type liveStreamReader struct {
+	r io.Reader
+}
+
+func (r liveStreamReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
@@ -0,0 +1,194 @@
+package lumberjack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readStream 解压 path，忽略尚未写入尾部的压缩流末尾的 io.ErrUnexpectedEOF
+func readStream(path string, t testing.TB) string {
+	data, err := ioutil.ReadFile(path)
+	isNilUp(err, t, 1)
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	isNilUp(err, t, 1)
+	out, err := ioutil.ReadAll(gz)
+	if err != io.ErrUnexpectedEOF {
+		isNilUp(err, t, 1)
+	}
+	return string(out)
+}
+
+func TestStreamCompress(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestStreamCompress", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:       filename,
+		MaxSize:        10,
+		StreamCompress: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(err, t)
+	notExist(filename, t)
+	// 刷新后尚未结束的压缩流也能读取
+	isNil(l.Flush(), t)
+	equals("boo!\n", readStream(filename+compressSuffix, t), t)
+	equals(int64(5), l.CurrentSize(), t)
+
+	// 按解压后的大小轮转，备份是完整的 gzip 文件
+	_, err = l.Write([]byte("foobar\n"))
+	isNil(err, t)
+	backup := backupFile(dir) + compressSuffix
+	equals("boo!\n", readStream(backup, t), t)
+	isNil(l.Close(), t)
+	equals("foobar\n", readStream(filename+compressSuffix, t), t)
+	fileCount(dir, 2, t)
+
+	// 已压缩的备份不会被再次压缩
+	files, err := l.oldLogFiles()
+	isNil(err, t)
+	equals(1, len(files), t)
+}
+
+func TestStreamCompressReopen(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestStreamCompressReopen", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:       filename,
+		MaxSize:        100,
+		MaxLines:       10,
+		StreamCompress: true,
+	}
+	_, err := l.Write([]byte("foo\n"))
+	isNil(err, t)
+	isNil(l.Close(), t)
+
+	// 重新打开时在原有内容之后追加新的 gzip 成员
+	l = &Logger{
+		Filename:       filename,
+		MaxSize:        100,
+		MaxLines:       10,
+		StreamCompress: true,
+	}
+	defer l.Close()
+	_, err = l.Write([]byte("bar\n"))
+	isNil(err, t)
+	equals(int64(8), l.CurrentSize(), t)
+	equals(int64(2), l.lines, t)
+	isNil(l.Close(), t)
+	equals("foo\nbar\n", readStream(filename+compressSuffix, t), t)
+	fileCount(dir, 1, t)
+}
+
+func TestStreamCompressTruncated(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestStreamCompressTruncated", t)
+	defer os.RemoveAll(dir)
+
+	// 模拟进程崩溃时尚未写入 gzip 尾部的文件
+	filename := logFile(dir)
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte("crash\n"))
+	isNil(err, t)
+	isNil(gz.Flush(), t)
+	isNil(ioutil.WriteFile(filename+compressSuffix, buf.Bytes(), 0644), t)
+
+	l := &Logger{Filename: filename, StreamCompress: true}
+	defer l.Close()
+	_, err = l.Write([]byte("new\n"))
+	isNil(err, t)
+	isNil(l.Close(), t)
+
+	equals("crash\n", readStream(backupFile(dir)+compressSuffix, t), t)
+	equals("new\n", readStream(filename+compressSuffix, t), t)
+}
+
+func TestStreamCompressFS(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestStreamCompressFS", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), StreamCompress: true}
+	defer l.Close()
+	_, err := l.Write([]byte("hello\nworld\n"))
+	isNil(err, t)
+	isNil(l.Flush(), t)
+
+	// FS 以日志文件名提供解压后的内容
+	f, err := l.FS().Open("foobar.log")
+	isNil(err, t)
+	defer f.Close()
+	data, err := ioutil.ReadAll(f)
+	isNil(err, t)
+	equals("hello\nworld\n", string(data), t)
+}
+
+func TestStreamCompressFollow(t *testing.T) {
+	currentTime = fakeTime
+	defer func(d time.Duration) { followPollInterval = d }(followPollInterval)
+	followPollInterval = time.Millisecond
+
+	dir := makeTempDir("TestStreamCompressFollow", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), StreamCompress: true, Manifest: "manifest.json"}
+	defer l.Close()
+
+	// 文件创建前开始跟踪，读到的是完整的压缩流
+	r := l.Follow(context.Background())
+	defer r.Close()
+
+	_, err := l.Write([]byte("hello\n"))
+	isNil(err, t)
+	isNil(l.Flush(), t)
+	equals(logFile(dir)+compressSuffix, l.CurrentFile(), t)
+
+	info, err := os.Stat(logFile(dir) + compressSuffix)
+	isNil(err, t)
+	gz, err := gzip.NewReader(strings.NewReader(readFollow(r, int(info.Size()), t)))
+	isNil(err, t)
+	out, err := ioutil.ReadAll(gz)
+	if err != io.ErrUnexpectedEOF {
+		isNil(err, t)
+	}
+	equals("hello\n", string(out), t)
+
+	// 清单记录的也是实际写入的文件
+	isNil(l.updateManifest(), t)
+	m, err := ReadManifest(filepath.Join(dir, "manifest.json"))
+	isNil(err, t)
+	equals(logFile(dir)+compressSuffix, m.Filename, t)
+}
+
+func TestValidateStreamCompress(t *testing.T) {
+	l := &Logger{Filename: "foo.log", StreamCompress: true, Seal: true}
+	notNil(l.Validate(), t)
+
+	l = &Logger{Filename: "foo.log", StreamCompress: true, InterProcessLock: true}
+	notNil(l.Validate(), t)
+
+	l = &Logger{Filename: "foo.log", StreamCompress: true, StreamFlushInterval: -1}
+	notNil(l.Validate(), t)
+}
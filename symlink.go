@@ -0,0 +1,46 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// symlinkPath 返回 SymlinkName 对应的完整路径，相对路径相对于日志文件所在目录
+func (l *Logger) symlinkPath() string {
+	if filepath.IsAbs(l.SymlinkName) {
+		return l.SymlinkName
+	}
+	return filepath.Join(l.dir(), l.SymlinkName)
+}
+
+// updateSymlink 让 SymlinkName 指向当前日志文件。先创建临时链接再重命名覆盖，
+// 保证跟踪该路径的工具任何时刻都能看到一个有效的链接。
+// 链接失败不影响日志写入，只输出调试日志。
+func (l *Logger) updateSymlink() {
+	if l.SymlinkName == "" {
+		return
+	}
+	link := l.symlinkPath()
+	target := l.activeName()
+	if rel, err := filepath.Rel(filepath.Dir(link), target); err == nil {
+		target = rel
+	}
+
+	tmp := link + tmpSuffix
+	_ = os.Remove(tmp)
+	err := os.Symlink(target, tmp)
+	if err != nil && runtime.GOOS == "windows" {
+		// Windows 上创建符号链接需要特权，退而使用硬链接。
+		// 硬链接指向的是文件本身，每次打开新文件后都会重新建立。
+		err = os.Link(l.activeName(), tmp)
+	}
+	if err != nil {
+		l.logDebug("创建符号链接失败: %v，文件: %s", err, link)
+		return
+	}
+	if err := renameFileRetry(l.retryPolicy(), tmp, link); err != nil {
+		_ = os.Remove(tmp)
+		l.logDebug("更新符号链接失败: %v，文件: %s", err, link)
+	}
+}
@@ -0,0 +1,44 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSymlinkName(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on windows")
+	}
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestSymlinkName", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:    filename,
+		SymlinkName: "current.log",
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	link := filepath.Join(dir, "current.log")
+	target, err := os.Readlink(link)
+	isNil(err, t)
+	equals("foobar.log", target, t)
+	existsWithContent(link, b, t)
+
+	// 轮转后链接依然指向新的当前文件
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	b2 := []byte("foo!")
+	_, err = l.Write(b2)
+	isNil(err, t)
+	existsWithContent(link, b2, t)
+	notExist(link+".tmp", t)
+}
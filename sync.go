@@ -0,0 +1,105 @@
+package lumberjack
+
+import (
+	"context"
+	"fmt"
+)
+
+// SyncPolicy 决定何时调用 fsync 把日志文件写入磁盘
+type SyncPolicy string
+
+const (
+	// SyncNever 从不主动 fsync，由操作系统决定何时落盘
+	SyncNever SyncPolicy = "none"
+	// SyncAlways 每次写入后 fsync
+	SyncAlways SyncPolicy = "every-write"
+	// SyncPeriodic 按 SyncEvery 的时间间隔或 SyncBytes 的字节数 fsync
+	SyncPeriodic SyncPolicy = "interval"
+)
+
+// Sync 把尚未写入的数据（AsyncQueue 队列与 BufferSize 缓冲区）写入日志文件
+// 并 fsync，Mirror 实现了 Flush() error 时一并刷新。*Logger 因此满足
+// zapcore.WriteSyncer，可以直接交给 zap 使用。尚未打开文件或已关闭时只刷新
+// 队列，返回 nil。
+func (l *Logger) Sync() error {
+	if ok, err := l.flushAsync(context.Background()); ok && err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.syncFile(); err != nil {
+		return err
+	}
+	return l.flushMirror()
+}
+
+// syncFile 把缓冲区与当前文件写入磁盘。调用方必须持有 l.mu。
+func (l *Logger) syncFile() error {
+	if l.file == nil {
+		return nil
+	}
+	if err := l.flush(); err != nil {
+		return err
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("can't sync log file: %s", err)
+	}
+	l.unsynced = 0
+	l.lastSync = l.now()
+	return nil
+}
+
+// syncAfterWrite 按 SyncPolicy 在写入 n 字节后决定是否 fsync。
+// 调用方必须持有 l.mu。
+func (l *Logger) syncAfterWrite(n int64) error {
+	switch l.SyncPolicy {
+	case "", SyncNever:
+		return nil
+	case SyncAlways:
+		return l.syncFile()
+	case SyncPeriodic:
+		l.unsynced += n
+		if l.lastSync.IsZero() {
+			l.lastSync = l.now()
+		}
+		if (l.SyncBytes > 0 && l.unsynced >= int64(l.SyncBytes)) ||
+			(l.SyncEvery > 0 && l.now().Sub(l.lastSync) >= l.SyncEvery) {
+			return l.syncFile()
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown sync policy %q", l.SyncPolicy)
+	}
+}
+
+// syncBeforeClose 在配置了 SyncPolicy 时于关闭或轮转文件前 fsync，
+// 保证被轮转的文件已完整落盘。调用方必须持有 l.mu。
+func (l *Logger) syncBeforeClose() error {
+	if l.SyncPolicy == "" || l.SyncPolicy == SyncNever {
+		return nil
+	}
+	return l.syncFile()
+}
+
+// syncTicker 返回后台 goroutine 定期 fsync 使用的 ticker，
+// 未按时间间隔同步时返回永不触发的 ticker
+func (l *Logger) syncTicker() *ticker {
+	if l.SyncPolicy != SyncPeriodic || l.SyncEvery <= 0 {
+		return &ticker{}
+	}
+	return l.newTicker(l.SyncEvery)
+}
+
+// syncIfDirty 在有未落盘的数据时 fsync，由后台 goroutine 定期调用，
+// 因此写入停止后数据也能按时落盘
+func (l *Logger) syncIfDirty() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed || l.unsynced == 0 {
+		return
+	}
+	if err := l.syncFile(); err != nil {
+		l.reportError(OpSync, err)
+		l.logDebug("定期同步日志文件失败: %v，文件: %s", err, l.filename())
+	}
+}
@@ -0,0 +1,127 @@
+package lumberjack
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+// unsyncedBytes 返回上次 fsync 后写入的字节数
+func unsyncedBytes(l *Logger) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.unsynced
+}
+
+func TestSyncPolicyBytes(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestSyncPolicyBytes", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		SyncPolicy: SyncPeriodic,
+		SyncBytes:  6,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	equals(int64(4), unsyncedBytes(l), t)
+
+	_, err = l.Write([]byte("foo"))
+	isNil(err, t)
+	equals(int64(0), unsyncedBytes(l), t)
+
+	// SyncEvery 按距上次 fsync 的时间判断
+	l.SyncEvery = time.Hour
+	_, err = l.Write([]byte("bar"))
+	isNil(err, t)
+	equals(int64(3), unsyncedBytes(l), t)
+	newFakeTime()
+	_, err = l.Write([]byte("baz"))
+	isNil(err, t)
+	equals(int64(0), unsyncedBytes(l), t)
+}
+
+func TestSyncPolicyInterval(t *testing.T) {
+	dir := makeTempDir("TestSyncPolicyInterval", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		SyncPolicy: SyncPeriodic,
+		SyncEvery:  10 * time.Millisecond,
+	}
+	defer l.Close()
+
+	// 写入后不再有新的写入，由后台 goroutine 按间隔落盘
+	currentTime = fakeTime
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	for i := 0; i < 100 && unsyncedBytes(l) != 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	equals(int64(0), unsyncedBytes(l), t)
+}
+
+func TestSyncPolicyOther(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestSyncPolicyOther", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		SyncPolicy: SyncAlways,
+		BufferSize: 1024,
+	}
+	defer l.Close()
+
+	// 每次写入后落盘，缓冲区也会先被刷新
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	equals(int64(0), unsyncedBytes(l), t)
+	existsWithContent(logFile(dir), []byte("boo!"), t)
+
+	l2 := &Logger{Filename: logFile(dir) + ".other", SyncPolicy: "sometimes"}
+	defer l2.Close()
+	_, err = l2.Write([]byte("boo!"))
+	notNil(err, t)
+}
+
+// writeSyncer 与 zapcore.WriteSyncer 相同
+type writeSyncer interface {
+	io.Writer
+	Sync() error
+}
+
+var _ writeSyncer = (*Logger)(nil)
+
+func TestSync(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestSync", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		AsyncQueue: 8,
+		BufferSize: 1024,
+	}
+	defer l.Close()
+
+	// 尚未打开文件
+	isNil(l.Sync(), t)
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.Sync(), t)
+	existsWithContent(filename, []byte("boo!"), t)
+
+	isNil(l.Close(), t)
+	isNil(l.Sync(), t)
+}
@@ -0,0 +1,38 @@
+package lumberjack
+
+import "regexp"
+
+// Transform 在数据写入日志文件前对每次写入的内容进行变换，例如去除 ANSI
+// 颜色码、脱敏或添加时间戳。不能修改传入的切片，需要修改时应返回新的切片；
+// 返回空切片时本次写入不产生任何内容。实现必须可以被并发调用。
+type Transform func(p []byte) []byte
+
+// ansiEscape 匹配 ANSI CSI 控制序列，例如颜色码 "\x1b[31m"
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// StripANSI 是去除 ANSI 颜色码等控制序列的 Transform，适合把写给终端的彩色
+// 日志写入文件的场景
+func StripANSI(p []byte) []byte {
+	return ansiEscape.ReplaceAll(p, nil)
+}
+
+// applyTransforms 依次对 p 应用 Transforms，再按 RedactPatterns 和
+// RedactKeys 脱敏
+func (l *Logger) applyTransforms(p []byte) ([]byte, error) {
+	for _, t := range l.Transforms {
+		p = t(p)
+	}
+	redact, err := l.redactor()
+	if err != nil {
+		return nil, err
+	}
+	if redact != nil {
+		p = redact(p)
+	}
+	return p, nil
+}
+
+// transforming 判断写入的数据是否需要经过 Transforms 或脱敏
+func (l *Logger) transforming() bool {
+	return len(l.Transforms) > 0 || len(l.RedactPatterns) > 0 || len(l.RedactKeys) > 0
+}
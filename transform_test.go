@@ -0,0 +1,60 @@
+package lumberjack
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTransforms(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestTransforms", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	upper := func(p []byte) []byte { return bytes.ToUpper(p) }
+	prefix := func(p []byte) []byte { return append([]byte("> "), p...) }
+	l := &Logger{
+		Filename:   filename,
+		MaxSize:    12,
+		Transforms: []Transform{StripANSI, upper, prefix},
+	}
+	defer l.Close()
+
+	b := []byte("\x1b[31mred\x1b[0m\n")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+	existsWithContent(filename, []byte("> RED\n"), t)
+	// 按变换后的长度计算大小
+	equals(int64(6), l.CurrentSize(), t)
+
+	// 变换后超过 MaxSize 的剩余空间时轮转
+	_, err = l.Write([]byte("abcd\n"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("> ABCD\n"), t)
+	fileCount(dir, 2, t)
+}
+
+func TestTransformsReadFrom(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir("TestTransformsReadFrom", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		Transforms:   []Transform{StripANSI},
+		MaxLineBytes: 2,
+	}
+	defer l.Close()
+
+	n, err := l.ReadFrom(strings.NewReader("\x1b[1mbold\x1b[0m"))
+	isNil(err, t)
+	equals(int64(len("\x1b[1mbold\x1b[0m")), n, t)
+	existsWithContent(filename, []byte("bold"), t)
+}
@@ -0,0 +1,89 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// trashDir 返回回收目录的路径，相对路径相对于日志文件所在目录
+func (l *Logger) trashDir() string {
+	if filepath.IsAbs(l.TrashDir) {
+		return l.TrashDir
+	}
+	return filepath.Join(l.dir(), l.TrashDir)
+}
+
+// expire 删除过期的备份 name。配置了 TrashDir 时改为移入回收目录，同名文件
+// 已存在时追加 ".N" 后缀，校验和旁路文件随之移动。
+func (l *Logger) expire(name string) error {
+	size := l.auditSize(name)
+	if l.TrashDir == "" {
+		if err := l.removeFile(name); err != nil {
+			return err
+		}
+		l.audit(AuditDelete, name, "", size)
+		return nil
+	}
+	dir := l.trashDir()
+	if err := os.MkdirAll(dir, l.dirMode()); err != nil {
+		return fmt.Errorf("can't make trash directory: %s", err)
+	}
+	dst := filepath.Join(dir, filepath.Base(name))
+	for n := 1; ; n++ {
+		if _, err := os.Lstat(dst); os.IsNotExist(err) {
+			break
+		}
+		dst = filepath.Join(dir, filepath.Base(name)+"."+strconv.Itoa(n))
+	}
+	if err := moveFile(l.retryPolicy(), name, dst); err != nil {
+		return fmt.Errorf("can't move backup to trash: %s", err)
+	}
+	// 以移入时间作为修改时间，TrashTTL 从此时开始计算
+	now := l.now()
+	os.Chtimes(dst, now, now)
+	l.audit(AuditTrash, dst, name, size)
+	if err := moveChecksum(name, dst); err != nil {
+		return fmt.Errorf("can't move checksum to trash: %s", err)
+	}
+	return nil
+}
+
+// sweepTrash 删除回收目录中移入时间早于 TrashTTL 的该 Logger 的备份。
+// 回收目录可能由多个 Logger 共享，只处理文件名以本日志文件名开头的文件。
+func (l *Logger) sweepTrash() error {
+	if l.TrashDir == "" || l.TrashTTL <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(l.trashDir())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("can't read trash directory: %s", err)
+	}
+	base := filepath.Base(l.filename())
+	prefix, _ := l.prefixAndExt()
+	cutoff := l.now().Add(-l.TrashTTL)
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !(strings.HasPrefix(name, prefix) || strings.HasPrefix(name, base+".")) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		path := filepath.Join(l.trashDir(), name)
+		err = removeFileRetry(l.retryPolicy(), path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("can't sweep trash: %s", err)
+		}
+		if err == nil {
+			l.audit(AuditDelete, path, "", info.Size())
+		}
+	}
+	return nil
+}
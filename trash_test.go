@@ -0,0 +1,63 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTrashDir(t *testing.T) {
+	dir := makeTempDir("TestTrashDir", t)
+	defer os.RemoveAll(dir)
+
+	at := func(day int) time.Time {
+		return time.Date(2024, 3, day, 12, 0, 0, 0, time.UTC)
+	}
+	names := makeBackups(dir, t, at(20), at(19), at(18))
+	isNil(writeChecksum(filepath.Join(dir, names[2]), []byte{1, 2, 3}), t)
+
+	clock := &manualClock{now: at(21)}
+	l := &Logger{Filename: logFile(dir), MaxBackups: 1, TrashDir: "trash", TrashTTL: 24 * time.Hour, Clock: clock}
+	defer l.Close()
+	isNil(l.Validate(), t)
+
+	removed, err := l.Purge()
+	isNil(err, t)
+	equals(2, len(removed), t)
+
+	// 过期的备份连同校验和被移入回收目录
+	trash := filepath.Join(dir, "trash")
+	equals([]string{names[0], "trash"}, remainingBackups(dir, t), t)
+	existsWithContent(filepath.Join(trash, names[1]), []byte("boo!"), t)
+	exists(filepath.Join(trash, names[2]), t)
+	exists(filepath.Join(trash, names[2]+checksumSuffix), t)
+
+	// 同名文件已在回收目录中时追加序号
+	makeBackups(dir, t, at(19))
+	_, err = l.Purge()
+	isNil(err, t)
+	exists(filepath.Join(trash, names[1]+".1"), t)
+
+	// 其他 Logger 的文件不受影响；超过 TrashTTL 后被清理
+	other := filepath.Join(trash, "other.log")
+	isNil(os.WriteFile(other, []byte("x"), 0644), t)
+	isNil(os.Chtimes(other, at(1), at(1)), t)
+	clock.Advance(25 * time.Hour)
+	_, err = l.Purge()
+	isNil(err, t)
+	notExist(filepath.Join(trash, names[1]), t)
+	notExist(filepath.Join(trash, names[1]+".1"), t)
+	notExist(filepath.Join(trash, names[2]), t)
+	exists(other, t)
+}
+
+func TestTrashDirValidate(t *testing.T) {
+	dir := makeTempDir("TestTrashDirValidate", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), BackupDir: "old", TrashDir: "old"}
+	notNil(l.Validate(), t)
+	l = &Logger{Filename: logFile(dir), TrashDir: "."}
+	notNil(l.Validate(), t)
+}
@@ -0,0 +1,99 @@
+package lumberjack
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// checkLimits 校验 MaxSizeStr 与 MaxAgeStr
+func (l *Logger) checkLimits() error {
+	if l.MaxSizeStr != "" {
+		n, err := parseSize(l.MaxSizeStr)
+		if err != nil {
+			return fmt.Errorf("MaxSizeStr: %s", err)
+		}
+		if n == 0 {
+			return fmt.Errorf("MaxSizeStr: size %q must be positive", l.MaxSizeStr)
+		}
+	}
+	if l.MaxAgeStr != "" {
+		if _, err := parseDuration(l.MaxAgeStr); err != nil {
+			return fmt.Errorf("MaxAgeStr: %s", err)
+		}
+	}
+	return nil
+}
+
+// sizeUnits 是 parseSize 接受的单位，十进制与二进制写法都按 1024 进制换算，
+// 与 MaxSize 中 MB 的含义一致
+var sizeUnits = []struct {
+	suffix string
+	n      int64
+}{
+	{"kib", 1 << 10}, {"mib", 1 << 20}, {"gib", 1 << 30}, {"tib", 1 << 40},
+	{"kb", 1 << 10}, {"mb", 1 << 20}, {"gb", 1 << 30}, {"tb", 1 << 40},
+	{"k", 1 << 10}, {"m", 1 << 20}, {"g", 1 << 30}, {"t", 1 << 40},
+	{"b", 1},
+}
+
+// parseSize 把 "100MiB"、"1.5GB"、"512" 这样的字符串解析为字节数，
+// 没有单位时按字节计算
+func parseSize(s string) (int64, error) {
+	str := strings.ToLower(strings.TrimSpace(s))
+	mult := int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(str, u.suffix) {
+			str = strings.TrimSpace(strings.TrimSuffix(str, u.suffix))
+			mult = u.n
+			break
+		}
+	}
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(f * float64(mult)), nil
+}
+
+// durationPart 匹配时长字符串中的一段，例如 "1.5h" 或 "30d"
+var durationPart = regexp.MustCompile(`^([0-9]*\.?[0-9]+)([a-zµμ]+)`)
+
+// parseDuration 与 time.ParseDuration 相同，另外接受 "d"（天，24 小时）与
+// "w"（周，7 天）两个单位，例如 "30d"、"1w"、"1d12h"
+func parseDuration(s string) (time.Duration, error) {
+	str := strings.TrimSpace(s)
+	if str == "" {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	var total time.Duration
+	for rest := str; rest != ""; {
+		m := durationPart.FindStringSubmatch(rest)
+		if m == nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		rest = rest[len(m[0]):]
+		var d time.Duration
+		switch m[2] {
+		case "d", "w":
+			f, err := strconv.ParseFloat(m[1], 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			unit := 24 * time.Hour
+			if m[2] == "w" {
+				unit *= 7
+			}
+			d = time.Duration(f * float64(unit))
+		default:
+			var err error
+			if d, err = time.ParseDuration(m[0]); err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+		}
+		total += d
+	}
+	return total, nil
+}
@@ -0,0 +1,99 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	for s, want := range map[string]int64{
+		"512":     512,
+		"10B":     10,
+		"4k":      4096,
+		"100MiB":  100 << 20,
+		"100 MB":  100 << 20,
+		"1.5GiB":  3 << 29,
+		"2TB":     2 << 40,
+		" 1gb ":   1 << 30,
+		"0.5 KiB": 512,
+	} {
+		got, err := parseSize(s)
+		isNil(err, t)
+		equals(want, got, t)
+	}
+	for _, s := range []string{"", "MiB", "-1MB", "ten"} {
+		_, err := parseSize(s)
+		assert(err != nil, t, "expected error for %q", s)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for s, want := range map[string]time.Duration{
+		"72h":    72 * time.Hour,
+		"30m":    30 * time.Minute,
+		"30d":    30 * 24 * time.Hour,
+		"1w":     7 * 24 * time.Hour,
+		"1d12h":  36 * time.Hour,
+		"0.5d":   12 * time.Hour,
+		"1h30m":  90 * time.Minute,
+		" 2d ":   48 * time.Hour,
+		"1w1d1s": 8*24*time.Hour + time.Second,
+	} {
+		got, err := parseDuration(s)
+		isNil(err, t)
+		equals(want, got, t)
+	}
+	for _, s := range []string{"", "30", "d", "-1d", "1y", "1d 2h"} {
+		_, err := parseDuration(s)
+		assert(err != nil, t, "expected error for %q", s)
+	}
+}
+
+func TestMaxSizeStr(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestMaxSizeStr", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxSize:    100,
+		MaxSizeStr: "10B",
+	}
+	defer l.Close()
+	equals(int64(10), l.max(), t)
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	_, err = l.Write([]byte("foooooo!"))
+	isNil(err, t)
+
+	// 超过 MaxSizeStr 后轮转
+	existsWithContent(logFile(dir), []byte("foooooo!"), t)
+	existsWithContent(backupFile(dir), []byte("boo!"), t)
+
+	_, err = l.Write([]byte("this is too long"))
+	notNil(err, t)
+}
+
+func TestMaxAgeStr(t *testing.T) {
+	equals(30*24*time.Hour, (&Logger{MaxAge: 1, MaxAgeStr: "30d"}).maxAge(), t)
+	equals(24*time.Hour, (&Logger{MaxAge: 1}).maxAge(), t)
+}
+
+func TestInvalidLimitStr(t *testing.T) {
+	dir := makeTempDir("TestInvalidLimitStr", t)
+	defer os.RemoveAll(dir)
+
+	for _, l := range []*Logger{
+		{Filename: logFile(dir), MaxSizeStr: "big"},
+		{Filename: logFile(dir), MaxSizeStr: "0MB"},
+		{Filename: logFile(dir), MaxAgeStr: "a month"},
+	} {
+		notNil(l.Validate(), t)
+		_, err := l.Write([]byte("boo!"))
+		notNil(err, t)
+		notExist(logFile(dir), t)
+		isNil(l.Close(), t)
+	}
+}
@@ -0,0 +1,259 @@
+package lumberjack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Uploader 把轮转后处理完毕（压缩、加密之后）的备份上传到远端存储。path 为
+// 本地备份文件的路径，实现负责读取文件并决定远端的对象名。Upload 在后台
+// goroutine（设置 CompressSync 时在轮转过程中）调用，可能与其他 Logger 的
+// 上传并发执行。
+type Uploader interface {
+	Upload(ctx context.Context, path string) error
+}
+
+// UploaderFunc 把普通函数适配为 Uploader
+type UploaderFunc func(ctx context.Context, path string) error
+
+// Upload 调用 f(ctx, path)
+func (f UploaderFunc) Upload(ctx context.Context, path string) error {
+	return f(ctx, path)
+}
+
+// ParseUploadTarget 按 URL 创建内置的上传目标：
+//
+//	gs://bucket/prefix              上传到 Google Cloud Storage，见 GCSUploader
+//	azblob://account/container/prefix  上传到 Azure Blob Storage，见 AzureBlobUploader
+//	sftp://user@host[:port]/dir     通过 SFTP 上传到远端目录，见 SFTPUploader
+//	https://host/path               以 POST 请求发送到该地址，见 HTTPUploader
+//
+// prefix 可以省略，对象名为 prefix 与备份文件名拼接的结果。sftp 目标通过
+// ssh-agent 认证，按 ~/.ssh/known_hosts 校验主机密钥；https 目标的
+// Authorization 头取自环境变量 LUMBERJACK_UPLOAD_AUTHORIZATION。sftp 和
+// https 目标失败时重试 3 次。
+func ParseUploadTarget(target string) (Uploader, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upload target %q: %s", target, err)
+	}
+	switch u.Scheme {
+	case "gs":
+		if u.Host == "" {
+			return nil, fmt.Errorf("upload target %q has no bucket", target)
+		}
+		return &GCSUploader{Bucket: u.Host, Prefix: strings.Trim(u.Path, "/")}, nil
+	case "azblob":
+		container, prefix, _ := strings.Cut(strings.Trim(u.Path, "/"), "/")
+		if u.Host == "" || container == "" {
+			return nil, fmt.Errorf("upload target %q must be azblob://account/container[/prefix]", target)
+		}
+		return &AzureBlobUploader{
+			ContainerURL: "https://" + u.Host + ".blob.core.windows.net/" + container,
+			SASToken:     os.Getenv("AZURE_STORAGE_SAS_TOKEN"),
+			Prefix:       prefix,
+		}, nil
+	case "sftp":
+		if u.Hostname() == "" || u.User.Username() == "" {
+			return nil, fmt.Errorf("upload target %q must be sftp://user@host[:port]/dir", target)
+		}
+		port := u.Port()
+		if port == "" {
+			port = "22"
+		}
+		return &SFTPUploader{
+			Addr:      net.JoinHostPort(u.Hostname(), port),
+			Config:    sftpTargetConfig(u.User.Username()),
+			Dir:       u.Path,
+			Retries:   3,
+			agentAuth: true,
+		}, nil
+	case "http", "https":
+		if u.Host == "" {
+			return nil, fmt.Errorf("upload target %q has no host", target)
+		}
+		return &HTTPUploader{
+			URL:           target,
+			Authorization: os.Getenv("LUMBERJACK_UPLOAD_AUTHORIZATION"),
+			Retries:       3,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown upload target scheme %q", u.Scheme)
+}
+
+// defaultUploadTimeout 是 UploadTimeout 的默认值
+const defaultUploadTimeout = 5 * time.Minute
+
+// uploadTimeout 返回单个备份上传的最长时间
+func (l *Logger) uploadTimeout() time.Duration {
+	if l.UploadTimeout > 0 {
+		return l.UploadTimeout
+	}
+	return defaultUploadTimeout
+}
+
+// millContext 返回后台上传和 Processors 使用的 context，Close 时被取消。
+// 后台 goroutine 尚未启动时（CompressSync 下的首次轮转）返回
+// context.Background()。
+func (l *Logger) millContext() context.Context {
+	if l.millCtx == nil {
+		return context.Background()
+	}
+	return l.millCtx
+}
+
+// cancelMill 取消 millContext，使正在进行的上传和 Processors 尽快返回
+func (l *Logger) cancelMill() {
+	if stop, ok := l.millStop.Load().(context.CancelFunc); ok {
+		stop()
+	}
+}
+
+// permanentError 标记重试也无法成功的错误，例如上传被服务器拒绝
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// retryBackoff 调用 fn，失败时最多重试 retries 次。第一次重试前按 c 等待 delay
+// （默认 1 秒），之后每次翻倍。fn 返回 *permanentError 或 ctx 结束时不再重试。
+func retryBackoff(ctx context.Context, c Clock, retries int, delay time.Duration, fn func() error) error {
+	if delay <= 0 {
+		delay = time.Second
+	}
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		var perm *permanentError
+		if errors.As(err, &perm) {
+			return perm.err
+		}
+		if attempt >= retries {
+			return err
+		}
+		if !sleep(ctx, c, delay) {
+			return err
+		}
+		delay *= 2
+	}
+}
+
+// objectName 返回备份 name 上传后的对象名
+func objectName(prefix, name string) string {
+	return path.Join(prefix, filepath.Base(name))
+}
+
+// uploader 返回生效的上传目标：优先使用 Uploader，其次按 UploadTarget 创建。
+// 都未设置时返回 nil。
+func (l *Logger) uploader() (Uploader, error) {
+	if l.Uploader != nil {
+		return l.Uploader, nil
+	}
+	if l.UploadTarget == "" {
+		return nil, nil
+	}
+	l.uploadMu.Lock()
+	defer l.uploadMu.Unlock()
+	if l.targetUploader == nil {
+		// 创建失败（例如凭据文件暂时不可读）时下次后台处理再试
+		u, err := ParseUploadTarget(l.UploadTarget)
+		if err != nil {
+			return nil, err
+		}
+		l.targetUploader = u
+	}
+	return l.targetUploader, nil
+}
+
+// backupQueue 记录轮转产生、等待后台处理的备份路径，可以被并发使用
+type backupQueue struct {
+	mu    sync.Mutex
+	names []string
+}
+
+// push 把备份追加到队尾
+func (q *backupQueue) push(name string) {
+	q.mu.Lock()
+	q.names = append(q.names, name)
+	q.mu.Unlock()
+}
+
+// take 取出队列中的全部备份
+func (q *backupQueue) take() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	names := q.names
+	q.names = nil
+	return names
+}
+
+// requeue 把处理失败的备份放回队首，下次优先处理
+func (q *backupQueue) requeue(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	q.mu.Lock()
+	q.names = append(names, q.names...)
+	q.mu.Unlock()
+}
+
+// queueUpload 登记一个刚轮转出的备份，由之后的后台处理负责上传
+func (l *Logger) queueUpload(name string) {
+	if l.Uploader == nil && l.UploadTarget == "" {
+		return
+	}
+	l.uploads.push(name)
+}
+
+// uploadPending 上传已登记的备份，备份此时已完成压缩和加密。失败的备份通过
+// Errors 通道上报并保留在队列中，下次后台处理时重试；已被清理删除的备份不再
+// 上传。
+func (l *Logger) uploadPending() {
+	pending := l.uploads.take()
+	if len(pending) == 0 {
+		return
+	}
+
+	u, err := l.uploader()
+	if err != nil {
+		l.reportError(OpUpload, err)
+		l.uploads.requeue(pending)
+		return
+	}
+
+	var failed []string
+	for _, name := range pending {
+		fn := l.processedName(name)
+		info, err := os.Stat(fn)
+		if err != nil {
+			continue
+		}
+		end := l.startOp(OpUpload, fn)
+		ctx, cancel := context.WithTimeout(l.millContext(), l.uploadTimeout())
+		err = u.Upload(ctx, fn)
+		cancel()
+		end(err)
+		if err != nil {
+			l.reportError(OpUpload, fmt.Errorf("can't upload %s: %w", fn, err))
+			failed = append(failed, name)
+			continue
+		}
+		l.metrics.uploads.Add(1)
+		l.audit(AuditUpload, fn, "", info.Size())
+		l.markUploaded(fn)
+	}
+	l.uploads.requeue(failed)
+}
@@ -0,0 +1,225 @@
+package lumberjack
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUploadAfterRotate(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestUploadAfterRotate", t)
+	defer os.RemoveAll(dir)
+
+	var (
+		mu       sync.Mutex
+		uploaded []string
+	)
+	l := &Logger{
+		Filename:     logFile(dir),
+		Compress:     true,
+		CompressSync: true,
+		Uploader: UploaderFunc(func(ctx context.Context, path string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			uploaded = append(uploaded, path)
+			return nil
+		}),
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// 上传的是压缩后的备份
+	mu.Lock()
+	equals([]string{backupFile(dir) + compressSuffix}, uploaded, t)
+	mu.Unlock()
+	equals(int64(1), l.Stats().Uploads, t)
+}
+
+func TestUploadRetry(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestUploadRetry", t)
+	defer os.RemoveAll(dir)
+
+	fail := true
+	var uploaded []string
+	l := &Logger{
+		Filename:     logFile(dir),
+		CompressSync: true,
+		Uploader: UploaderFunc(func(ctx context.Context, path string) error {
+			if fail {
+				return errors.New("unavailable")
+			}
+			uploaded = append(uploaded, filepath.Base(path))
+			return nil
+		}),
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	first := backupFile(dir)
+	isNil(l.Rotate(), t)
+
+	select {
+	case err := <-l.Errors():
+		var ae *AsyncError
+		assert(errors.As(err, &ae), t, "expected *AsyncError, got %T", err)
+		equals(OpUpload, ae.Op, t)
+	default:
+		t.Fatal("expected upload error")
+	}
+	equals(0, len(uploaded), t)
+
+	// 失败的备份在下次轮转时与新备份一起上传
+	fail = false
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+	newFakeTime()
+	second := backupFile(dir)
+	isNil(l.Rotate(), t)
+	equals([]string{filepath.Base(first), filepath.Base(second)}, uploaded, t)
+}
+
+func TestUploadTargetError(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestUploadTargetError", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:     logFile(dir),
+		CompressSync: true,
+		UploadTarget: "sftp://loghost/logs",
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	first := backupFile(dir)
+	isNil(l.Rotate(), t)
+	isNil(l.WaitIdle(context.Background()), t)
+
+	select {
+	case err := <-l.Errors():
+		var ae *AsyncError
+		assert(errors.As(err, &ae), t, "expected *AsyncError, got %T", err)
+		equals(OpUpload, ae.Op, t)
+	default:
+		t.Fatal("expected upload error")
+	}
+
+	// 无法创建上传目标时备份仍留在队列中，下次后台处理时上传
+	var uploaded []string
+	l.Uploader = UploaderFunc(func(ctx context.Context, path string) error {
+		uploaded = append(uploaded, filepath.Base(path))
+		return nil
+	})
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+	newFakeTime()
+	second := backupFile(dir)
+	isNil(l.Rotate(), t)
+	isNil(l.WaitIdle(context.Background()), t)
+	equals([]string{filepath.Base(first), filepath.Base(second)}, uploaded, t)
+}
+
+func TestParseUploadTarget(t *testing.T) {
+	u, err := ParseUploadTarget("gs://bucket/logs/app/")
+	isNil(err, t)
+	g, ok := u.(*GCSUploader)
+	assert(ok, t, "expected *GCSUploader, got %T", u)
+	equals("bucket", g.Bucket, t)
+	equals("logs/app", g.Prefix, t)
+
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "sv=1&sig=x")
+	u, err = ParseUploadTarget("azblob://account/container/logs")
+	isNil(err, t)
+	a, ok := u.(*AzureBlobUploader)
+	assert(ok, t, "expected *AzureBlobUploader, got %T", u)
+	equals("https://account.blob.core.windows.net/container", a.ContainerURL, t)
+	equals("sv=1&sig=x", a.SASToken, t)
+	equals("logs", a.Prefix, t)
+
+	for _, target := range []string{"gs:///logs", "azblob://account", "s4://bucket", "://"} {
+		_, err := ParseUploadTarget(target)
+		assert(err != nil, t, "expected error for %q", target)
+	}
+
+	l := &Logger{UploadTarget: "ftp://host/logs"}
+	notNil(l.Validate(), t)
+}
+
+func TestUploadCanceledOnClose(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestUploadCanceledOnClose", t)
+	defer os.RemoveAll(dir)
+
+	started := make(chan struct{})
+	l := &Logger{
+		Filename: logFile(dir),
+		Uploader: UploaderFunc(func(ctx context.Context, path string) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+	}
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	<-started
+
+	// 卡住的上传不能阻止 Close 返回
+	closed := make(chan error, 1)
+	go func() { closed <- l.Close() }()
+	select {
+	case err := <-closed:
+		isNil(err, t)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close blocked on a hung upload")
+	}
+}
+
+func TestUploadTimeout(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestUploadTimeout", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:      logFile(dir),
+		CompressSync:  true,
+		UploadTimeout: 10 * time.Millisecond,
+		Uploader: UploaderFunc(func(ctx context.Context, path string) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// 超时的上传被放弃并上报，备份留在队列中等待重试
+	err = <-l.Errors()
+	assert(errors.Is(err, context.DeadlineExceeded), t, "expected deadline exceeded, got %v", err)
+	equals(int64(0), l.Stats().Uploads, t)
+	// 后台 goroutine 可能同时取走了队列，等它放回
+	waitFor(func() bool {
+		l.uploads.mu.Lock()
+		defer l.uploads.mu.Unlock()
+		return len(l.uploads.names) == 1
+	}, t)
+}
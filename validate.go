@@ -0,0 +1,123 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// Validate 检查配置是否有效，返回发现的第一个错误。除读取 EncryptionKeyFile
+// 外不访问文件系统，也不会打开日志文件。未经 Validate 的配置错误会在首次写入
+// 或轮转时才返回。
+func (l *Logger) Validate() error {
+	for _, f := range []struct {
+		name  string
+		value int64
+	}{
+		{"MaxSize", int64(l.MaxSize)},
+		{"MaxLines", int64(l.MaxLines)},
+		{"MaxLineBytes", int64(l.MaxLineBytes)},
+		{"MaxAge", int64(l.MaxAge)},
+		{"MaxAgeDuration", int64(l.MaxAgeDuration)},
+		{"MaxBackups", int64(l.MaxBackups)},
+		{"KeepDaily", int64(l.KeepDaily)},
+		{"KeepWeekly", int64(l.KeepWeekly)},
+		{"KeepMonthly", int64(l.KeepMonthly)},
+		{"ThinAfter", int64(l.ThinAfter)},
+		{"TrashTTL", int64(l.TrashTTL)},
+		{"MaxTotalSize", l.MaxTotalSize},
+		{"MinDiskFree", int64(l.MinDiskFree)},
+		{"DiskCheckInterval", int64(l.DiskCheckInterval)},
+		{"BufferSize", int64(l.BufferSize)},
+		{"MaxBytesPerSecond", l.MaxBytesPerSecond},
+		{"AsyncQueue", int64(l.AsyncQueue)},
+		{"SyncEvery", int64(l.SyncEvery)},
+		{"SyncBytes", int64(l.SyncBytes)},
+		{"CompressWorkers", int64(l.CompressWorkers)},
+		{"ReopenCheckInterval", int64(l.ReopenCheckInterval)},
+		{"RotationInterval", int64(l.RotationInterval)},
+		{"StreamFlushInterval", int64(l.StreamFlushInterval)},
+		{"ProcessorRetries", int64(l.ProcessorRetries)},
+		{"ProcessorRetryDelay", int64(l.ProcessorRetryDelay)},
+		{"UploadTimeout", int64(l.UploadTimeout)},
+	} {
+		if f.value < 0 {
+			return fmt.Errorf("%s must not be negative", f.name)
+		}
+	}
+
+	if _, err := l.compressor(); err != nil {
+		return err
+	}
+	if err := validateTimeFormat(l.timeFormat()); err != nil {
+		return err
+	}
+	if l.NumberedBackups && l.FilenamePattern != "" {
+		return errors.New("NumberedBackups cannot be combined with FilenamePattern")
+	}
+	if _, err := l.filenamePattern(); err != nil {
+		return err
+	}
+	if l.TimeZone != "" {
+		if _, err := loadLocation(l.TimeZone); err != nil {
+			return err
+		}
+	}
+	if _, err := l.nextRotation(l.now()); err != nil {
+		return err
+	}
+	if err := l.checkACL(); err != nil {
+		return err
+	}
+	if err := l.checkLimits(); err != nil {
+		return err
+	}
+	switch l.DropPolicy {
+	case "", DropBlock, DropNewest, DropOldest:
+	default:
+		return fmt.Errorf("unknown drop policy %q", l.DropPolicy)
+	}
+	switch l.RateLimitMode {
+	case "", RateLimitBlock, RateLimitDrop:
+	default:
+		return fmt.Errorf("unknown rate limit mode %q", l.RateLimitMode)
+	}
+	if l.DiskUsageWatermark < 0 || l.DiskUsageWatermark > 100 {
+		return errors.New("DiskUsageWatermark must be between 0 and 100")
+	}
+	if _, err := l.redactor(); err != nil {
+		return err
+	}
+	if l.TrashDir != "" && filepath.Clean(l.trashDir()) == filepath.Clean(l.backupDir()) {
+		return errors.New("TrashDir must differ from the backup directory")
+	}
+	switch l.ThinKeep {
+	case "", ThinKeepFirst, ThinKeepLast:
+	default:
+		return fmt.Errorf("unknown thin keep %q", l.ThinKeep)
+	}
+	switch l.SyncPolicy {
+	case "", SyncNever, SyncAlways, SyncPeriodic:
+	default:
+		return fmt.Errorf("unknown sync policy %q", l.SyncPolicy)
+	}
+	if _, err := l.encrypter(); err != nil {
+		return err
+	}
+	if err := l.checkFilesystem(); err != nil {
+		return err
+	}
+	if err := l.checkStream(); err != nil {
+		return err
+	}
+	if err := l.checkProcessors(); err != nil {
+		return err
+	}
+	if _, err := l.uploader(); err != nil {
+		return err
+	}
+	if len(l.PostRotateCommand) > 0 && l.PostRotateCommand[0] == "" {
+		return errors.New("PostRotateCommand has an empty program name")
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package lumberjack
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	isNil((&Logger{}).Validate(), t)
+	isNil((&Logger{
+		Filename:         "foo.log",
+		MaxSize:          10,
+		Compression:      "gzip",
+		RotationInterval: time.Hour,
+		DropPolicy:       DropOldest,
+		SyncPolicy:       SyncPeriodic,
+		CopyACL:          ACLFromPrevious,
+	}).Validate(), t)
+
+	for name, l := range map[string]*Logger{
+		"negative size":   {MaxSize: -1},
+		"negative age":    {MaxAgeDuration: -time.Hour},
+		"compression":     {Compression: "lz4"},
+		"time format":     {BackupTimeFormat: "no-time"},
+		"pattern":         {FilenamePattern: "a/{timestamp}"},
+		"numbered":        {NumberedBackups: true, FilenamePattern: "{seq}.log"},
+		"schedule":        {RotateSchedule: "whenever"},
+		"acl":             {CopyACL: "parent"},
+		"drop policy":     {DropPolicy: "drop-all"},
+		"sync policy":     {SyncPolicy: "sometimes"},
+		"encryption key":  {Encrypt: true},
+		"encrypt and age": {Encrypt: true, EncryptionKey: make([]byte, 32), AgeRecipients: []string{"age1"}},
+	} {
+		assert(l.Validate() != nil, t, "expected error for %s", name)
+	}
+}
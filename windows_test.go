@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"golang.org/x/sys/windows"
 )
 
 // TestWindowsFileRotation 测试 Windows 平台上的文件轮转
@@ -202,3 +205,156 @@ func TestWindowsFileShareMode(t *testing.T) {
 
 	t.Log("文件共享模式测试成功")
 }
+
+// TestWindowsNamedMutex 测试命名互斥量在不同 Logger 之间互斥，且不创建锁文件
+func TestWindowsNamedMutex(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "test.log")
+
+	m1, err := newNamedMutex(mutexName(filename))
+	if err != nil {
+		t.Fatalf("创建命名互斥量失败: %v", err)
+	}
+	defer m1.close()
+	m2, err := newNamedMutex(mutexName(filename))
+	if err != nil {
+		t.Fatalf("打开命名互斥量失败: %v", err)
+	}
+	defer m2.close()
+
+	if err := m1.lock(); err != nil {
+		t.Fatalf("获取互斥量失败: %v", err)
+	}
+	acquired := make(chan struct{})
+	go func() {
+		if err := m2.lock(); err == nil {
+			close(acquired)
+			m2.unlock()
+		}
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("互斥量被持有时不应被再次获取")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err := m1.unlock(); err != nil {
+		t.Fatalf("释放互斥量失败: %v", err)
+	}
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("释放后应能获取互斥量")
+	}
+
+	l := &Logger{Filename: filename, NamedMutex: true}
+	defer l.Close()
+	if _, err := l.Write([]byte("test\n")); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	if _, err := os.Stat(filename + lockSuffix); !os.IsNotExist(err) {
+		t.Errorf("使用命名互斥量时不应创建锁文件: %v", err)
+	}
+}
+
+// TestWindowsLongPath 测试超过 MAX_PATH 的日志路径可以正常写入和轮转
+func TestWindowsLongPath(t *testing.T) {
+	short := `C:\logs\app.log`
+	if got := fixLongPath(short); got != short {
+		t.Errorf("短路径不应被修改: %s", got)
+	}
+	long := `C:\` + strings.Repeat(`a\`, 130) + "app.log"
+	if got := fixLongPath(long); got != `\\?\`+long {
+		t.Errorf("长路径应加上前缀: %s", got)
+	}
+	unc := `\\server\share\` + strings.Repeat(`a\`, 130) + "app.log"
+	if got := fixLongPath(unc); got != `\\?\UNC\server\share\`+strings.Repeat(`a\`, 130)+"app.log" {
+		t.Errorf("UNC 长路径应转换为 \\\\?\\UNC 形式: %s", got)
+	}
+	if got := fixLongPath(`\\?\` + long); got != `\\?\`+long {
+		t.Errorf("已有前缀的路径不应被修改: %s", got)
+	}
+
+	dir := filepath.Join(t.TempDir(), strings.Repeat("deep-directory-name\\", 15))
+	filename := filepath.Join(dir, "test.log")
+	l := &Logger{Filename: filename}
+	defer l.Close()
+
+	if _, err := l.Write([]byte("test\n")); err != nil {
+		t.Fatalf("写入长路径失败: %v", err)
+	}
+	if err := l.Rotate(); err != nil {
+		t.Fatalf("轮转长路径失败: %v", err)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("读取目录失败: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("期望 2 个文件，实际 %d 个", len(files))
+	}
+}
+
+// TestWindowsWriteThroughRename 测试 MoveFileEx 重命名在文件被打开时也能完成
+func TestWindowsWriteThroughRename(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.log")
+	dst := filepath.Join(dir, "dst.log")
+
+	f, err := openFile(src, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("创建文件失败: %v", err)
+	}
+	defer f.Close()
+	if err := moveFileWriteThrough(src, dst); err != nil {
+		t.Fatalf("MoveFileEx 重命名失败: %v", err)
+	}
+	if _, err := os.Stat(dst); err != nil {
+		t.Errorf("重命名后目标文件不存在: %v", err)
+	}
+}
+
+// TestWindowsCopyACL 测试轮转后新文件复制了旧文件的 DACL
+func TestWindowsCopyACL(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "test.log")
+	l := &Logger{Filename: filename, CopyACL: ACLFromPrevious}
+	defer l.Close()
+
+	if _, err := l.Write([]byte("test\n")); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+
+	// 为当前日志文件设置受保护的 DACL，仅 SYSTEM、管理员与文件所有者可以访问
+	sd, err := windows.SecurityDescriptorFromString("D:P(A;;FA;;;SY)(A;;FA;;;BA)(A;;FA;;;OW)")
+	if err != nil {
+		t.Fatalf("解析安全描述符失败: %v", err)
+	}
+	dacl, _, err := sd.DACL()
+	if err != nil {
+		t.Fatalf("读取 DACL 失败: %v", err)
+	}
+	err = windows.SetNamedSecurityInfo(filename, windows.SE_FILE_OBJECT,
+		windows.DACL_SECURITY_INFORMATION|windows.PROTECTED_DACL_SECURITY_INFORMATION, nil, nil, dacl, nil)
+	if err != nil {
+		t.Fatalf("设置 DACL 失败: %v", err)
+	}
+
+	if err := l.Rotate(); err != nil {
+		t.Fatalf("轮转失败: %v", err)
+	}
+	if l.lastBackup == "" {
+		t.Fatalf("轮转后没有产生备份")
+	}
+
+	daclString := func(name string) string {
+		sd, err := windows.GetNamedSecurityInfo(name, windows.SE_FILE_OBJECT, windows.DACL_SECURITY_INFORMATION)
+		if err != nil {
+			t.Fatalf("读取 %s 的安全描述符失败: %v", name, err)
+		}
+		return sd.String()
+	}
+	if got, want := daclString(filename), daclString(l.lastBackup); got != want {
+		t.Errorf("新文件的 DACL 为 %s，期望与旧文件相同: %s", got, want)
+	}
+}